@@ -0,0 +1,342 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/joho/godotenv"
+	"github.com/julietrb1/offair-cli/fbo"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// scoreWeightPrefix starts the names of environment variables that override scorer weights
+const scoreWeightPrefix = "FBO_SCORE_"
+
+// CandidateFilterFields are the names of the candidate filter settings, read from FBO_FILTER_<NAME>
+// environment variables or the equivalent query parameters
+var CandidateFilterFields = []string{
+	"types", "countries", "states", "min_size", "max_size", "surfaces", "military", "max_elevation", "exclude",
+}
+
+// Config holds the FBO analysis settings
+type Config struct {
+	OptimalDistance     float64                `json:"optimal_distance"`
+	MaxDistance         float64                `json:"max_distance"`
+	RequireLights       bool                   `json:"require_lights"`
+	PreferredSize       *int                   `json:"preferred_size"`
+	RedundancyThreshold float64                `json:"redundancy_threshold"`
+	CoverageRadius      float64                `json:"coverage_radius"`
+	RelocationRadius    float64                `json:"relocation_radius"`
+	Rebalance           fbo.RebalanceBudget    `json:"rebalance"`
+	Clustering          fbo.ClusterSettings    `json:"clustering"`
+	Simulation          fbo.SimulationSettings `json:"simulation"`
+	CorridorWidth       float64                `json:"corridor_width"`
+	CandidateFilter     fbo.CandidateFilter    `json:"candidate_filter"`
+	ScorerName          string                 `json:"scorer"`
+	ScoreWeights        map[string]float64     `json:"score_weights,omitempty"`
+}
+
+// FilePath returns the path of the config file, ~/.offair/config.env
+func FilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".offair", "config.env"), nil
+}
+
+// LoadFile loads settings from the config file into the environment, if the file exists.
+// Variables already set, such as from a .env file, take precedence.
+func LoadFile() error {
+	path, err := FilePath()
+	if err != nil {
+		return err
+	}
+
+	if err := godotenv.Load(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("error loading config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// NewScorer creates the configured scorer with any configured weights
+func (c Config) NewScorer() (fbo.Scorer, error) {
+	return fbo.NewScorer(c.ScorerName, c.ScoreWeights, c.CoverageRadius)
+}
+
+// DemandWeights returns the weights airport demand is derived with: the demand scorer's when it's configured, so
+// any demand weight overrides apply, or the defaults otherwise
+func (c Config) DemandWeights() fbo.DemandWeights {
+	if scorer, err := c.NewScorer(); err == nil {
+		if demandScorer, ok := scorer.(fbo.DemandScorer); ok {
+			return demandScorer.Demand
+		}
+	}
+	return fbo.DefaultDemandWeights()
+}
+
+// SimulationSettings returns the job simulation settings, with the range set to the maximum distance unless
+// FBO_NM_SIM_RANGE sets one
+func (c Config) SimulationSettings() fbo.SimulationSettings {
+	settings := c.Simulation
+	if settings.Range <= 0 {
+		settings.Range = c.MaxDistance
+	}
+	return settings
+}
+
+// SweepRanges returns the default parameter sweep: the optimal and max distances 25% either side of the configured
+// ones, with and without lights required, keeping the top 10 candidates
+func (c Config) SweepRanges() fbo.SweepRanges {
+	return fbo.SweepRanges{
+		OptimalDistances: []float64{c.OptimalDistance * 0.75, c.OptimalDistance, c.OptimalDistance * 1.25},
+		MaxDistances:     []float64{c.MaxDistance * 0.75, c.MaxDistance, c.MaxDistance * 1.25},
+		RequireLights:    []bool{true, false},
+		TopN:             10,
+	}
+}
+
+// ParseDistances parses a comma-separated list of distances in nm
+func ParseDistances(value string) ([]float64, error) {
+	var distances []float64
+	for _, item := range parseList(value, false) {
+		distance, err := strconv.ParseFloat(item, 64)
+		if err != nil || distance <= 0 {
+			return nil, fmt.Errorf("distances must be numbers greater than zero")
+		}
+		distances = append(distances, distance)
+	}
+	return distances, nil
+}
+
+// Load reads the FBO analysis settings from environment variables, using defaults for any that aren't set
+func Load() Config {
+	var cfg Config
+
+	optimalDistanceStr := os.Getenv("FBO_NM_OPTIMAL")
+	if optimalDistanceStr == "" {
+		optimalDistanceStr = "800" // Default value when environment variable is not set
+	}
+	cfg.OptimalDistance, _ = strconv.ParseFloat(optimalDistanceStr, 64)
+
+	maxDistanceStr := os.Getenv("FBO_NM_MAX")
+	if maxDistanceStr == "" {
+		maxDistanceStr = "1200" // Default value when environment variable is not set
+	}
+	cfg.MaxDistance, _ = strconv.ParseFloat(maxDistanceStr, 64)
+
+	// Get FBO_REQ_LIGHTS environment variable (default to "true")
+	requireLightsStr := os.Getenv("FBO_REQ_LIGHTS")
+	if requireLightsStr == "" {
+		requireLightsStr = "true" // Default value when environment variable is not set
+	}
+	cfg.RequireLights = requireLightsStr == "true"
+
+	// Get FBO_PREFERRED_SIZE environment variable (no default)
+	cfg.PreferredSize = ParsePreferredSize(os.Getenv("FBO_PREFERRED_SIZE"))
+
+	// Get FBO_REDUNDANCY_THRESHOLD environment variable (default to "100.0")
+	redundancyThresholdStr := os.Getenv("FBO_REDUNDANCY_THRESHOLD")
+	if redundancyThresholdStr == "" {
+		redundancyThresholdStr = "100.0" // Default value when environment variable is not set
+	}
+	cfg.RedundancyThreshold, _ = strconv.ParseFloat(redundancyThresholdStr, 64)
+
+	// Get FBO_NM_COVERAGE environment variable (default to half the optimal distance)
+	cfg.CoverageRadius, _ = strconv.ParseFloat(os.Getenv("FBO_NM_COVERAGE"), 64)
+	if cfg.CoverageRadius <= 0 {
+		cfg.CoverageRadius = cfg.OptimalDistance / 2
+	}
+
+	// Get FBO_NM_RELOCATION environment variable (default to a quarter of the optimal distance)
+	cfg.RelocationRadius, _ = strconv.ParseFloat(os.Getenv("FBO_NM_RELOCATION"), 64)
+	if cfg.RelocationRadius <= 0 {
+		cfg.RelocationRadius = cfg.OptimalDistance / 4
+	}
+
+	// Get FBO_MAX_COUNT, FBO_BUDGET and FBO_SIZE_COSTS environment variables (no defaults), ignoring any that can't be parsed
+	cfg.Rebalance.MaxFBOs, _ = strconv.Atoi(os.Getenv("FBO_MAX_COUNT"))
+	cfg.Rebalance.Budget, _ = strconv.ParseFloat(os.Getenv("FBO_BUDGET"), 64)
+	cfg.Rebalance.SizeCosts, _ = ParseSizeCosts(os.Getenv("FBO_SIZE_COSTS"))
+
+	// Get FBO_CLUSTER_ALGORITHM, FBO_NM_CLUSTER and FBO_CLUSTER_MIN_POINTS environment variables (default to single-linkage within 300 nm),
+	// ignoring an unknown algorithm
+	cfg.Clustering = fbo.DefaultClusterSettings()
+	if algorithm := os.Getenv("FBO_CLUSTER_ALGORITHM"); algorithm != "" {
+		cfg.Clustering.Algorithm = strings.ToLower(algorithm)
+		if cfg.Clustering.Validate() != nil {
+			cfg.Clustering.Algorithm = fbo.DefaultClusterSettings().Algorithm
+		}
+	}
+	if radius, err := strconv.ParseFloat(os.Getenv("FBO_NM_CLUSTER"), 64); err == nil && radius > 0 {
+		cfg.Clustering.Radius = radius
+	}
+	if minPoints, err := strconv.Atoi(os.Getenv("FBO_CLUSTER_MIN_POINTS")); err == nil && minPoints > 0 {
+		cfg.Clustering.MinPoints = minPoints
+	}
+
+	// Get FBO_SIM_JOBS, FBO_NM_SIM_RANGE and FBO_SIM_SEED environment variables (default to 1,000 jobs within the maximum distance, seed 1).
+	// The range stays 0 unless set, so it follows the maximum distance; see SimulationSettings.
+	cfg.Simulation = fbo.DefaultSimulationSettings()
+	if jobs, err := strconv.Atoi(os.Getenv("FBO_SIM_JOBS")); err == nil && jobs > 0 && jobs <= fbo.MaxSimulationJobs {
+		cfg.Simulation.Jobs = jobs
+	}
+	if simRange, err := strconv.ParseFloat(os.Getenv("FBO_NM_SIM_RANGE"), 64); err == nil && simRange > 0 {
+		cfg.Simulation.Range = simRange
+	}
+	if seed, err := strconv.ParseInt(os.Getenv("FBO_SIM_SEED"), 10, 64); err == nil {
+		cfg.Simulation.Seed = seed
+	}
+
+	// Get FBO_NM_CORRIDOR environment variable (default to 50 nm)
+	cfg.CorridorWidth, _ = strconv.ParseFloat(os.Getenv("FBO_NM_CORRIDOR"), 64)
+	if cfg.CorridorWidth <= 0 {
+		cfg.CorridorWidth = 50
+	}
+
+	// Get FBO_FILTER_* environment variables, ignoring any that can't be parsed
+	cfg.CandidateFilter, _ = ParseCandidateFilter(func(name string) string {
+		return os.Getenv("FBO_FILTER_" + strings.ToUpper(name))
+	})
+
+	// Get FBO_SCORER environment variable (default to "default")
+	cfg.ScorerName = os.Getenv("FBO_SCORER")
+	if cfg.ScorerName == "" {
+		cfg.ScorerName = fbo.DefaultScorerName
+	}
+
+	// Get FBO_SCORE_* environment variables, such as FBO_SCORE_NO_LIGHTS_PENALTY, ignoring any that aren't numbers
+	for _, variable := range os.Environ() {
+		name, value, _ := strings.Cut(variable, "=")
+		if !strings.HasPrefix(name, scoreWeightPrefix) {
+			continue
+		}
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		if cfg.ScoreWeights == nil {
+			cfg.ScoreWeights = make(map[string]float64)
+		}
+		cfg.ScoreWeights[strings.ToLower(strings.TrimPrefix(name, scoreWeightPrefix))] = weight
+	}
+
+	return cfg
+}
+
+// ParseSizeCosts parses a comma-separated list of size=cost pairs, such as "0=1,1=1,2=2", returning nil if blank
+func ParseSizeCosts(value string) (map[int]float64, error) {
+	var costs map[int]float64
+	for _, pair := range parseList(value, false) {
+		sizeStr, costStr, ok := strings.Cut(pair, "=")
+		size := ParsePreferredSize(strings.TrimSpace(sizeStr))
+		cost, err := strconv.ParseFloat(strings.TrimSpace(costStr), 64)
+		if !ok || size == nil || err != nil || cost < 0 {
+			return nil, fmt.Errorf("size costs must be size=cost pairs, with sizes from 0 to 5 and costs of 0 or more")
+		}
+		if costs == nil {
+			costs = make(map[int]float64)
+		}
+		costs[*size] = cost
+	}
+	return costs, nil
+}
+
+// ParsePreferredSize parses a preferred airport size, returning nil if blank or outside 0-5
+func ParsePreferredSize(preferredSizeStr string) *int {
+	if preferredSizeStr == "" {
+		return nil
+	}
+
+	size, err := strconv.Atoi(preferredSizeStr)
+	if err != nil || size < 0 || size > 5 {
+		return nil
+	}
+
+	return &size
+}
+
+// ParseCandidateFilter builds a candidate filter from named settings (see CandidateFilterFields). Lists are
+// comma-separated, military is true (only military) or false (no military), and blank settings don't filter.
+// Settings that can't be parsed are left out of the filter and the first such error is returned.
+func ParseCandidateFilter(get func(name string) string) (fbo.CandidateFilter, error) {
+	var filter fbo.CandidateFilter
+	var firstErr error
+	fail := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	filter.AirportTypes = parseList(get("types"), true)
+	for _, airportType := range filter.AirportTypes {
+		if airportType != "AD" && airportType != "ALA" {
+			fail(fmt.Errorf("types must be AD, ALA or both"))
+			filter.AirportTypes = nil
+			break
+		}
+	}
+	filter.CountryCodes = parseList(get("countries"), true)
+	filter.States = parseList(get("states"), false)
+	filter.ExcludeICAOs = parseList(get("exclude"), true)
+
+	for name, target := range map[string]**int{"min_size": &filter.MinSize, "max_size": &filter.MaxSize} {
+		if value := strings.TrimSpace(get(name)); value != "" {
+			if *target = ParsePreferredSize(value); *target == nil {
+				fail(fmt.Errorf("%s must be a whole number from 0 to 5", name))
+			}
+		}
+	}
+
+	for _, value := range parseList(get("surfaces"), false) {
+		surfaceType, err := strconv.Atoi(value)
+		if err != nil {
+			fail(fmt.Errorf("surfaces must be whole numbers"))
+			filter.SurfaceTypes = nil
+			break
+		}
+		filter.SurfaceTypes = append(filter.SurfaceTypes, surfaceType)
+	}
+
+	if value := strings.TrimSpace(get("military")); value != "" {
+		military, err := strconv.ParseBool(value)
+		if err != nil {
+			fail(fmt.Errorf("military must be true or false"))
+		} else {
+			filter.Military = &military
+		}
+	}
+
+	if value := strings.TrimSpace(get("max_elevation")); value != "" {
+		maxElevation, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			fail(fmt.Errorf("max_elevation must be a number of feet"))
+		} else {
+			filter.MaxElevation = &maxElevation
+		}
+	}
+
+	return filter, firstErr
+}
+
+// parseList splits a comma-separated list, dropping blanks and optionally converting to upper case
+func parseList(value string, upper bool) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if upper {
+			item = strings.ToUpper(item)
+		}
+		items = append(items, item)
+	}
+	return items
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/models"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -45,9 +46,37 @@ func InitDB() (*sqlx.DB, error) {
 		return nil, fmt.Errorf("failed to add airport_type column: %w", err)
 	}
 
+	// Add demand_weight column if it doesn't exist
+	if err := addDemandWeightColumn(db); err != nil {
+		return nil, fmt.Errorf("failed to add demand_weight column: %w", err)
+	}
+
+	// Add is_pinned and is_excluded columns if they don't exist
+	if err := addPinColumns(db); err != nil {
+		return nil, fmt.Errorf("failed to add pin columns: %w", err)
+	}
+
 	return db, nil
 }
 
+// InsertAirport inserts an airport with all of its columns, through a database or a transaction
+func InsertAirport(db sqlx.Ext, airport models.Airport) error {
+	_, err := sqlx.NamedExec(db, `
+		INSERT INTO airports (
+			id, name, icao, country_code, iata, state, country_name, city,
+			latitude, longitude, elevation, size, is_military, has_lights,
+			is_basecamp, map_surface_type, is_in_simbrief, display_name, has_fbo,
+			airport_type, demand_weight, is_pinned, is_excluded
+		) VALUES (
+			:id, :name, :icao, :country_code, :iata, :state, :country_name, :city,
+			:latitude, :longitude, :elevation, :size, :is_military, :has_lights,
+			:is_basecamp, :map_surface_type, :is_in_simbrief, :display_name, :has_fbo,
+			:airport_type, :demand_weight, :is_pinned, :is_excluded
+		)
+	`, airport)
+	return err
+}
+
 // createTables creates the necessary tables in the database
 func createTables(db *sqlx.DB) error {
 	// Create airports table
@@ -72,7 +101,10 @@ func createTables(db *sqlx.DB) error {
 			is_in_simbrief BOOLEAN DEFAULT FALSE,
 			display_name TEXT,
 			has_fbo BOOLEAN DEFAULT FALSE,
-			airport_type TEXT
+			airport_type TEXT,
+			demand_weight REAL,
+			is_pinned BOOLEAN DEFAULT FALSE,
+			is_excluded BOOLEAN DEFAULT FALSE
 		)
 	`)
 	if err != nil {
@@ -110,6 +142,65 @@ func createTables(db *sqlx.DB) error {
 		return err
 	}
 
+	// Create planned FBOs table, which holds both planned sets (kind 'plan') and network variants (kind 'variant')
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS planned_fbos (
+			kind TEXT NOT NULL DEFAULT 'plan',
+			plan_name TEXT NOT NULL,
+			icao TEXT NOT NULL,
+			airport_id TEXT NOT NULL,
+			FOREIGN KEY (airport_id) REFERENCES airports(id),
+			PRIMARY KEY (kind, plan_name, icao)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create what-if scenario changes table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS scenario_changes (
+			scenario_name TEXT NOT NULL,
+			icao TEXT NOT NULL,
+			airport_id TEXT NOT NULL,
+			action TEXT NOT NULL CHECK (action IN ('add', 'remove')),
+			FOREIGN KEY (airport_id) REFERENCES airports(id),
+			PRIMARY KEY (scenario_name, icao)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create network health snapshots table
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS network_snapshots (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			taken_at TIMESTAMP NOT NULL,
+			reason TEXT NOT NULL,
+			optimal_distance REAL NOT NULL,
+			max_distance REAL NOT NULL,
+			coverage_radius REAL NOT NULL,
+			fbo_count INTEGER NOT NULL,
+			average_distance REAL,
+			efficiency_score REAL,
+			optimal_connections INTEGER,
+			total_connections INTEGER,
+			legs_within_max INTEGER NOT NULL,
+			components INTEGER NOT NULL,
+			largest_component INTEGER NOT NULL,
+			isolated_fbos INTEGER NOT NULL,
+			longest_gap REAL NOT NULL,
+			covered_airports INTEGER NOT NULL,
+			total_airports INTEGER NOT NULL,
+			coverage_percent REAL NOT NULL,
+			average_nearest_distance REAL NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -126,3 +217,27 @@ func addAirportTypeColumn(db *sqlx.DB) error {
 	}
 	return nil
 }
+
+// addDemandWeightColumn adds the demand_weight column to the airports table if it doesn't exist
+func addDemandWeightColumn(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		ALTER TABLE airports ADD COLUMN demand_weight REAL;
+	`)
+	// Ignore the error if the column already exists
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
+	return nil
+}
+
+// addPinColumns adds the is_pinned and is_excluded columns to the airports table if they don't exist
+func addPinColumns(db *sqlx.DB) error {
+	for _, column := range []string{"is_pinned", "is_excluded"} {
+		_, err := db.Exec(`ALTER TABLE airports ADD COLUMN ` + column + ` BOOLEAN DEFAULT FALSE;`)
+		// Ignore the error if the column already exists
+		if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return err
+		}
+	}
+	return nil
+}
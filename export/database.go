@@ -0,0 +1,51 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/models"
+	"io"
+	"time"
+)
+
+// DatabaseExportVersion is the version of the database export format written by WriteDatabaseExport
+const DatabaseExportVersion = 1
+
+// DatabaseExport is a snapshot of the local airports and FBOs, for sharing with other OffAir users
+type DatabaseExport struct {
+	Version    int              `json:"version"`
+	ExportedAt time.Time        `json:"exported_at"`
+	Airports   []models.Airport `json:"airports"`
+	FBOs       []models.FBO     `json:"fbos"`
+}
+
+// BuildDatabaseExport reads all airports and FBOs from the local database
+func BuildDatabaseExport(db *sqlx.DB) (DatabaseExport, error) {
+	export := DatabaseExport{
+		Version:    DatabaseExportVersion,
+		ExportedAt: time.Now().UTC(),
+	}
+
+	err := db.Select(&export.Airports, "SELECT * FROM airports ORDER BY icao")
+	if err != nil {
+		return DatabaseExport{}, fmt.Errorf("error fetching airports: %w", err)
+	}
+
+	err = db.Select(&export.FBOs, "SELECT * FROM fbos ORDER BY icao")
+	if err != nil {
+		return DatabaseExport{}, fmt.Errorf("error fetching FBOs: %w", err)
+	}
+
+	return export, nil
+}
+
+// WriteDatabaseExport writes a database export as indented JSON
+func WriteDatabaseExport(w io.Writer, export DatabaseExport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(export); err != nil {
+		return fmt.Errorf("error writing database export: %w", err)
+	}
+	return nil
+}
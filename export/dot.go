@@ -0,0 +1,115 @@
+package export
+
+import (
+	"fmt"
+	"github.com/julietrb1/offair-cli/fbo"
+	"github.com/julietrb1/offair-cli/models"
+	"io"
+	"math"
+	"strings"
+)
+
+// DOTOptions controls which parts of the FBO network are written to a DOT graph
+type DOTOptions struct {
+	// MaxDistance is the longest leg, in nm, included as an edge
+	MaxDistance float64
+	// MinimumSpanningTree restricts edges to the minimum spanning tree of the legs
+	MinimumSpanningTree bool
+	// ComponentICAO, if set, restricts the graph to the connected component containing this FBO
+	ComponentICAO string
+}
+
+// WriteFBONetworkDOT writes the FBO leg network as an undirected Graphviz DOT graph.
+// Nodes are FBO ICAOs and edges are legs within the maximum distance, weighted by nm.
+func WriteFBONetworkDOT(w io.Writer, fbos []models.Airport, options DOTOptions) error {
+	var nodes []models.Airport
+	for _, airport := range fbos {
+		if airport.Latitude != nil && airport.Longitude != nil {
+			nodes = append(nodes, airport)
+		}
+	}
+
+	legs := fbo.BuildLegs(nodes, options.MaxDistance)
+
+	if options.ComponentICAO != "" {
+		var component []models.Airport
+		for _, c := range fbo.ConnectedComponents(nodes, legs) {
+			for _, airport := range c {
+				if airport.ICAO == options.ComponentICAO {
+					component = c
+					break
+				}
+			}
+		}
+		if component == nil {
+			return fmt.Errorf("FBO %s not found in the network", options.ComponentICAO)
+		}
+
+		inComponent := make(map[string]bool)
+		for _, airport := range component {
+			inComponent[airport.ICAO] = true
+		}
+
+		var componentLegs []fbo.Leg
+		for _, leg := range legs {
+			if inComponent[leg.From.ICAO] {
+				componentLegs = append(componentLegs, leg)
+			}
+		}
+
+		nodes = component
+		legs = componentLegs
+	}
+
+	if options.MinimumSpanningTree {
+		legs = fbo.MinimumSpanningTree(nodes, legs)
+	}
+
+	var b strings.Builder
+	b.WriteString("graph fbo_network {\n")
+	b.WriteString("    graph [overlap=false, splines=true];\n")
+	b.WriteString("    node [shape=circle, fontsize=10];\n")
+	b.WriteString("    edge [fontsize=8];\n\n")
+
+	for _, airport := range nodes {
+		size := ""
+		if airport.Size != nil {
+			size = fmt.Sprintf(", airport_size=%d", *airport.Size)
+		}
+
+		fmt.Fprintf(&b, "    %s [label=%s, name=%s, country=%s%s];\n",
+			dotQuote(airport.ICAO),
+			dotQuote(airport.ICAO+"\\n"+airport.Name),
+			dotQuote(airport.Name),
+			dotQuote(airport.CountryCode),
+			size)
+	}
+
+	if len(legs) > 0 {
+		b.WriteString("\n")
+	}
+
+	for _, leg := range legs {
+		fmt.Fprintf(&b, "    %s -- %s [label=%s, weight=%d, nm=%.2f, len=%.2f];\n",
+			dotQuote(leg.From.ICAO),
+			dotQuote(leg.To.ICAO),
+			dotQuote(fmt.Sprintf("%.0f nm", leg.Distance)),
+			int(math.Round(leg.Distance)),
+			leg.Distance,
+			leg.Distance/100.0)
+	}
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	if err != nil {
+		return fmt.Errorf("error writing DOT graph: %w", err)
+	}
+
+	return nil
+}
+
+// dotQuote quotes a string as a DOT identifier, leaving label escapes such as \n intact
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
@@ -0,0 +1,77 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/julietrb1/offair-cli/models"
+	"io"
+	"strconv"
+	"time"
+)
+
+// littleNavmapHeader is the header row Little Navmap writes to, and recognises in, userpoint CSV files
+var littleNavmapHeader = []string{
+	"Type", "Name", "Ident", "Latitude", "Longitude", "Elevation", "Magnetic Declination",
+	"Tags", "Description", "Region", "Visible From", "Last Edit", "Import Filename",
+}
+
+// WriteLittleNavmapUserpoints writes airports as a Little Navmap userpoints CSV.
+// Airports without latitude or longitude information are skipped. Returns the number of userpoints written.
+func WriteLittleNavmapUserpoints(w io.Writer, airports []models.Airport) (int, error) {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(littleNavmapHeader); err != nil {
+		return 0, fmt.Errorf("error writing userpoint header: %w", err)
+	}
+
+	lastEdit := time.Now().Format("2006-01-02T15:04:05.000")
+	written := 0
+	for _, airport := range airports {
+		if airport.Latitude == nil || airport.Longitude == nil {
+			continue
+		}
+
+		elevation := ""
+		if airport.Elevation != nil {
+			elevation = strconv.FormatFloat(*airport.Elevation, 'f', 0, 64)
+		}
+
+		// Little Navmap's "Airstrip" symbol suits aircraft landing areas better than the full airport symbol
+		pointType := "Airport"
+		if airport.AirportType != nil && *airport.AirportType == "ALA" {
+			pointType = "Airstrip"
+		}
+
+		description := "OnAir FBO"
+		if airport.City != nil {
+			description += " - " + *airport.City
+		}
+
+		record := []string{
+			pointType,
+			airport.Name,
+			airport.ICAO,
+			strconv.FormatFloat(*airport.Latitude, 'f', 6, 64),
+			strconv.FormatFloat(*airport.Longitude, 'f', 6, 64),
+			elevation,
+			"0",
+			"OnAir,FBO",
+			description,
+			airport.CountryCode,
+			"250",
+			lastEdit,
+			"",
+		}
+		if err := writer.Write(record); err != nil {
+			return written, fmt.Errorf("error writing userpoint for %s: %w", airport.ICAO, err)
+		}
+		written++
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return written, fmt.Errorf("error writing userpoints: %w", err)
+	}
+
+	return written, nil
+}
@@ -0,0 +1,152 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/julietrb1/offair-cli/models"
+	"io"
+	"math"
+	"strings"
+)
+
+// msfsDocument is the root element of an MSFS .pln flight plan
+type msfsDocument struct {
+	XMLName    xml.Name       `xml:"SimBase.Document"`
+	Type       string         `xml:"Type,attr"`
+	Version    string         `xml:"version,attr"`
+	Descr      string         `xml:"Descr"`
+	FlightPlan msfsFlightPlan `xml:"FlightPlan.FlightPlan"`
+}
+
+// msfsFlightPlan is the flight plan element of an MSFS .pln flight plan
+type msfsFlightPlan struct {
+	Title           string            `xml:"Title"`
+	FPType          string            `xml:"FPType"`
+	RouteType       string            `xml:"RouteType"`
+	CruisingAlt     string            `xml:"CruisingAlt"`
+	DepartureID     string            `xml:"DepartureID"`
+	DepartureLLA    string            `xml:"DepartureLLA"`
+	DestinationID   string            `xml:"DestinationID"`
+	DestinationLLA  string            `xml:"DestinationLLA"`
+	Descr           string            `xml:"Descr"`
+	DepartureName   string            `xml:"DepartureName"`
+	DestinationName string            `xml:"DestinationName"`
+	AppVersion      msfsAppVersion    `xml:"AppVersion"`
+	Waypoints       []msfsATCWaypoint `xml:"ATCWaypoint"`
+}
+
+// msfsAppVersion identifies the simulator build the flight plan was written for
+type msfsAppVersion struct {
+	AppVersionMajor int `xml:"AppVersionMajor"`
+	AppVersionBuild int `xml:"AppVersionBuild"`
+}
+
+// msfsATCWaypoint is a single waypoint in an MSFS .pln flight plan
+type msfsATCWaypoint struct {
+	ID              string   `xml:"id,attr"`
+	ATCWaypointType string   `xml:"ATCWaypointType"`
+	WorldPosition   string   `xml:"WorldPosition"`
+	ICAO            msfsICAO `xml:"ICAO"`
+}
+
+// msfsICAO identifies a waypoint by its ICAO identifier
+type msfsICAO struct {
+	ICAOIdent string `xml:"ICAOIdent"`
+}
+
+// WriteMSFSFlightPlan writes a route of airports as an MSFS .pln VFR flight plan.
+// The first airport is the departure, the last is the destination and any in between become airport waypoints.
+func WriteMSFSFlightPlan(w io.Writer, route []models.Airport, cruisingAltitude float64) error {
+	if len(route) < 2 {
+		return fmt.Errorf("a flight plan needs at least 2 airports, got %d", len(route))
+	}
+
+	var waypoints []msfsATCWaypoint
+	for _, airport := range route {
+		if airport.Latitude == nil || airport.Longitude == nil {
+			return fmt.Errorf("airport %s does not have latitude or longitude information", airport.ICAO)
+		}
+
+		waypoints = append(waypoints, msfsATCWaypoint{
+			ID:              airport.ICAO,
+			ATCWaypointType: "Airport",
+			WorldPosition:   formatMSFSPosition(airport),
+			ICAO:            msfsICAO{ICAOIdent: airport.ICAO},
+		})
+	}
+
+	departure := route[0]
+	destination := route[len(route)-1]
+
+	var idents []string
+	for _, airport := range route {
+		idents = append(idents, airport.ICAO)
+	}
+
+	document := msfsDocument{
+		Type:    "AceXML",
+		Version: "1,0",
+		Descr:   "AceXML Document",
+		FlightPlan: msfsFlightPlan{
+			Title:           fmt.Sprintf("%s to %s", departure.ICAO, destination.ICAO),
+			FPType:          "VFR",
+			RouteType:       "Direct",
+			CruisingAlt:     fmt.Sprintf("%.0f", cruisingAltitude),
+			DepartureID:     departure.ICAO,
+			DepartureLLA:    formatMSFSPosition(departure),
+			DestinationID:   destination.ICAO,
+			DestinationLLA:  formatMSFSPosition(destination),
+			Descr:           fmt.Sprintf("%s, exported by OffAir", strings.Join(idents, " - ")),
+			DepartureName:   departure.Name,
+			DestinationName: destination.Name,
+			AppVersion: msfsAppVersion{
+				AppVersionMajor: 11,
+				AppVersionBuild: 282174,
+			},
+			Waypoints: waypoints,
+		},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("error writing flight plan: %w", err)
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "    ")
+	if err := encoder.Encode(document); err != nil {
+		return fmt.Errorf("error writing flight plan: %w", err)
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// formatMSFSPosition formats an airport's coordinates and elevation the way MSFS expects,
+// e.g. S33° 56' 46.00",E151° 10' 38.00",+000021.00
+func formatMSFSPosition(airport models.Airport) string {
+	elevation := 0.0
+	if airport.Elevation != nil {
+		elevation = *airport.Elevation
+	}
+
+	return fmt.Sprintf("%s,%s,%+010.2f",
+		formatMSFSCoordinate(*airport.Latitude, "N", "S"),
+		formatMSFSCoordinate(*airport.Longitude, "E", "W"),
+		elevation)
+}
+
+// formatMSFSCoordinate formats a decimal coordinate as hemisphere, degrees, minutes and seconds
+func formatMSFSCoordinate(value float64, positive, negative string) string {
+	hemisphere := positive
+	if value < 0 {
+		hemisphere = negative
+	}
+
+	// Round to whole hundredths of a second before splitting, so seconds never print as 60.00
+	hundredths := int64(math.Round(math.Abs(value) * 3600 * 100))
+	degrees := hundredths / (3600 * 100)
+	minutes := hundredths / (60 * 100) % 60
+	seconds := float64(hundredths%(60*100)) / 100
+
+	return fmt.Sprintf("%s%d° %d' %.2f\"", hemisphere, degrees, minutes, seconds)
+}
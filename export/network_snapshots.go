@@ -0,0 +1,75 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/julietrb1/offair-cli/fbo"
+	"io"
+	"strconv"
+	"time"
+)
+
+// networkSnapshotHeader is the header row of the network snapshot history CSV
+var networkSnapshotHeader = []string{
+	"Taken At", "Reason", "Optimal Distance", "Max Distance", "Coverage Radius", "FBOs",
+	"Average Leg", "Efficiency Score", "Optimal Connections", "Total Connections",
+	"Legs Within Max", "Components", "Largest Component", "Isolated FBOs", "Longest Gap",
+	"Covered Airports", "Total Airports", "Coverage Percent", "Average Nearest FBO",
+}
+
+// WriteNetworkSnapshotsCSV writes network snapshots as CSV, one row per snapshot, for charting elsewhere.
+// Metrics that weren't available when a snapshot was taken are left blank.
+func WriteNetworkSnapshotsCSV(w io.Writer, snapshots []fbo.NetworkSnapshot) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(networkSnapshotHeader); err != nil {
+		return fmt.Errorf("error writing snapshot header: %w", err)
+	}
+
+	formatFloat := func(value float64) string { return strconv.FormatFloat(value, 'f', 2, 64) }
+	optionalFloat := func(value *float64) string {
+		if value == nil {
+			return ""
+		}
+		return formatFloat(*value)
+	}
+	optionalInt := func(value *int) string {
+		if value == nil {
+			return ""
+		}
+		return strconv.Itoa(*value)
+	}
+
+	for _, snapshot := range snapshots {
+		record := []string{
+			snapshot.TakenAt.UTC().Format(time.RFC3339),
+			snapshot.Reason,
+			formatFloat(snapshot.OptimalDistance),
+			formatFloat(snapshot.MaxDistance),
+			formatFloat(snapshot.CoverageRadius),
+			strconv.Itoa(snapshot.FBOCount),
+			optionalFloat(snapshot.AverageDistance),
+			optionalFloat(snapshot.EfficiencyScore),
+			optionalInt(snapshot.OptimalConnections),
+			optionalInt(snapshot.TotalConnections),
+			strconv.Itoa(snapshot.LegsWithinMax),
+			strconv.Itoa(snapshot.Components),
+			strconv.Itoa(snapshot.LargestComponent),
+			strconv.Itoa(snapshot.IsolatedFBOs),
+			formatFloat(snapshot.LongestGap),
+			strconv.Itoa(snapshot.CoveredAirports),
+			strconv.Itoa(snapshot.TotalAirports),
+			formatFloat(snapshot.CoveragePercent),
+			formatFloat(snapshot.AverageNearestDistance),
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("error writing snapshot: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error writing snapshots: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/julietrb1/offair-cli/fbo"
+	"io"
+	"strconv"
+)
+
+// WriteSweepCSV writes a parameter sweep as CSV, one row per candidate with its rank under each setting.
+// Ranks are left blank for settings the candidate wasn't in the top N of.
+func WriteSweepCSV(w io.Writer, analysis fbo.SweepAnalysis) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{"ICAO", "Name", "Verdict", "Settings In Top N", "Share", "Best Rank", "Worst Rank"}
+	for _, setting := range analysis.Settings {
+		header = append(header, "Rank "+setting.Label())
+	}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("error writing sweep header: %w", err)
+	}
+
+	for _, candidate := range analysis.Candidates {
+		verdict := "Fragile"
+		if candidate.Robust {
+			verdict = "Robust"
+		}
+
+		record := []string{
+			candidate.Airport.ICAO,
+			candidate.Airport.Name,
+			verdict,
+			strconv.Itoa(candidate.TopCount),
+			strconv.FormatFloat(candidate.Share, 'f', 2, 64),
+			strconv.Itoa(candidate.BestRank),
+			strconv.Itoa(candidate.WorstRank),
+		}
+		for _, rank := range candidate.Ranks {
+			if rank == 0 {
+				record = append(record, "")
+				continue
+			}
+			record = append(record, strconv.Itoa(rank))
+		}
+
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("error writing sweep row for %s: %w", candidate.Airport.ICAO, err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error writing sweep: %w", err)
+	}
+	return nil
+}
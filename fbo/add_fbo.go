@@ -8,8 +8,13 @@ import (
 
 // AddFBO adds an FBO at an airport
 func AddFBO(db *sqlx.DB, icao string) error {
+	return addFBO(db, icao)
+}
+
+// addFBO adds an FBO at an airport using db, which may be a transaction
+func addFBO(db sqlx.Ext, icao string) error {
 	var airport models.Airport
-	err := db.Get(&airport, "SELECT * FROM airports WHERE icao = ?", icao)
+	err := sqlx.Get(db, &airport, "SELECT * FROM airports WHERE icao = ?", icao)
 	if err != nil {
 		return fmt.Errorf("airport with ICAO %s not found: %w", icao, err)
 	}
@@ -0,0 +1,121 @@
+package fbo
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/models"
+	"math"
+	"sort"
+)
+
+// BackboneLeg is a leg of the minimum spanning tree over the FBO network
+type BackboneLeg struct {
+	From     models.Airport `json:"from"`
+	To       models.Airport `json:"to"`
+	Distance float64        `json:"distance"`
+	// ExceedsMax is true when the leg is longer than the max distance, so the network is broken there
+	ExceedsMax bool `json:"exceeds_max"`
+}
+
+// NetworkBackbone is the minimum spanning tree over the FBO network: the shortest set of legs connecting every FBO
+type NetworkBackbone struct {
+	FBOCount    int     `json:"fbo_count"`
+	MaxDistance float64 `json:"max_distance"`
+	// Legs are ordered longest first
+	Legs        []BackboneLeg `json:"legs"`
+	TotalLength float64       `json:"total_length"`
+	// Longest is the weakest link in the network, or nil with fewer than 2 FBOs
+	Longest *BackboneLeg `json:"longest"`
+	// BrokenLegs counts the legs longer than the max distance
+	BrokenLegs int `json:"broken_legs"`
+}
+
+// FindNetworkBackbone reports the minimum spanning tree over the FBO network, its weakest link, and the legs
+// longer than the max distance
+func FindNetworkBackbone(db *sqlx.DB, maxDistance float64) (string, error) {
+	// Define color functions
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	backbone, err := AnalyseNetworkBackbone(db, maxDistance)
+	if err != nil {
+		return "", err
+	}
+
+	result := fmt.Sprintf("%s %s %.2f nm\n", bold("Using:"), bold("maximum distance:"), maxDistance)
+	result += fmt.Sprintf("%s %d legs connect %d FBOs, %.0f nm in total.\n",
+		bold("Backbone:"), len(backbone.Legs), backbone.FBOCount, backbone.TotalLength)
+	result += fmt.Sprintf("%s %s to %s, %.0f nm\n",
+		bold("Weakest link:"), backbone.Longest.From.ICAO, backbone.Longest.To.ICAO, backbone.Longest.Distance)
+
+	if backbone.BrokenLegs == 0 {
+		result += fmt.Sprintf("%s\n", green(fmt.Sprintf("Every backbone leg is within %.0f nm, so the network is connected.", maxDistance)))
+	} else {
+		result += fmt.Sprintf("%s\n", red(fmt.Sprintf("%d backbone legs are longer than %.0f nm, splitting the network into %d parts.",
+			backbone.BrokenLegs, maxDistance, backbone.BrokenLegs+1)))
+	}
+
+	result += fmt.Sprintf("\n%s\n", bold(cyan("Backbone legs, longest first:")))
+	for i, leg := range backbone.Legs {
+		line := fmt.Sprintf("%-3d %-40s %-40s %8.1f nm",
+			i+1,
+			leg.From.Name+" ("+leg.From.ICAO+")",
+			leg.To.Name+" ("+leg.To.ICAO+")",
+			leg.Distance)
+		if leg.ExceedsMax {
+			line = red(line + "  beyond max")
+		}
+		result += line + "\n"
+	}
+
+	return result, nil
+}
+
+// AnalyseNetworkBackbone builds the minimum spanning tree over the FBOs with coordinates, weighted by distance
+func AnalyseNetworkBackbone(db *sqlx.DB, maxDistance float64) (NetworkBackbone, error) {
+	var allFBOs []models.Airport
+	err := db.Select(&allFBOs, "SELECT * FROM airports WHERE has_fbo = TRUE")
+	if err != nil {
+		return NetworkBackbone{}, fmt.Errorf("error fetching FBOs: %w", err)
+	}
+
+	var fbos []models.Airport
+	for _, fbo := range allFBOs {
+		if fbo.Latitude != nil && fbo.Longitude != nil {
+			fbos = append(fbos, fbo)
+		}
+	}
+	if len(fbos) < 2 {
+		return NetworkBackbone{}, &InsufficientFBOsError{Total: len(allFBOs), WithCoords: len(fbos)}
+	}
+
+	backbone := NetworkBackbone{
+		FBOCount:    len(fbos),
+		MaxDistance: maxDistance,
+		Legs:        []BackboneLeg{},
+	}
+
+	for _, leg := range MinimumSpanningTree(fbos, BuildLegs(fbos, math.Inf(1))) {
+		backboneLeg := BackboneLeg{
+			From:       leg.From,
+			To:         leg.To,
+			Distance:   leg.Distance,
+			ExceedsMax: leg.Distance > maxDistance,
+		}
+		backbone.Legs = append(backbone.Legs, backboneLeg)
+		backbone.TotalLength += leg.Distance
+		if backboneLeg.ExceedsMax {
+			backbone.BrokenLegs++
+		}
+	}
+
+	sort.SliceStable(backbone.Legs, func(i, j int) bool {
+		return backbone.Legs[i].Distance > backbone.Legs[j].Distance
+	})
+	backbone.Longest = &backbone.Legs[0]
+
+	return backbone, nil
+}
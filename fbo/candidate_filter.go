@@ -0,0 +1,144 @@
+package fbo
+
+import (
+	"fmt"
+	"github.com/julietrb1/offair-cli/models"
+	"strings"
+)
+
+// CandidateFilter holds hard filters on which airports the analyses may recommend. Empty fields don't filter,
+// and an airport missing a value that a filter needs (such as an unknown size) doesn't match.
+type CandidateFilter struct {
+	AirportTypes []string `json:"airport_types,omitempty"`
+	CountryCodes []string `json:"country_codes,omitempty"`
+	States       []string `json:"states,omitempty"`
+	MinSize      *int     `json:"min_size,omitempty"`
+	MaxSize      *int     `json:"max_size,omitempty"`
+	SurfaceTypes []int    `json:"surface_types,omitempty"`
+	// Military is nil to allow any airport, false to leave out military airports and true to only allow them
+	Military     *bool    `json:"military,omitempty"`
+	MaxElevation *float64 `json:"max_elevation,omitempty"`
+	ExcludeICAOs []string `json:"exclude_icaos,omitempty"`
+}
+
+// Matches reports whether an airport passes every filter
+func (f CandidateFilter) Matches(airport models.Airport) bool {
+	if len(f.AirportTypes) > 0 && (airport.AirportType == nil || !containsFold(f.AirportTypes, *airport.AirportType)) {
+		return false
+	}
+
+	if len(f.CountryCodes) > 0 && !containsFold(f.CountryCodes, airport.CountryCode) {
+		return false
+	}
+
+	if len(f.States) > 0 && (airport.State == nil || !containsFold(f.States, *airport.State)) {
+		return false
+	}
+
+	if f.MinSize != nil || f.MaxSize != nil {
+		if airport.Size == nil {
+			return false
+		}
+		if f.MinSize != nil && *airport.Size < *f.MinSize {
+			return false
+		}
+		if f.MaxSize != nil && *airport.Size > *f.MaxSize {
+			return false
+		}
+	}
+
+	if len(f.SurfaceTypes) > 0 {
+		if airport.MapSurfaceType == nil {
+			return false
+		}
+		found := false
+		for _, surfaceType := range f.SurfaceTypes {
+			if *airport.MapSurfaceType == surfaceType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if f.Military != nil && airport.IsMilitary != *f.Military {
+		return false
+	}
+
+	if f.MaxElevation != nil && (airport.Elevation == nil || *airport.Elevation > *f.MaxElevation) {
+		return false
+	}
+
+	if containsFold(f.ExcludeICAOs, airport.ICAO) {
+		return false
+	}
+
+	return true
+}
+
+// IsEmpty reports whether the filter lets every airport through
+func (f CandidateFilter) IsEmpty() bool {
+	return len(f.AirportTypes) == 0 && len(f.CountryCodes) == 0 && len(f.States) == 0 &&
+		f.MinSize == nil && f.MaxSize == nil && len(f.SurfaceTypes) == 0 &&
+		f.Military == nil && f.MaxElevation == nil && len(f.ExcludeICAOs) == 0
+}
+
+// String describes the active filters, or "none"
+func (f CandidateFilter) String() string {
+	var parts []string
+
+	if len(f.AirportTypes) > 0 {
+		parts = append(parts, "type "+strings.Join(f.AirportTypes, "/"))
+	}
+	if len(f.CountryCodes) > 0 {
+		parts = append(parts, "country "+strings.Join(f.CountryCodes, "/"))
+	}
+	if len(f.States) > 0 {
+		parts = append(parts, "state "+strings.Join(f.States, "/"))
+	}
+	switch {
+	case f.MinSize != nil && f.MaxSize != nil:
+		parts = append(parts, fmt.Sprintf("size %d-%d", *f.MinSize, *f.MaxSize))
+	case f.MinSize != nil:
+		parts = append(parts, fmt.Sprintf("size %d+", *f.MinSize))
+	case f.MaxSize != nil:
+		parts = append(parts, fmt.Sprintf("size up to %d", *f.MaxSize))
+	}
+	if len(f.SurfaceTypes) > 0 {
+		var surfaceTypes []string
+		for _, surfaceType := range f.SurfaceTypes {
+			surfaceTypes = append(surfaceTypes, fmt.Sprintf("%d", surfaceType))
+		}
+		parts = append(parts, "surface "+strings.Join(surfaceTypes, "/"))
+	}
+	if f.Military != nil {
+		if *f.Military {
+			parts = append(parts, "military only")
+		} else {
+			parts = append(parts, "no military")
+		}
+	}
+	if f.MaxElevation != nil {
+		parts = append(parts, fmt.Sprintf("elevation up to %.0f ft", *f.MaxElevation))
+	}
+	if len(f.ExcludeICAOs) > 0 {
+		parts = append(parts, "excluding "+strings.Join(f.ExcludeICAOs, ", "))
+	}
+
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// containsFold reports whether values contains value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package fbo
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/models"
+	"math"
+	"sort"
+)
+
+// NearbyAirport is an airport and its distance from some point of interest
+type NearbyAirport struct {
+	Airport  models.Airport `json:"airport"`
+	Distance float64        `json:"distance"`
+}
+
+// Catchment is the set of airports closer to one FBO than to any other
+type Catchment struct {
+	FBO          models.Airport `json:"fbo"`
+	AirportCount int            `json:"airport_count"`
+	// SharePercent is the percentage of all non-FBO airports in the catchment
+	SharePercent    float64        `json:"share_percent"`
+	AverageDistance float64        `json:"average_distance"`
+	Farthest        *NearbyAirport `json:"farthest"`
+	BeyondMax       int            `json:"beyond_max"`
+}
+
+// CatchmentAnalysis assigns every airport to its nearest FBO
+type CatchmentAnalysis struct {
+	AirportCount int         `json:"airport_count"`
+	FBOCount     int         `json:"fbo_count"`
+	MaxDistance  float64     `json:"max_distance"`
+	Catchments   []Catchment `json:"catchments"`
+	// Orphaned airports are farther than the max distance from every FBO, farthest first, with their nearest FBO
+	Orphaned []OrphanedAirport `json:"orphaned"`
+}
+
+// OrphanedAirport is an airport beyond the max distance from every FBO
+type OrphanedAirport struct {
+	Airport    models.Airport `json:"airport"`
+	NearestFBO NearbyFBO      `json:"nearest_fbo"`
+}
+
+// FindFBOCatchments reports how many airports are nearest to each FBO, and which airports no FBO reaches
+func FindFBOCatchments(db *sqlx.DB, maxDistance float64) (string, error) {
+	// Define color functions
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	analysis, err := AnalyseFBOCatchments(db, maxDistance)
+	if err != nil {
+		return "", err
+	}
+	if analysis.FBOCount == 0 {
+		return bold(yellow("There are no FBOs with latitude/longitude information.")), nil
+	}
+
+	result := fmt.Sprintf("%s %s %.2f nm\n", bold("Using:"), bold("maximum distance:"), maxDistance)
+	result += fmt.Sprintf("%s %d airports assigned to %d FBOs.\n", bold("Found:"), analysis.AirportCount, analysis.FBOCount)
+
+	// A catchment more than twice the average size suggests an overloaded base
+	averageSize := float64(analysis.AirportCount) / float64(analysis.FBOCount)
+
+	result += fmt.Sprintf("\n%s\n", bold(cyan("Catchments, largest first:")))
+	result += bold(fmt.Sprintf("%-3s %-40s  %8s  %6s  %8s  %-18s  %10s", "#", "FBO", "Airports", "Share", "Avg (nm)", "Farthest", "Beyond max")) + "\n"
+	for i, catchment := range analysis.Catchments {
+		count := fmt.Sprintf("%8d", catchment.AirportCount)
+		if float64(catchment.AirportCount) > 2*averageSize {
+			count = yellow(count)
+		}
+
+		farthest := "-"
+		if catchment.Farthest != nil {
+			farthest = fmt.Sprintf("%s (%d nm)", catchment.Farthest.Airport.ICAO, int(math.Round(catchment.Farthest.Distance)))
+		}
+
+		beyond := green(fmt.Sprintf("%10d", catchment.BeyondMax))
+		if catchment.BeyondMax > 0 {
+			beyond = red(fmt.Sprintf("%10d", catchment.BeyondMax))
+		}
+
+		result += fmt.Sprintf("%-3d %-40s  %s  %5.1f%%  %8.1f  %-18s  %s\n",
+			i+1,
+			bold(catchment.FBO.Name)+" "+cyan("("+catchment.FBO.ICAO+")"),
+			count, catchment.SharePercent, catchment.AverageDistance, farthest, beyond)
+	}
+
+	if len(analysis.Orphaned) == 0 {
+		result += fmt.Sprintf("\n%s\n", green(fmt.Sprintf("Every airport is within %.0f nm of an FBO.", maxDistance)))
+		return result, nil
+	}
+
+	result += fmt.Sprintf("\n%s\n", bold(red(fmt.Sprintf("%d airports are beyond %.0f nm of every FBO, farthest first:", len(analysis.Orphaned), maxDistance))))
+	limit := 20
+	for i, orphan := range analysis.Orphaned {
+		if i == limit {
+			result += fmt.Sprintf("  ... and %d more\n", len(analysis.Orphaned)-limit)
+			break
+		}
+		result += fmt.Sprintf("  %-40s  nearest FBO %s (%d nm)\n",
+			bold(orphan.Airport.Name)+" "+cyan("("+orphan.Airport.ICAO+")"),
+			orphan.NearestFBO.ICAO, int(math.Round(orphan.NearestFBO.Distance)))
+	}
+
+	return result, nil
+}
+
+// AnalyseFBOCatchments assigns every airport without an FBO to its nearest FBO, largest catchment first.
+// Airports and FBOs without coordinates are left out.
+func AnalyseFBOCatchments(db *sqlx.DB, maxDistance float64) (CatchmentAnalysis, error) {
+	var airports []models.Airport
+	err := db.Select(&airports, "SELECT * FROM airports WHERE latitude IS NOT NULL AND longitude IS NOT NULL AND has_fbo = FALSE")
+	if err != nil {
+		return CatchmentAnalysis{}, fmt.Errorf("error fetching airports: %w", err)
+	}
+
+	var fbos []models.Airport
+	err = db.Select(&fbos, "SELECT * FROM airports WHERE has_fbo = TRUE AND latitude IS NOT NULL AND longitude IS NOT NULL")
+	if err != nil {
+		return CatchmentAnalysis{}, fmt.Errorf("error fetching FBOs: %w", err)
+	}
+
+	analysis := CatchmentAnalysis{
+		AirportCount: len(airports),
+		FBOCount:     len(fbos),
+		MaxDistance:  maxDistance,
+		Catchments:   make([]Catchment, len(fbos)),
+		Orphaned:     []OrphanedAirport{},
+	}
+	if len(fbos) == 0 {
+		return analysis, nil
+	}
+
+	totalDistance := make([]float64, len(fbos))
+	for i, fbo := range fbos {
+		analysis.Catchments[i].FBO = fbo
+	}
+
+	for _, airport := range airports {
+		nearest, nearestDistance := -1, math.Inf(1)
+		for i, fbo := range fbos {
+			distance := CalculateDistance(*airport.Latitude, *airport.Longitude, *fbo.Latitude, *fbo.Longitude)
+			if distance < nearestDistance {
+				nearest, nearestDistance = i, distance
+			}
+		}
+
+		catchment := &analysis.Catchments[nearest]
+		catchment.AirportCount++
+		totalDistance[nearest] += nearestDistance
+		if catchment.Farthest == nil || nearestDistance > catchment.Farthest.Distance {
+			catchment.Farthest = &NearbyAirport{Airport: airport, Distance: nearestDistance}
+		}
+
+		if nearestDistance > maxDistance {
+			catchment.BeyondMax++
+			analysis.Orphaned = append(analysis.Orphaned, OrphanedAirport{
+				Airport:    airport,
+				NearestFBO: NearbyFBO{ICAO: fbos[nearest].ICAO, Distance: nearestDistance},
+			})
+		}
+	}
+
+	for i := range analysis.Catchments {
+		catchment := &analysis.Catchments[i]
+		if catchment.AirportCount > 0 {
+			catchment.AverageDistance = totalDistance[i] / float64(catchment.AirportCount)
+		}
+		if analysis.AirportCount > 0 {
+			catchment.SharePercent = float64(catchment.AirportCount) / float64(analysis.AirportCount) * 100.0
+		}
+	}
+
+	sort.SliceStable(analysis.Catchments, func(i, j int) bool {
+		return analysis.Catchments[i].AirportCount > analysis.Catchments[j].AirportCount
+	})
+	sort.SliceStable(analysis.Orphaned, func(i, j int) bool {
+		return analysis.Orphaned[i].NearestFBO.Distance > analysis.Orphaned[j].NearestFBO.Distance
+	})
+
+	return analysis, nil
+}
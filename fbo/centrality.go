@@ -0,0 +1,224 @@
+package fbo
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/models"
+	"math"
+	"sort"
+)
+
+// FBOCentrality measures how important an FBO is to routing through the network
+type FBOCentrality struct {
+	FBO models.Airport `json:"fbo"`
+	// Degree is the number of FBOs within the max distance
+	Degree int `json:"degree"`
+	// Betweenness is the share of shortest routes between other FBOs that pass through this one, from 0 to 1
+	Betweenness float64 `json:"betweenness"`
+	// Closeness is how near the FBO is to the rest of the network by shortest route, scaled down by the share of
+	// the network it can't reach, relative to the closest FBO (1)
+	Closeness float64 `json:"closeness"`
+	// AverageRoute is the average shortest-route distance to the reachable FBOs, in nm
+	AverageRoute float64 `json:"average_route"`
+	// Reachable is the number of other FBOs reachable within the max distance per leg
+	Reachable int `json:"reachable"`
+}
+
+// CentralityAnalysis ranks the FBOs by centrality on the network of legs within the max distance
+type CentralityAnalysis struct {
+	FBOCount    int     `json:"fbo_count"`
+	MaxDistance float64 `json:"max_distance"`
+	LegCount    int     `json:"leg_count"`
+	// FBOs are ordered by betweenness, then closeness, then degree
+	FBOs []FBOCentrality `json:"fbos"`
+}
+
+// RankFBOCentrality reports which FBOs are hubs: how many shortest routes pass through them, how close they are to
+// the rest of the network, and how many legs they have
+func RankFBOCentrality(db *sqlx.DB, maxDistance float64) (string, error) {
+	// Define color functions
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	analysis, err := AnalyseFBOCentrality(db, maxDistance)
+	if err != nil {
+		return "", err
+	}
+
+	result := fmt.Sprintf("%s %s %.2f nm\n", bold("Using:"), bold("maximum distance:"), maxDistance)
+	result += fmt.Sprintf("%s %d FBOs connected by %d legs.\n", bold("Network:"), analysis.FBOCount, analysis.LegCount)
+
+	result += fmt.Sprintf("\n%s\n", bold(cyan("FBOs by centrality:")))
+	result += bold(fmt.Sprintf("%-3s %-40s  %11s  %9s  %6s  %9s  %9s", "#", "FBO", "Betweenness", "Closeness", "Degree", "Reachable", "Avg route")) + "\n"
+	for i, centrality := range analysis.FBOs {
+		degree := fmt.Sprintf("%6d", centrality.Degree)
+		if centrality.Degree == 0 {
+			degree = yellow(degree)
+		}
+
+		result += fmt.Sprintf("%-3d %-40s  %11.3f  %9.3f  %s  %9d  %9.0f\n",
+			i+1,
+			bold(centrality.FBO.Name)+" "+cyan("("+centrality.FBO.ICAO+")"),
+			centrality.Betweenness, centrality.Closeness, degree, centrality.Reachable, centrality.AverageRoute)
+	}
+
+	result += fmt.Sprintf("\n%s\n", yellow("High betweenness marks a hub that many routes depend on; removing or moving it lengthens or breaks them."))
+
+	return result, nil
+}
+
+// AnalyseFBOCentrality ranks the FBOs with coordinates by centrality on the legs within the max distance
+func AnalyseFBOCentrality(db *sqlx.DB, maxDistance float64) (CentralityAnalysis, error) {
+	var allFBOs []models.Airport
+	err := db.Select(&allFBOs, "SELECT * FROM airports WHERE has_fbo = TRUE")
+	if err != nil {
+		return CentralityAnalysis{}, fmt.Errorf("error fetching FBOs: %w", err)
+	}
+
+	var fbos []models.Airport
+	for _, fbo := range allFBOs {
+		if fbo.Latitude != nil && fbo.Longitude != nil {
+			fbos = append(fbos, fbo)
+		}
+	}
+	if len(fbos) < 2 {
+		return CentralityAnalysis{}, &InsufficientFBOsError{Total: len(allFBOs), WithCoords: len(fbos)}
+	}
+
+	legs := BuildLegs(fbos, maxDistance)
+	analysis := CentralityAnalysis{
+		FBOCount:    len(fbos),
+		MaxDistance: maxDistance,
+		LegCount:    len(legs),
+		FBOs:        FBOCentralities(fbos, legs),
+	}
+
+	sort.SliceStable(analysis.FBOs, func(i, j int) bool {
+		a, b := analysis.FBOs[i], analysis.FBOs[j]
+		if a.Betweenness != b.Betweenness {
+			return a.Betweenness > b.Betweenness
+		}
+		if a.Closeness != b.Closeness {
+			return a.Closeness > b.Closeness
+		}
+		return a.Degree > b.Degree
+	})
+
+	return analysis, nil
+}
+
+// FBOCentralities calculates each FBO's degree, betweenness and closeness over the legs, weighted by distance,
+// in the order of fbos. Betweenness uses Brandes' algorithm with Dijkstra's shortest paths.
+func FBOCentralities(fbos []models.Airport, legs []Leg) []FBOCentrality {
+	n := len(fbos)
+	index := make(map[string]int, n)
+	for i, fbo := range fbos {
+		index[fbo.ICAO] = i
+	}
+
+	type edge struct {
+		to       int
+		distance float64
+	}
+	adjacent := make([][]edge, n)
+	for _, leg := range legs {
+		from, okFrom := index[leg.From.ICAO]
+		to, okTo := index[leg.To.ICAO]
+		if !okFrom || !okTo {
+			continue
+		}
+		adjacent[from] = append(adjacent[from], edge{to: to, distance: leg.Distance})
+		adjacent[to] = append(adjacent[to], edge{to: from, distance: leg.Distance})
+	}
+
+	centralities := make([]FBOCentrality, n)
+	betweenness := make([]float64, n)
+	for source := 0; source < n; source++ {
+		centralities[source].FBO = fbos[source]
+		centralities[source].Degree = len(adjacent[source])
+
+		// Dijkstra from the source, counting shortest paths and recording predecessors
+		distance := make([]float64, n)
+		paths := make([]float64, n)
+		predecessors := make([][]int, n)
+		settled := make([]bool, n)
+		for i := range distance {
+			distance[i] = math.Inf(1)
+		}
+		distance[source] = 0
+		paths[source] = 1
+
+		var order []int
+		for {
+			current := -1
+			for i := 0; i < n; i++ {
+				if !settled[i] && !math.IsInf(distance[i], 1) && (current < 0 || distance[i] < distance[current]) {
+					current = i
+				}
+			}
+			if current < 0 {
+				break
+			}
+			settled[current] = true
+			order = append(order, current)
+
+			for _, e := range adjacent[current] {
+				candidate := distance[current] + e.distance
+				switch {
+				case candidate < distance[e.to]:
+					distance[e.to] = candidate
+					paths[e.to] = paths[current]
+					predecessors[e.to] = []int{current}
+				case candidate == distance[e.to]:
+					paths[e.to] += paths[current]
+					predecessors[e.to] = append(predecessors[e.to], current)
+				}
+			}
+		}
+
+		// Closeness over the reachable FBOs, scaled by how much of the network is reachable
+		var total float64
+		reachable := len(order) - 1
+		for _, i := range order {
+			total += distance[i]
+		}
+		centralities[source].Reachable = reachable
+		if reachable > 0 && total > 0 {
+			centralities[source].AverageRoute = total / float64(reachable)
+			centralities[source].Closeness = float64(reachable) / total * float64(reachable) / float64(n-1)
+		}
+
+		// Accumulate each FBO's dependency on the way back from the farthest
+		dependency := make([]float64, n)
+		for i := len(order) - 1; i >= 0; i-- {
+			current := order[i]
+			for _, predecessor := range predecessors[current] {
+				dependency[predecessor] += paths[predecessor] / paths[current] * (1 + dependency[current])
+			}
+			if current != source {
+				betweenness[current] += dependency[current]
+			}
+		}
+	}
+
+	// Closeness is in 1/nm, so make it relative to the closest FBO
+	var closest float64
+	for _, centrality := range centralities {
+		closest = math.Max(closest, centrality.Closeness)
+	}
+
+	// Each route was counted from both ends, and there are (n-1)(n-2)/2 routes between other FBOs
+	pairs := float64(n-1) * float64(n-2)
+	for i := range centralities {
+		if pairs > 0 {
+			centralities[i].Betweenness = betweenness[i] / pairs
+		}
+		if closest > 0 {
+			centralities[i].Closeness /= closest
+		}
+	}
+
+	return centralities
+}
@@ -0,0 +1,288 @@
+package fbo
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/models"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Clustering algorithms
+const (
+	// SingleLinkageClustering joins FBOs into the same cluster when a chain of legs no longer than the radius links them
+	SingleLinkageClustering = "single-linkage"
+	// DBSCANClustering grows clusters from FBOs with at least the minimum number of FBOs within the radius
+	DBSCANClustering = "dbscan"
+)
+
+// ClusterSettings controls how FBOs are grouped into clusters
+type ClusterSettings struct {
+	Algorithm string  `json:"algorithm"`
+	Radius    float64 `json:"radius"`
+	// MinPoints is the smallest cluster for single-linkage, and the neighbourhood size (including the FBO itself)
+	// that makes an FBO a core point for DBSCAN
+	MinPoints int `json:"min_points"`
+}
+
+// DefaultClusterSettings returns single-linkage clustering within 300 nm
+func DefaultClusterSettings() ClusterSettings {
+	return ClusterSettings{
+		Algorithm: SingleLinkageClustering,
+		Radius:    300,
+		MinPoints: 2,
+	}
+}
+
+// Validate checks the settings can be used to cluster FBOs
+func (s ClusterSettings) Validate() error {
+	if s.Algorithm != SingleLinkageClustering && s.Algorithm != DBSCANClustering {
+		return fmt.Errorf("unknown clustering algorithm %q (available: %s, %s)", s.Algorithm, SingleLinkageClustering, DBSCANClustering)
+	}
+	if s.Radius <= 0 {
+		return fmt.Errorf("the cluster radius must be greater than zero")
+	}
+	if s.MinPoints < 1 {
+		return fmt.Errorf("the cluster min points must be at least 1")
+	}
+	return nil
+}
+
+// FBOCluster is a group of FBOs close to each other
+type FBOCluster struct {
+	Members []models.FBO `json:"members"`
+	// Latitude and Longitude are the centroid of the members
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	// Diameter is the longest distance between two members
+	Diameter float64 `json:"diameter"`
+}
+
+// ClusterAnalysis is the result of clustering the FBOs
+type ClusterAnalysis struct {
+	Settings ClusterSettings `json:"settings"`
+	Clusters []FBOCluster    `json:"clusters"`
+	// Outliers are the FBOs in no cluster
+	Outliers []models.FBO `json:"outliers"`
+}
+
+// AnalyseFBOClusters clusters the FBOs with coordinates in the database
+func AnalyseFBOClusters(db *sqlx.DB, settings ClusterSettings) (ClusterAnalysis, error) {
+	var fbos []models.FBO
+	err := db.Select(&fbos, `
+		SELECT f.id, f.airport_id, f.icao, f.name, a.latitude, a.longitude
+		FROM fbos f
+		JOIN airports a ON f.airport_id = a.id
+		WHERE a.latitude IS NOT NULL AND a.longitude IS NOT NULL
+	`)
+	if err != nil {
+		return ClusterAnalysis{}, fmt.Errorf("error fetching FBOs: %w", err)
+	}
+
+	return ClusterFBOs(fbos, settings)
+}
+
+// ClusterFBOs groups FBOs with the configured algorithm. Clusters are ordered largest first, and members and
+// outliers by ICAO, so the result doesn't depend on the order of fbos.
+func ClusterFBOs(fbos []models.FBO, settings ClusterSettings) (ClusterAnalysis, error) {
+	if err := settings.Validate(); err != nil {
+		return ClusterAnalysis{}, err
+	}
+
+	sorted := append([]models.FBO(nil), fbos...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ICAO < sorted[j].ICAO
+	})
+
+	// Each FBO's neighbours within the radius, not including itself
+	neighbours := make([][]int, len(sorted))
+	for i := range sorted {
+		for j := i + 1; j < len(sorted); j++ {
+			if CalculateDistance(sorted[i].Latitude, sorted[i].Longitude, sorted[j].Latitude, sorted[j].Longitude) <= settings.Radius {
+				neighbours[i] = append(neighbours[i], j)
+				neighbours[j] = append(neighbours[j], i)
+			}
+		}
+	}
+
+	var labels []int
+	if settings.Algorithm == DBSCANClustering {
+		labels = dbscanLabels(neighbours, settings.MinPoints)
+	} else {
+		labels = singleLinkageLabels(neighbours, settings.MinPoints)
+	}
+
+	analysis := ClusterAnalysis{
+		Settings: settings,
+		Clusters: []FBOCluster{},
+		Outliers: []models.FBO{},
+	}
+	clusterIndex := make(map[int]int)
+	for i, label := range labels {
+		if label < 0 {
+			analysis.Outliers = append(analysis.Outliers, sorted[i])
+			continue
+		}
+		index, ok := clusterIndex[label]
+		if !ok {
+			index = len(analysis.Clusters)
+			clusterIndex[label] = index
+			analysis.Clusters = append(analysis.Clusters, FBOCluster{})
+		}
+		analysis.Clusters[index].Members = append(analysis.Clusters[index].Members, sorted[i])
+	}
+
+	for i := range analysis.Clusters {
+		cluster := &analysis.Clusters[i]
+		cluster.Latitude, cluster.Longitude = centroid(cluster.Members)
+		for a := range cluster.Members {
+			for b := a + 1; b < len(cluster.Members); b++ {
+				distance := CalculateDistance(cluster.Members[a].Latitude, cluster.Members[a].Longitude, cluster.Members[b].Latitude, cluster.Members[b].Longitude)
+				cluster.Diameter = math.Max(cluster.Diameter, distance)
+			}
+		}
+	}
+
+	sort.SliceStable(analysis.Clusters, func(i, j int) bool {
+		return len(analysis.Clusters[i].Members) > len(analysis.Clusters[j].Members)
+	})
+
+	return analysis, nil
+}
+
+// singleLinkageLabels labels each FBO with its connected component over legs within the radius, or -1 if its
+// component has fewer than minPoints FBOs
+func singleLinkageLabels(neighbours [][]int, minPoints int) []int {
+	const unvisited, outlier = -2, -1
+
+	labels := make([]int, len(neighbours))
+	for i := range labels {
+		labels[i] = unvisited
+	}
+
+	label := 0
+	for start := range neighbours {
+		if labels[start] != unvisited {
+			continue
+		}
+
+		component := []int{start}
+		labels[start] = label
+		for queue := []int{start}; len(queue) > 0; queue = queue[1:] {
+			for _, next := range neighbours[queue[0]] {
+				if labels[next] == unvisited {
+					labels[next] = label
+					component = append(component, next)
+					queue = append(queue, next)
+				}
+			}
+		}
+
+		if len(component) < minPoints {
+			for _, i := range component {
+				labels[i] = outlier
+			}
+			continue
+		}
+		label++
+	}
+
+	return labels
+}
+
+// dbscanLabels labels each FBO with its DBSCAN cluster, or -1 for noise. An FBO is a core point when at least
+// minPoints FBOs, itself included, are within the radius. Clusters grow through core points and take in the
+// border points next to them.
+func dbscanLabels(neighbours [][]int, minPoints int) []int {
+	const unvisited, noise = -2, -1
+
+	labels := make([]int, len(neighbours))
+	for i := range labels {
+		labels[i] = unvisited
+	}
+	isCore := func(i int) bool {
+		return len(neighbours[i])+1 >= minPoints
+	}
+
+	label := 0
+	for start := range neighbours {
+		if labels[start] != unvisited {
+			continue
+		}
+		if !isCore(start) {
+			labels[start] = noise
+			continue
+		}
+
+		labels[start] = label
+		for queue := []int{start}; len(queue) > 0; queue = queue[1:] {
+			current := queue[0]
+			if !isCore(current) {
+				continue
+			}
+			for _, next := range neighbours[current] {
+				if labels[next] == unvisited || labels[next] == noise {
+					labels[next] = label
+					queue = append(queue, next)
+				}
+			}
+		}
+		label++
+	}
+
+	return labels
+}
+
+// centroid returns the geographic centre of the FBOs, averaging their positions on the globe so clusters
+// spanning the antimeridian are handled
+func centroid(fbos []models.FBO) (float64, float64) {
+	var x, y, z float64
+	for _, fbo := range fbos {
+		lat := fbo.Latitude * math.Pi / 180.0
+		lon := fbo.Longitude * math.Pi / 180.0
+		x += math.Cos(lat) * math.Cos(lon)
+		y += math.Cos(lat) * math.Sin(lon)
+		z += math.Sin(lat)
+	}
+
+	lon := math.Atan2(y, x)
+	lat := math.Atan2(z, math.Sqrt(x*x+y*y))
+	return lat * 180.0 / math.Pi, lon * 180.0 / math.Pi
+}
+
+// formatFBOClusters describes the clusters and outliers for the distance report
+func formatFBOClusters(analysis ClusterAnalysis, bold, yellow func(a ...interface{}) string) string {
+	settings := analysis.Settings
+	result := fmt.Sprintf("  %s %s, %s %.0f nm, %s %d\n",
+		bold("Using:"), settings.Algorithm,
+		bold("radius:"), settings.Radius,
+		bold("min points:"), settings.MinPoints)
+
+	if len(analysis.Clusters) == 0 {
+		result += fmt.Sprintf("  %s\n", yellow(fmt.Sprintf("No clusters found within %.0f nm", settings.Radius)))
+	}
+
+	for i, cluster := range analysis.Clusters {
+		var icaos []string
+		for _, member := range cluster.Members {
+			icaos = append(icaos, member.ICAO)
+		}
+		result += fmt.Sprintf("  %s %d: %d FBOs, centroid %.2f, %.2f, diameter %.0f nm\n      %s\n",
+			bold("Cluster"), i+1,
+			len(cluster.Members),
+			cluster.Latitude, cluster.Longitude,
+			cluster.Diameter,
+			strings.Join(icaos, ", "))
+	}
+
+	if len(analysis.Outliers) > 0 {
+		var icaos []string
+		for _, outlier := range analysis.Outliers {
+			icaos = append(icaos, outlier.ICAO)
+		}
+		result += fmt.Sprintf("  %s %s\n", bold("Unclustered:"), strings.Join(icaos, ", "))
+	}
+
+	return result
+}
@@ -0,0 +1,249 @@
+package fbo
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/models"
+	"math"
+	"sort"
+	"strings"
+)
+
+// CorridorAirport is an airport near a route, located relative to the great-circle path
+type CorridorAirport struct {
+	Airport models.Airport `json:"airport"`
+	// AlongTrack is the distance along the route from the origin to the point abeam the airport, in nm
+	AlongTrack float64 `json:"along_track"`
+	// CrossTrack is the airport's distance from the route, in nm: negative to the left, positive to the right
+	CrossTrack float64 `json:"cross_track"`
+}
+
+// Side returns "L" or "R" for the side of the route the airport is on
+func (a CorridorAirport) Side() string {
+	if a.CrossTrack < 0 {
+		return "L"
+	}
+	return "R"
+}
+
+// CorridorStretch is the part of a route between consecutive FBOs, counting the origin and destination as ends
+type CorridorStretch struct {
+	From   models.Airport `json:"from"`
+	To     models.Airport `json:"to"`
+	Length float64        `json:"length"`
+	// TooLong is set when the stretch is longer than the maximum distance
+	TooLong bool `json:"too_long"`
+	// Split is the corridor airport without an FBO nearest the middle of a stretch that's too long, if any
+	Split *CorridorAirport `json:"split"`
+}
+
+// Corridor is the result of searching for airports and FBOs along a route
+type Corridor struct {
+	From     models.Airport `json:"from"`
+	To       models.Airport `json:"to"`
+	Distance float64        `json:"distance"`
+	Width    float64        `json:"width"`
+	// Airports are the airports within Width of the route, in order along it
+	Airports []CorridorAirport `json:"airports"`
+	FBOCount int               `json:"fbo_count"`
+	// Stretches break the route at the FBOs in the corridor, in order along it
+	Stretches []CorridorStretch `json:"stretches"`
+}
+
+// FindCorridor lists the airports and FBOs along the great-circle route between two airports, and flags the
+// stretches without an FBO longer than the maximum distance
+func FindCorridor(db *sqlx.DB, fromICAO, toICAO string, width, maxDistance float64) (string, error) {
+	// Define color functions
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	corridor, err := AnalyseCorridor(db, fromICAO, toICAO, width, maxDistance)
+	if err != nil {
+		return "", err
+	}
+
+	result := fmt.Sprintf("%s %s %.2f nm, %s %.2f nm\n",
+		bold("Using:"),
+		bold("corridor width:"), width,
+		bold("maximum distance:"), maxDistance)
+	result += fmt.Sprintf("%s %s %s to %s %s, %.0f nm\n",
+		bold("Route:"),
+		bold(corridor.From.Name), cyan("("+corridor.From.ICAO+")"),
+		bold(corridor.To.Name), cyan("("+corridor.To.ICAO+")"),
+		corridor.Distance)
+
+	if len(corridor.Airports) == 0 {
+		result += fmt.Sprintf("\n%s\n", yellow(fmt.Sprintf("No airports within %.0f nm of the route.", width)))
+	} else {
+		result += fmt.Sprintf("\n%s\n", bold(cyan(fmt.Sprintf("%d airports along the route, %d with FBOs:", len(corridor.Airports), corridor.FBOCount))))
+		result += bold(fmt.Sprintf("%-3s %-40s %-10s %s\n", "#", "Airport", "Along", "Offset"))
+		for i, airport := range corridor.Airports {
+			name := bold(airport.Airport.Name) + " " + cyan("("+airport.Airport.ICAO+")")
+			padding := 40 - len(airport.Airport.Name) - len(airport.Airport.ICAO) - 3
+			if padding < 0 {
+				padding = 0
+			}
+			offset := fmt.Sprintf("%.0f nm %s", math.Abs(airport.CrossTrack), airport.Side())
+			if airport.Airport.HasFBO {
+				offset = fmt.Sprintf("%-10s %s", offset, green("FBO"))
+			}
+			result += fmt.Sprintf("%-3d %s%s %-10s %s\n",
+				i+1, name, strings.Repeat(" ", padding),
+				fmt.Sprintf("%.0f nm", airport.AlongTrack),
+				offset)
+		}
+	}
+
+	result += fmt.Sprintf("\n%s\n", bold(cyan("Stretches between FBOs:")))
+	for _, stretch := range corridor.Stretches {
+		length := fmt.Sprintf("%.0f nm", stretch.Length)
+		if stretch.TooLong {
+			length = red(length + ", longer than the maximum distance")
+		}
+		result += fmt.Sprintf("  %s to %s  %s\n", cyan(stretch.From.ICAO), cyan(stretch.To.ICAO), length)
+		if stretch.Split != nil {
+			result += fmt.Sprintf("    %s %s %s, %.0f nm along and %.0f nm %s of the route\n",
+				yellow("An FBO at"), bold(stretch.Split.Airport.Name), cyan("("+stretch.Split.Airport.ICAO+")"),
+				stretch.Split.AlongTrack, math.Abs(stretch.Split.CrossTrack), stretch.Split.Side())
+		} else if stretch.TooLong {
+			result += fmt.Sprintf("    %s\n", yellow("No airport in the corridor could split this stretch."))
+		}
+	}
+
+	return result, nil
+}
+
+// AnalyseCorridor finds the airports within width of the great-circle route between two airports, ordered by
+// along-track distance, and breaks the route into stretches at the FBOs among them. Airports abeam the route
+// behind the origin or beyond the destination are left out.
+func AnalyseCorridor(db *sqlx.DB, fromICAO, toICAO string, width, maxDistance float64) (Corridor, error) {
+	if width <= 0 {
+		return Corridor{}, fmt.Errorf("the corridor width must be greater than zero")
+	}
+
+	fromICAO = strings.ToUpper(strings.TrimSpace(fromICAO))
+	toICAO = strings.ToUpper(strings.TrimSpace(toICAO))
+	if fromICAO == toICAO {
+		return Corridor{}, fmt.Errorf("the route needs two different airports")
+	}
+
+	var endpoints [2]models.Airport
+	for i, icao := range []string{fromICAO, toICAO} {
+		err := db.Get(&endpoints[i], "SELECT * FROM airports WHERE icao = ?", icao)
+		if err != nil {
+			return Corridor{}, fmt.Errorf("error fetching airport %s: %w", icao, err)
+		}
+		if endpoints[i].Latitude == nil || endpoints[i].Longitude == nil {
+			return Corridor{}, fmt.Errorf("airport %s has no latitude/longitude information", icao)
+		}
+	}
+	from, to := endpoints[0], endpoints[1]
+
+	var airports []models.Airport
+	err := db.Select(&airports, "SELECT * FROM airports WHERE latitude IS NOT NULL AND longitude IS NOT NULL")
+	if err != nil {
+		return Corridor{}, fmt.Errorf("error fetching airports: %w", err)
+	}
+
+	corridor := Corridor{
+		From:      from,
+		To:        to,
+		Distance:  CalculateDistance(*from.Latitude, *from.Longitude, *to.Latitude, *to.Longitude),
+		Width:     width,
+		Airports:  []CorridorAirport{},
+		Stretches: []CorridorStretch{},
+	}
+
+	routeBearing := initialBearing(*from.Latitude, *from.Longitude, *to.Latitude, *to.Longitude)
+	for _, airport := range airports {
+		if airport.ICAO == from.ICAO || airport.ICAO == to.ICAO {
+			continue
+		}
+
+		// Cross-track and along-track distances, from the angular distance and bearing from the origin
+		angular := CalculateDistance(*from.Latitude, *from.Longitude, *airport.Latitude, *airport.Longitude) / earthRadiusNM
+		offset := initialBearing(*from.Latitude, *from.Longitude, *airport.Latitude, *airport.Longitude) - routeBearing
+		crossTrack := math.Asin(math.Sin(angular) * math.Sin(offset))
+		if math.Abs(crossTrack)*earthRadiusNM > width {
+			continue
+		}
+		alongTrack := math.Acos(math.Max(-1, math.Min(1, math.Cos(angular)/math.Cos(crossTrack)))) * earthRadiusNM
+		if math.Cos(offset) < 0 {
+			alongTrack = -alongTrack
+		}
+		if alongTrack < 0 || alongTrack > corridor.Distance {
+			continue
+		}
+
+		corridor.Airports = append(corridor.Airports, CorridorAirport{
+			Airport:    airport,
+			AlongTrack: alongTrack,
+			CrossTrack: crossTrack * earthRadiusNM,
+		})
+		if airport.HasFBO {
+			corridor.FBOCount++
+		}
+	}
+
+	sort.SliceStable(corridor.Airports, func(i, j int) bool {
+		if corridor.Airports[i].AlongTrack != corridor.Airports[j].AlongTrack {
+			return corridor.Airports[i].AlongTrack < corridor.Airports[j].AlongTrack
+		}
+		return corridor.Airports[i].Airport.ICAO < corridor.Airports[j].Airport.ICAO
+	})
+
+	// Break the route at each FBO, measuring along the track
+	start, startAlong := from, 0.0
+	addStretch := func(end models.Airport, endAlong float64) {
+		stretch := CorridorStretch{
+			From:    start,
+			To:      end,
+			Length:  endAlong - startAlong,
+			TooLong: endAlong-startAlong > maxDistance,
+		}
+		if stretch.TooLong {
+			stretch.Split = corridorSplit(corridor.Airports, startAlong, endAlong)
+		}
+		corridor.Stretches = append(corridor.Stretches, stretch)
+		start, startAlong = end, endAlong
+	}
+	for _, airport := range corridor.Airports {
+		if airport.Airport.HasFBO {
+			addStretch(airport.Airport, airport.AlongTrack)
+		}
+	}
+	addStretch(to, corridor.Distance)
+
+	return corridor, nil
+}
+
+// corridorSplit returns the eligible airport without an FBO nearest the middle of a stretch, or nil if there's none
+func corridorSplit(airports []CorridorAirport, startAlong, endAlong float64) *CorridorAirport {
+	middle := (startAlong + endAlong) / 2
+
+	var split *CorridorAirport
+	for i, airport := range airports {
+		if airport.AlongTrack <= startAlong || airport.AlongTrack >= endAlong || airport.Airport.HasFBO || airport.Airport.IsExcluded {
+			continue
+		}
+		if split == nil || math.Abs(airport.AlongTrack-middle) < math.Abs(split.AlongTrack-middle) {
+			split = &airports[i]
+		}
+	}
+	return split
+}
+
+// initialBearing returns the initial great-circle bearing from one point to another, in radians
+func initialBearing(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1Rad := lat1 * (math.Pi / 180.0)
+	lat2Rad := lat2 * (math.Pi / 180.0)
+	dLon := (lon2 - lon1) * (math.Pi / 180.0)
+
+	y := math.Sin(dLon) * math.Cos(lat2Rad)
+	x := math.Cos(lat1Rad)*math.Sin(lat2Rad) - math.Sin(lat1Rad)*math.Cos(lat2Rad)*math.Cos(dLon)
+	return math.Atan2(y, x)
+}
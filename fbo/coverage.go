@@ -0,0 +1,97 @@
+package fbo
+
+import (
+	"github.com/julietrb1/offair-cli/models"
+	"math"
+	"sort"
+)
+
+// Coverage describes how much of the airport database is within reach of the FBO network
+type Coverage struct {
+	CoveredAirports        int     `json:"covered_airports"`
+	TotalAirports          int     `json:"total_airports"`
+	CoveragePercent        float64 `json:"coverage_percent"`
+	AverageNearestDistance float64 `json:"average_nearest_distance"`
+}
+
+// CalculateCoverage counts the airports within radius of an FBO and their average distance to the nearest FBO
+// Airports without coordinates are ignored
+func CalculateCoverage(airports, fbos []models.Airport, radius float64) Coverage {
+	index := newAirportIndex(fbos)
+
+	var coverage Coverage
+	var totalNearest float64
+	for _, airport := range airports {
+		if airport.Latitude == nil || airport.Longitude == nil {
+			continue
+		}
+		coverage.TotalAirports++
+
+		nearest := index.nearestDistance(*airport.Latitude, *airport.Longitude)
+		if nearest <= radius {
+			coverage.CoveredAirports++
+		}
+		if !math.IsInf(nearest, 1) {
+			totalNearest += nearest
+		}
+	}
+
+	if coverage.TotalAirports > 0 {
+		coverage.CoveragePercent = float64(coverage.CoveredAirports) / float64(coverage.TotalAirports) * 100.0
+		if len(index.airports) > 0 {
+			coverage.AverageNearestDistance = totalNearest / float64(coverage.TotalAirports)
+		}
+	}
+
+	return coverage
+}
+
+// airportIndex holds airports with coordinates sorted by latitude, so searches can skip
+// airports whose latitude alone puts them out of range
+type airportIndex struct {
+	airports []models.Airport
+}
+
+// newAirportIndex builds an index of the airports that have coordinates
+func newAirportIndex(airports []models.Airport) airportIndex {
+	var index airportIndex
+	for _, airport := range airports {
+		if airport.Latitude != nil && airport.Longitude != nil {
+			index.airports = append(index.airports, airport)
+		}
+	}
+
+	sort.Slice(index.airports, func(i, j int) bool {
+		return *index.airports[i].Latitude < *index.airports[j].Latitude
+	})
+
+	return index
+}
+
+// within calls visit for every indexed airport within radius of a point
+func (index airportIndex) within(lat, lon, radius float64, visit func(i int, distance float64)) {
+	// One degree of latitude is 60 nm, so nothing further than radius/60 degrees away can be in range
+	band := radius / 60.0
+	start := sort.Search(len(index.airports), func(i int) bool {
+		return *index.airports[i].Latitude >= lat-band
+	})
+
+	for i := start; i < len(index.airports) && *index.airports[i].Latitude <= lat+band; i++ {
+		distance := CalculateDistance(lat, lon, *index.airports[i].Latitude, *index.airports[i].Longitude)
+		if distance <= radius {
+			visit(i, distance)
+		}
+	}
+}
+
+// nearestDistance returns the distance to the closest indexed airport, or +Inf if the index is empty
+func (index airportIndex) nearestDistance(lat, lon float64) float64 {
+	nearest := math.Inf(1)
+	for _, airport := range index.airports {
+		distance := CalculateDistance(lat, lon, *airport.Latitude, *airport.Longitude)
+		if distance < nearest {
+			nearest = distance
+		}
+	}
+	return nearest
+}
@@ -0,0 +1,284 @@
+package fbo
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/models"
+	"math"
+	"sort"
+)
+
+// maxGapSamplePoints caps the number of grid points sampled when looking for coverage gaps
+const maxGapSamplePoints = 20000
+
+// CoverageGap is a region inside the network's footprint with no FBO within the coverage radius
+type CoverageGap struct {
+	// Latitude and Longitude are the centre of the largest empty circle found in the gap
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	// Radius is the distance from the centre to the nearest FBO
+	Radius     float64   `json:"radius"`
+	NearestFBO NearbyFBO `json:"nearest_fbo"`
+	// AirportsInGap counts the airports within Radius of the centre
+	AirportsInGap int `json:"airports_in_gap"`
+	// Proposal is the best-scoring eligible airport within Radius of the centre, or nil if there isn't one
+	Proposal         *CandidateScore `json:"proposal"`
+	ProposalDistance float64         `json:"proposal_distance"`
+}
+
+// CoverageGapAnalysis is the result of sampling the network's footprint for coverage gaps
+type CoverageGapAnalysis struct {
+	FBOCount       int     `json:"fbo_count"`
+	CoverageRadius float64 `json:"coverage_radius"`
+	// GridSpacing is the distance between sampled points, in nm
+	GridSpacing     float64       `json:"grid_spacing"`
+	SampledPoints   int           `json:"sampled_points"`
+	UncoveredPoints int           `json:"uncovered_points"`
+	Gaps            []CoverageGap `json:"gaps"`
+}
+
+// FindCoverageGaps finds the largest regions inside the FBO network's footprint without an FBO in reach,
+// and proposes an airport to fill each one
+func FindCoverageGaps(db *sqlx.DB, coverageRadius float64, limit int, optimalDistance float64, requireLights bool, preferredSize *int, filter CandidateFilter, scorer Scorer) (string, error) {
+	// Define color functions
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	analysis, err := AnalyseCoverageGaps(db, coverageRadius, limit, optimalDistance, requireLights, preferredSize, filter, scorer)
+	if err != nil {
+		return "", err
+	}
+
+	result := fmt.Sprintf("%s %s %.2f nm, %s %.2f nm\n",
+		bold("Using:"),
+		bold("coverage radius:"), coverageRadius,
+		bold("optimal distance:"), optimalDistance)
+	if !filter.IsEmpty() {
+		result += fmt.Sprintf("%s %s\n", bold("Candidate filters:"), filter)
+	}
+	if scorer != nil && scorer.Name() != DefaultScorerName {
+		result += fmt.Sprintf("%s %s\n", bold("Scoring:"), scorer.Name())
+	}
+
+	uncoveredPercent := 0.0
+	if analysis.SampledPoints > 0 {
+		uncoveredPercent = float64(analysis.UncoveredPoints) / float64(analysis.SampledPoints) * 100.0
+	}
+	result += fmt.Sprintf("%s %d points %.0f nm apart inside the footprint of %d FBOs, %.1f%% of them beyond %.0f nm of an FBO.\n",
+		bold("Sampled:"), analysis.SampledPoints, analysis.GridSpacing, analysis.FBOCount, uncoveredPercent, coverageRadius)
+
+	if len(analysis.Gaps) == 0 {
+		result += fmt.Sprintf("\n%s\n", green("No coverage gaps found inside the network's footprint."))
+		return result, nil
+	}
+
+	result += fmt.Sprintf("\n%s\n", bold(cyan("Largest coverage gaps:")))
+	for i, gap := range analysis.Gaps {
+		result += fmt.Sprintf("%-3d %s %.2f, %.2f  %s %d nm to %s  %s %d\n",
+			i+1,
+			bold("Centre:"), gap.Latitude, gap.Longitude,
+			bold("Nearest FBO:"), int(math.Round(gap.Radius)), gap.NearestFBO.ICAO,
+			bold("Airports in gap:"), gap.AirportsInGap)
+
+		if gap.Proposal == nil {
+			result += fmt.Sprintf("    %s\n", yellow("No eligible airport in the gap"))
+			continue
+		}
+		result += fmt.Sprintf("    %s %s %s, %d nm from the centre, score %d\n",
+			green("Proposed:"),
+			bold(gap.Proposal.Airport.Name), cyan("("+gap.Proposal.Airport.ICAO+")"),
+			int(math.Round(gap.ProposalDistance)), int(gap.Proposal.Score))
+	}
+
+	return result, nil
+}
+
+// AnalyseCoverageGaps samples a grid over the convex hull of the FBOs and finds points beyond coverageRadius of
+// every FBO. The farthest such point becomes a gap's centre, points within its reach are set aside, and this
+// repeats for up to limit gaps. Each gap proposes the best-scoring airport passing the filter inside it.
+func AnalyseCoverageGaps(db *sqlx.DB, coverageRadius float64, limit int, optimalDistance float64, requireLights bool, preferredSize *int, filter CandidateFilter, scorer Scorer) (CoverageGapAnalysis, error) {
+	if scorer == nil {
+		scorer = NewDefaultScorer()
+	}
+	if coverageRadius <= 0 {
+		return CoverageGapAnalysis{}, fmt.Errorf("the coverage radius must be greater than zero")
+	}
+
+	var airports []models.Airport
+	err := db.Select(&airports, "SELECT * FROM airports WHERE latitude IS NOT NULL AND longitude IS NOT NULL")
+	if err != nil {
+		return CoverageGapAnalysis{}, fmt.Errorf("error fetching airports: %w", err)
+	}
+
+	var fbos []models.Airport
+	err = db.Select(&fbos, "SELECT * FROM airports WHERE has_fbo = TRUE AND latitude IS NOT NULL AND longitude IS NOT NULL")
+	if err != nil {
+		return CoverageGapAnalysis{}, fmt.Errorf("error fetching FBOs: %w", err)
+	}
+	if len(fbos) < 3 {
+		return CoverageGapAnalysis{}, fmt.Errorf("at least 3 FBOs with coordinates are needed to outline the network, found %d", len(fbos))
+	}
+
+	hull := convexHull(fbos)
+	if len(hull) < 3 {
+		return CoverageGapAnalysis{}, fmt.Errorf("the FBOs lie in a line, so the network has no area to search")
+	}
+
+	analysis := CoverageGapAnalysis{
+		FBOCount:       len(fbos),
+		CoverageRadius: coverageRadius,
+		Gaps:           []CoverageGap{},
+	}
+
+	// Sample the hull's bounding box, a quarter of the coverage radius apart, widening the spacing if that's too many points
+	minLat, maxLat, minLon, maxLon := hull[0].lat, hull[0].lat, hull[0].lon, hull[0].lon
+	for _, p := range hull {
+		minLat, maxLat = math.Min(minLat, p.lat), math.Max(maxLat, p.lat)
+		minLon, maxLon = math.Min(minLon, p.lon), math.Max(maxLon, p.lon)
+	}
+	spacing := coverageRadius / 4
+	midLatCos := math.Max(math.Cos((minLat+maxLat)/2*math.Pi/180.0), 0.1)
+	for ((maxLat-minLat)*60/spacing+1)*((maxLon-minLon)*60*midLatCos/spacing+1) > maxGapSamplePoints {
+		spacing *= 1.5
+	}
+	analysis.GridSpacing = spacing
+
+	type samplePoint struct {
+		lat, lon, nearest float64
+		nearestFBO        string
+	}
+	var uncovered []samplePoint
+	latStep := spacing / 60.0
+	for lat := minLat; lat <= maxLat; lat += latStep {
+		lonStep := spacing / (60.0 * math.Max(math.Cos(lat*math.Pi/180.0), 0.1))
+		for lon := minLon; lon <= maxLon; lon += lonStep {
+			if !hullContains(hull, hullPoint{lat: lat, lon: lon}) {
+				continue
+			}
+			analysis.SampledPoints++
+
+			point := samplePoint{lat: lat, lon: lon, nearest: math.Inf(1)}
+			for _, fbo := range fbos {
+				distance := CalculateDistance(lat, lon, *fbo.Latitude, *fbo.Longitude)
+				if distance < point.nearest {
+					point.nearest, point.nearestFBO = distance, fbo.ICAO
+				}
+			}
+			if point.nearest > coverageRadius {
+				uncovered = append(uncovered, point)
+			}
+		}
+	}
+	analysis.UncoveredPoints = len(uncovered)
+
+	// Take the farthest uncovered point as a gap's centre, then set aside the points that gap accounts for
+	sort.Slice(uncovered, func(i, j int) bool {
+		return uncovered[i].nearest > uncovered[j].nearest
+	})
+	claimed := make([]bool, len(uncovered))
+	for i, centre := range uncovered {
+		if limit > 0 && len(analysis.Gaps) >= limit {
+			break
+		}
+		if claimed[i] {
+			continue
+		}
+
+		for j := i; j < len(uncovered); j++ {
+			if !claimed[j] && CalculateDistance(centre.lat, centre.lon, uncovered[j].lat, uncovered[j].lon) <= centre.nearest {
+				claimed[j] = true
+			}
+		}
+
+		analysis.Gaps = append(analysis.Gaps, CoverageGap{
+			Latitude:   centre.lat,
+			Longitude:  centre.lon,
+			Radius:     centre.nearest,
+			NearestFBO: NearbyFBO{ICAO: centre.nearestFBO, Distance: centre.nearest},
+		})
+	}
+
+	// Propose the best-scoring eligible airport inside each gap
+	ctx := newScoringContext(airports, optimalDistance, requireLights, preferredSize)
+	for i := range analysis.Gaps {
+		gap := &analysis.Gaps[i]
+		ctx.index.within(gap.Latitude, gap.Longitude, gap.Radius, func(j int, distance float64) {
+			gap.AirportsInGap++
+
+			airport := ctx.index.airports[j]
+			if airport.HasFBO || airport.IsExcluded || (requireLights && !airport.HasLights) || !filter.Matches(airport) {
+				return
+			}
+
+			score, ok := scorer.ScoreCandidate(ctx, airport, fbos)
+			if !ok {
+				return
+			}
+			if gap.Proposal == nil || score.Score > gap.Proposal.Score ||
+				(score.Score == gap.Proposal.Score && distance < gap.ProposalDistance) {
+				gap.Proposal = &score
+				gap.ProposalDistance = distance
+			}
+		})
+	}
+
+	return analysis, nil
+}
+
+// hullPoint is a point of the network's outline, in degrees
+type hullPoint struct {
+	lat, lon float64
+}
+
+// convexHull returns the convex hull of the airports' positions in counter-clockwise order, treating
+// latitude and longitude as plane coordinates (Andrew's monotone chain)
+func convexHull(airports []models.Airport) []hullPoint {
+	var points []hullPoint
+	for _, airport := range airports {
+		points = append(points, hullPoint{lat: *airport.Latitude, lon: *airport.Longitude})
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].lon != points[j].lon {
+			return points[i].lon < points[j].lon
+		}
+		return points[i].lat < points[j].lat
+	})
+
+	cross := func(o, a, b hullPoint) float64 {
+		return (a.lon-o.lon)*(b.lat-o.lat) - (a.lat-o.lat)*(b.lon-o.lon)
+	}
+
+	var hull []hullPoint
+	// Lower hull, then upper hull
+	for _, p := range points {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(points) - 2; i >= 0; i-- {
+		p := points[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+
+	// The last point repeats the first
+	return hull[:len(hull)-1]
+}
+
+// hullContains reports whether a point is inside or on a counter-clockwise convex hull
+func hullContains(hull []hullPoint, p hullPoint) bool {
+	for i := range hull {
+		a, b := hull[i], hull[(i+1)%len(hull)]
+		if (b.lon-a.lon)*(p.lat-a.lat)-(b.lat-a.lat)*(p.lon-a.lon) < 0 {
+			return false
+		}
+	}
+	return true
+}
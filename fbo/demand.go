@@ -0,0 +1,369 @@
+package fbo
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/models"
+	"math"
+	"sort"
+	"strings"
+)
+
+// DemandWeights derive an airport's demand from its details, for airports without a user-assigned weight
+type DemandWeights struct {
+	// Base is every airport's demand before its details are counted
+	Base float64 `json:"base"`
+	// PerSize is added for each step of airport size
+	PerSize  float64 `json:"per_size"`
+	Lights   float64 `json:"lights"`
+	Basecamp float64 `json:"basecamp"`
+}
+
+// DefaultDemandWeights returns the standard weights for deriving demand
+func DefaultDemandWeights() DemandWeights {
+	return DemandWeights{
+		Base:     1.0,
+		PerSize:  1.0,
+		Lights:   1.0,
+		Basecamp: 2.0,
+	}
+}
+
+// Demand returns the airport's user-assigned demand weight, or the one derived from its details
+func (w DemandWeights) Demand(airport models.Airport) float64 {
+	if airport.DemandWeight != nil {
+		return *airport.DemandWeight
+	}
+	return w.Derive(airport)
+}
+
+// Derive calculates the airport's demand from its size, lights and basecamp status, ignoring any user-assigned weight
+func (w DemandWeights) Derive(airport models.Airport) float64 {
+	demand := w.Base
+	if airport.Size != nil {
+		demand += float64(*airport.Size) * w.PerSize
+	}
+	if airport.HasLights {
+		demand += w.Lights
+	}
+	if airport.IsBasecamp {
+		demand += w.Basecamp
+	}
+	return math.Max(demand, 0)
+}
+
+// AirportDemand is an airport's demand weight and whether the FBO network serves it
+type AirportDemand struct {
+	Airport models.Airport `json:"airport"`
+	Demand  float64        `json:"demand"`
+	// Assigned is true when the weight was set by the user rather than derived
+	Assigned bool `json:"assigned"`
+	// NearestFBO is the distance to the closest FBO in nm, or -1 if there are no FBOs
+	NearestFBO float64 `json:"nearest_fbo"`
+	Covered    bool    `json:"covered"`
+}
+
+// DemandAnalysis is the demand across the airport database and how much of it is within the optimal distance of an FBO
+type DemandAnalysis struct {
+	Weights         DemandWeights `json:"weights"`
+	OptimalDistance float64       `json:"optimal_distance"`
+	TotalDemand     float64       `json:"total_demand"`
+	CoveredDemand   float64       `json:"covered_demand"`
+	CoveragePercent float64       `json:"coverage_percent"`
+	AssignedCount   int           `json:"assigned_count"`
+	// Airports are ordered by demand, highest first
+	Airports []AirportDemand `json:"airports"`
+}
+
+// ListAirportDemand reports each airport's demand weight and the share of total demand within the optimal
+// distance of an FBO, listing the limit airports with the most demand and the most unserved demand
+func ListAirportDemand(db *sqlx.DB, weights DemandWeights, optimalDistance float64, limit int) (string, error) {
+	// Define color functions
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	analysis, err := AnalyseAirportDemand(db, weights, optimalDistance)
+	if err != nil {
+		return "", err
+	}
+
+	result := fmt.Sprintf("%s %s %.2f nm\n", bold("Using:"), bold("optimal distance:"), optimalDistance)
+	result += fmt.Sprintf("%s base %.1f, %.1f per size, %.1f for lights, %.1f for a basecamp\n",
+		bold("Derived demand:"), weights.Base, weights.PerSize, weights.Lights, weights.Basecamp)
+	result += fmt.Sprintf("%s %.1f of %.1f (%.1f%%) across %d airports, %d with assigned weights\n",
+		bold("Demand within the optimal distance of an FBO:"),
+		analysis.CoveredDemand, analysis.TotalDemand, analysis.CoveragePercent, len(analysis.Airports), analysis.AssignedCount)
+
+	if len(analysis.Airports) == 0 {
+		result += fmt.Sprintf("\n%s\n", yellow("No airports with latitude/longitude information found."))
+		return result, nil
+	}
+
+	formatAirports := func(heading string, airports []AirportDemand) string {
+		section := fmt.Sprintf("\n%s\n", bold(cyan(heading)))
+		section += bold(fmt.Sprintf("%-3s %-40s  %7s  %-8s  %11s", "#", "Airport", "Demand", "Source", "Nearest FBO")) + "\n"
+		for i, demand := range airports {
+			if i == limit {
+				break
+			}
+
+			source := "derived"
+			if demand.Assigned {
+				source = "assigned"
+			}
+			nearest := "none"
+			if demand.NearestFBO >= 0 {
+				nearest = fmt.Sprintf("%.0f nm", demand.NearestFBO)
+			}
+			if demand.Covered {
+				nearest = green(fmt.Sprintf("%11s", nearest))
+			} else {
+				nearest = yellow(fmt.Sprintf("%11s", nearest))
+			}
+
+			section += fmt.Sprintf("%-3d %-40s  %7.1f  %-8s  %s\n",
+				i+1,
+				bold(demand.Airport.Name)+" "+cyan("("+demand.Airport.ICAO+")"),
+				demand.Demand, source, nearest)
+		}
+		return section
+	}
+
+	result += formatAirports("Highest demand:", analysis.Airports)
+
+	var unserved []AirportDemand
+	for _, demand := range analysis.Airports {
+		if !demand.Covered && demand.Demand > 0 {
+			unserved = append(unserved, demand)
+		}
+	}
+	if len(unserved) == 0 {
+		result += fmt.Sprintf("\n%s\n", green("All demand is within the optimal distance of an FBO."))
+	} else {
+		result += formatAirports("Highest unserved demand:", unserved)
+	}
+
+	return result, nil
+}
+
+// AnalyseAirportDemand weighs every airport with coordinates and checks whether it's within the optimal distance
+// of an FBO
+func AnalyseAirportDemand(db *sqlx.DB, weights DemandWeights, optimalDistance float64) (DemandAnalysis, error) {
+	var airports []models.Airport
+	err := db.Select(&airports, "SELECT * FROM airports WHERE latitude IS NOT NULL AND longitude IS NOT NULL")
+	if err != nil {
+		return DemandAnalysis{}, fmt.Errorf("error fetching airports: %w", err)
+	}
+
+	var fbos []models.Airport
+	for _, airport := range airports {
+		if airport.HasFBO {
+			fbos = append(fbos, airport)
+		}
+	}
+	fboIndex := newAirportIndex(fbos)
+
+	analysis := DemandAnalysis{
+		Weights:         weights,
+		OptimalDistance: optimalDistance,
+		Airports:        []AirportDemand{},
+	}
+	for _, airport := range airports {
+		demand := AirportDemand{
+			Airport:    airport,
+			Demand:     weights.Demand(airport),
+			Assigned:   airport.DemandWeight != nil,
+			NearestFBO: fboIndex.nearestDistance(*airport.Latitude, *airport.Longitude),
+		}
+		if math.IsInf(demand.NearestFBO, 1) {
+			demand.NearestFBO = -1
+		}
+		demand.Covered = demand.NearestFBO >= 0 && demand.NearestFBO <= optimalDistance
+
+		analysis.TotalDemand += demand.Demand
+		if demand.Covered {
+			analysis.CoveredDemand += demand.Demand
+		}
+		if demand.Assigned {
+			analysis.AssignedCount++
+		}
+		analysis.Airports = append(analysis.Airports, demand)
+	}
+	if analysis.TotalDemand > 0 {
+		analysis.CoveragePercent = analysis.CoveredDemand / analysis.TotalDemand * 100.0
+	}
+
+	sort.SliceStable(analysis.Airports, func(i, j int) bool {
+		if analysis.Airports[i].Demand != analysis.Airports[j].Demand {
+			return analysis.Airports[i].Demand > analysis.Airports[j].Demand
+		}
+		return analysis.Airports[i].Airport.ICAO < analysis.Airports[j].Airport.ICAO
+	})
+
+	return analysis, nil
+}
+
+// SetDemandWeight assigns an airport's demand weight, or clears it to go back to the derived weight when weight is nil
+func SetDemandWeight(db *sqlx.DB, icao string, weight *float64) error {
+	if weight != nil && *weight < 0 {
+		return fmt.Errorf("demand weight can't be negative")
+	}
+
+	result, err := db.Exec("UPDATE airports SET demand_weight = ? WHERE icao = ?", weight, strings.ToUpper(icao))
+	if err != nil {
+		return fmt.Errorf("error updating demand weight: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("airport %s not found in the local database", strings.ToUpper(icao))
+	}
+	return nil
+}
+
+// DemandScorer scores on demand-weighted coverage within the optimal distance: candidates by the demand they bring
+// within it, and FBOs by how much of the demand they serve is also served by the rest of the network
+type DemandScorer struct {
+	Demand                    DemandWeights
+	NewDemandWeight           float64
+	CloserDemandWeight        float64
+	ExactSizeBonus            float64
+	NearSizeBonus             float64
+	NoLightsPenalty           float64
+	RedundancyNearSizePenalty float64
+	RedundancyFarSizePenalty  float64
+	RedundancyLightsCredit    float64
+}
+
+// NewDemandScorer creates a demand scorer with its standard weights
+func NewDemandScorer() *DemandScorer {
+	return &DemandScorer{
+		Demand:                    DefaultDemandWeights(),
+		NewDemandWeight:           10.0,
+		CloserDemandWeight:        1.0,
+		ExactSizeBonus:            15.0,
+		NearSizeBonus:             7.5,
+		NoLightsPenalty:           10.0,
+		RedundancyNearSizePenalty: 5.0,
+		RedundancyFarSizePenalty:  10.0,
+		RedundancyLightsCredit:    10.0,
+	}
+}
+
+// Name returns the name of the demand scorer
+func (s DemandScorer) Name() string {
+	return DemandScorerName
+}
+
+// ScoreCandidate scores a candidate by the demand it would newly bring within the optimal distance of an FBO, and
+// the served demand it would be closer to
+func (s DemandScorer) ScoreCandidate(ctx ScoringContext, candidate models.Airport, existingFBOs []models.Airport) (CandidateScore, bool) {
+	if candidate.Latitude == nil || candidate.Longitude == nil {
+		return CandidateScore{}, false
+	}
+
+	fbos := newAirportIndex(existingFBOs)
+	if len(fbos.airports) == 0 {
+		return CandidateScore{}, false
+	}
+
+	newDemand, closerDemand := 0.0, 0.0
+	ctx.index.within(*candidate.Latitude, *candidate.Longitude, ctx.OptimalDistance, func(i int, distance float64) {
+		airport := ctx.index.airports[i]
+		nearest := fbos.nearestDistance(*airport.Latitude, *airport.Longitude)
+		if nearest > ctx.OptimalDistance {
+			newDemand += s.Demand.Demand(airport)
+		} else if distance < nearest {
+			closerDemand += s.Demand.Demand(airport)
+		}
+	})
+	score := newDemand*s.NewDemandWeight + closerDemand*s.CloserDemandWeight
+
+	var terms []ScoreTerm
+	if ctx.Explain {
+		terms = append(terms,
+			ScoreTerm{
+				Term:  fmt.Sprintf("%.1f demand newly served x %.1f", newDemand, s.NewDemandWeight),
+				Value: newDemand * s.NewDemandWeight,
+				Score: newDemand * s.NewDemandWeight,
+			},
+			ScoreTerm{
+				Term:  fmt.Sprintf("%.1f served demand brought closer x %.1f", closerDemand, s.CloserDemandWeight),
+				Value: closerDemand * s.CloserDemandWeight,
+				Score: score,
+			})
+	}
+
+	bonus := sizeBonus(ctx, candidate, s.ExactSizeBonus, s.NearSizeBonus)
+	penalty := lightsPenalty(ctx, candidate, s.NoLightsPenalty)
+	score += bonus - penalty
+	terms = append(terms, preferenceTerms(ctx, candidate, score, bonus, penalty)...)
+	if score < 0 {
+		if ctx.Explain {
+			terms = append(terms, ScoreTerm{Term: "Floor at 0", Value: -score, Score: 0})
+		}
+		score = 0
+	}
+	if rounded := math.Floor(score); ctx.Explain && rounded != score {
+		terms = append(terms, ScoreTerm{Term: "Round down to a whole number", Value: rounded - score, Score: rounded})
+	}
+
+	eligible, total, connections := candidateConnections(candidate, existingFBOs, ctx.OptimalDistance)
+	candidateScore := CandidateScore{
+		Airport:             candidate,
+		Score:               math.Floor(score),
+		EligibleConnections: eligible,
+		TotalConnections:    total,
+		Connections:         connections,
+		Terms:               terms,
+	}
+	if ctx.Explain {
+		candidateScore.AllConnections = allCandidateConnections(candidate, existingFBOs, ctx.OptimalDistance)
+	}
+	return candidateScore, true
+}
+
+// ScoreRedundancy scores an FBO by the percentage of the demand within the optimal distance of it that the rest of
+// the network also serves, adjusted for size and lights
+func (s DemandScorer) ScoreRedundancy(ctx ScoringContext, fbo models.Airport, remainingFBOs []models.Airport, original, without NetworkMetrics) float64 {
+	if fbo.Latitude == nil || fbo.Longitude == nil {
+		return 0
+	}
+
+	remaining := newAirportIndex(remainingFBOs)
+	served, stillServed := 0.0, 0.0
+	ctx.index.within(*fbo.Latitude, *fbo.Longitude, ctx.OptimalDistance, func(i int, distance float64) {
+		airport := ctx.index.airports[i]
+		demand := s.Demand.Demand(airport)
+		served += demand
+		if remaining.nearestDistance(*airport.Latitude, *airport.Longitude) <= ctx.OptimalDistance {
+			stillServed += demand
+		}
+	})
+
+	score := 100.0
+	if served > 0 {
+		score = stillServed / served * 100.0
+	}
+
+	return score + redundancyPreferenceAdjustment(ctx, fbo, s.RedundancyNearSizePenalty, s.RedundancyFarSizePenalty, s.RedundancyLightsCredit)
+}
+
+// weights maps the configurable weight names to the scorer's fields
+func (s *DemandScorer) weights() map[string]*float64 {
+	return map[string]*float64{
+		"demand_base":                  &s.Demand.Base,
+		"demand_per_size":              &s.Demand.PerSize,
+		"demand_lights":                &s.Demand.Lights,
+		"demand_basecamp":              &s.Demand.Basecamp,
+		"new_demand_weight":            &s.NewDemandWeight,
+		"closer_demand_weight":         &s.CloserDemandWeight,
+		"exact_size_bonus":             &s.ExactSizeBonus,
+		"near_size_bonus":              &s.NearSizeBonus,
+		"no_lights_penalty":            &s.NoLightsPenalty,
+		"redundancy_near_size_penalty": &s.RedundancyNearSizePenalty,
+		"redundancy_far_size_penalty":  &s.RedundancyFarSizePenalty,
+		"redundancy_lights_credit":     &s.RedundancyLightsCredit,
+	}
+}
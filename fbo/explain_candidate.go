@@ -0,0 +1,191 @@
+package fbo
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/models"
+	"math"
+	"strings"
+)
+
+// CandidateExplanation breaks a candidate's score down into the terms that produced it
+type CandidateExplanation struct {
+	Scorer    string         `json:"scorer"`
+	Candidate CandidateScore `json:"candidate"`
+	// Rank is the candidate's position in the optimiser's ranking, or 0 if it isn't ranked
+	Rank        int         `json:"rank"`
+	RankedCount int         `json:"ranked_count"`
+	Notes       []string    `json:"notes"`
+	NearestFBOs []NearbyFBO `json:"nearest_fbos"`
+}
+
+// ExplainOptimalFBOLocation explains how an airport's score as a location for a new FBO was calculated
+func ExplainOptimalFBOLocation(db *sqlx.DB, icao string, optimalDistance, maxDistance float64, requireLights bool, preferredSize *int, filter CandidateFilter, scorer Scorer) (string, error) {
+	// Define color functions
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	explanation, err := ExplainCandidateScore(db, icao, optimalDistance, requireLights, preferredSize, filter, scorer)
+	if err != nil {
+		return "", err
+	}
+	candidate := explanation.Candidate
+
+	result := fmt.Sprintf("%s %s\n", bold(cyan("Score explanation for")),
+		bold(candidate.Airport.Name)+" "+cyan("("+candidate.Airport.ICAO+")"))
+	result += fmt.Sprintf("%s %s %.2f nm, %s %.2f nm\n",
+		bold("Using:"),
+		bold("optimal distance:"), optimalDistance,
+		bold("maximum distance:"), maxDistance)
+	result += fmt.Sprintf("%s %s\n", bold("Scoring:"), explanation.Scorer)
+
+	for _, note := range explanation.Notes {
+		result += fmt.Sprintf("%s %s\n", yellow("Note:"), note)
+	}
+
+	// Score terms, with the running score after each
+	result += fmt.Sprintf("\n%s\n", bold(cyan("Score terms:")))
+	for _, term := range candidate.Terms {
+		value := fmt.Sprintf("%+.2f", term.Value)
+		if term.Value > 0 {
+			value = green(value)
+		} else if term.Value < 0 {
+			value = red(value)
+		}
+		result += fmt.Sprintf("  %-55s %10s  %s %.2f\n", term.Term, value, bold("→"), term.Score)
+	}
+
+	rank := yellow("not ranked")
+	if explanation.Rank > 0 {
+		rank = fmt.Sprintf("ranked %d of %d", explanation.Rank, explanation.RankedCount)
+	}
+	result += fmt.Sprintf("%s %s (%s)\n", bold("Final score:"), bold(fmt.Sprintf("%d", int(candidate.Score))), rank)
+
+	// Every connection to the network, marking those within 20% of the optimal distance
+	result += fmt.Sprintf("\n%s %d/%d within 20%% of the optimal distance\n", bold(cyan("Connections:")),
+		candidate.EligibleConnections, candidate.TotalConnections)
+	displayLimit := 20
+	for i, conn := range candidate.AllConnections {
+		if i == displayLimit {
+			result += fmt.Sprintf("  ... and %d more\n", len(candidate.AllConnections)-displayLimit)
+			break
+		}
+
+		status := ""
+		if math.Abs(conn.Distance-optimalDistance) <= 0.2*optimalDistance {
+			status = green("optimal")
+		} else if conn.Distance > maxDistance {
+			status = red("beyond max")
+		}
+		result += fmt.Sprintf("  %-6s %6d nm  score %6.2f  %s\n", conn.ICAO, int(math.Round(conn.Distance)), conn.Contribution, status)
+	}
+
+	if len(explanation.NearestFBOs) > 0 {
+		var nearest []string
+		for _, nearby := range explanation.NearestFBOs {
+			nearest = append(nearest, fmt.Sprintf("%s (%d nm)", nearby.ICAO, int(math.Round(nearby.Distance))))
+		}
+		result += fmt.Sprintf("\n%s %s\n", bold(cyan("Nearest FBOs:")), strings.Join(nearest, ", "))
+	}
+
+	return result, nil
+}
+
+// ExplainCandidateScore scores one airport as a location for a new FBO, recording every term of the score, its rank
+// among the optimiser's candidates and the FBOs nearest to it. Airports the optimiser would skip are still scored,
+// with a note saying why they're skipped.
+func ExplainCandidateScore(db *sqlx.DB, icao string, optimalDistance float64, requireLights bool, preferredSize *int, filter CandidateFilter, scorer Scorer) (CandidateExplanation, error) {
+	if scorer == nil {
+		scorer = NewDefaultScorer()
+	}
+
+	var candidate models.Airport
+	err := db.Get(&candidate, "SELECT * FROM airports WHERE icao = ?", icao)
+	if err != nil {
+		return CandidateExplanation{}, fmt.Errorf("error fetching airport %s: %w", icao, err)
+	}
+	if candidate.Latitude == nil || candidate.Longitude == nil {
+		return CandidateExplanation{}, fmt.Errorf("airport %s has no latitude/longitude information", candidate.ICAO)
+	}
+
+	var airports []models.Airport
+	err = db.Select(&airports, "SELECT * FROM airports WHERE latitude IS NOT NULL AND longitude IS NOT NULL")
+	if err != nil {
+		return CandidateExplanation{}, fmt.Errorf("error fetching airports: %w", err)
+	}
+
+	var existingFBOs []models.Airport
+	err = db.Select(&existingFBOs, "SELECT * FROM airports WHERE has_fbo = TRUE")
+	if err != nil {
+		return CandidateExplanation{}, fmt.Errorf("error fetching existing FBOs: %w", err)
+	}
+
+	validFBOCount := 0
+	for _, fbo := range existingFBOs {
+		if fbo.Latitude != nil && fbo.Longitude != nil {
+			validFBOCount++
+		}
+	}
+	if validFBOCount < 2 {
+		return CandidateExplanation{}, &InsufficientFBOsError{Total: len(existingFBOs), WithCoords: validFBOCount}
+	}
+
+	// Score against the network without the airport's own FBO, so an existing FBO can be explained too
+	var otherFBOs []models.Airport
+	for _, fbo := range existingFBOs {
+		if fbo.ID != candidate.ID {
+			otherFBOs = append(otherFBOs, fbo)
+		}
+	}
+
+	explanation := CandidateExplanation{
+		Scorer:      scorer.Name(),
+		Notes:       []string{},
+		NearestFBOs: NearestFBOs(candidate, existingFBOs, 5),
+	}
+
+	if candidate.HasFBO {
+		explanation.Notes = append(explanation.Notes,
+			"already has an FBO, so it isn't a candidate; scored against the rest of the network")
+	}
+	if requireLights && !candidate.HasLights {
+		explanation.Notes = append(explanation.Notes, "has no lights and lights are required, so it isn't a candidate")
+	}
+	if !filter.Matches(candidate) {
+		explanation.Notes = append(explanation.Notes,
+			fmt.Sprintf("ruled out by the candidate filters (%s), so it isn't a candidate", filter))
+	}
+	if candidate.IsExcluded {
+		explanation.Notes = append(explanation.Notes, "is excluded, so it isn't a candidate")
+	}
+
+	ctx := newScoringContext(airports, optimalDistance, requireLights, preferredSize)
+	ctx.Explain = true
+	score, ok := scorer.ScoreCandidate(ctx, candidate, otherFBOs)
+	if !ok {
+		return CandidateExplanation{}, fmt.Errorf("airport %s can't be scored against the FBO network", candidate.ICAO)
+	}
+	explanation.Candidate = score
+	if score.Score <= 0 {
+		explanation.Notes = append(explanation.Notes, "scores zero, so the optimiser leaves it out")
+	}
+
+	// Find where the optimiser ranks it
+	analysis, err := ScoreOptimalFBOLocations(db, optimalDistance, requireLights, preferredSize, filter, scorer)
+	if err != nil {
+		return CandidateExplanation{}, err
+	}
+	explanation.RankedCount = len(analysis.Candidates)
+	for i, ranked := range analysis.Candidates {
+		if ranked.Airport.ID == candidate.ID {
+			explanation.Rank = i + 1
+			break
+		}
+	}
+
+	return explanation, nil
+}
@@ -1,9 +1,25 @@
 package fbo
 
+import "fmt"
+
 // NetworkMetrics holds metrics about the FBO network
 type NetworkMetrics struct {
-	averageDistance    float64
-	efficiencyScore    float64
-	optimalConnections int
-	totalConnections   int
+	AverageDistance    float64 `json:"average_distance"`
+	EfficiencyScore    float64 `json:"efficiency_score"`
+	OptimalConnections int     `json:"optimal_connections"`
+	TotalConnections   int     `json:"total_connections"`
+}
+
+// InsufficientFBOsError is returned when an analysis needs at least 2 FBOs with coordinates
+type InsufficientFBOsError struct {
+	Total      int
+	WithCoords int
+}
+
+func (e *InsufficientFBOsError) Error() string {
+	if e.Total < 2 {
+		return "there are fewer than 2 FBOs in the network"
+	}
+	return fmt.Sprintf("found %d FBOs in total, but only %d have valid latitude/longitude information; "+
+		"at least 2 FBOs with coordinates are needed", e.Total, e.WithCoords)
 }
@@ -1,39 +1,200 @@
 package fbo
 
 import (
+	"errors"
 	"fmt"
 	"github.com/fatih/color"
 	"github.com/jmoiron/sqlx"
 	"github.com/julietrb1/offair-cli/models"
 	"math"
+	"sort"
 	"strings"
 )
 
+// CandidateConnection is a connection between a candidate airport and an existing FBO
+type CandidateConnection struct {
+	ICAO         string  `json:"icao"`
+	Distance     float64 `json:"distance"`
+	Contribution float64 `json:"contribution"`
+}
+
+// ScoreTerm is one step in calculating a candidate's score: what was applied, by how much, and the score after it
+type ScoreTerm struct {
+	Term  string  `json:"term"`
+	Value float64 `json:"value"`
+	Score float64 `json:"score"`
+}
+
+// CandidateScore is an airport's score as a location for a new FBO
+type CandidateScore struct {
+	Airport             models.Airport        `json:"airport"`
+	Score               float64               `json:"score"`
+	EligibleConnections int                   `json:"eligible_connections"`
+	TotalConnections    int                   `json:"total_connections"`
+	Connections         []CandidateConnection `json:"connections"`
+	// Terms and AllConnections are only filled in when explaining a score
+	Terms          []ScoreTerm           `json:"terms,omitempty"`
+	AllConnections []CandidateConnection `json:"all_connections,omitempty"`
+}
+
+// OptimalLocationsAnalysis is the result of scoring every candidate airport for a new FBO
+type OptimalLocationsAnalysis struct {
+	AirportCount     int              `json:"airport_count"`
+	ExistingFBOCount int              `json:"existing_fbo_count"`
+	CandidateCount   int              `json:"candidate_count"`
+	Candidates       []CandidateScore `json:"candidates"`
+	// PinOverrides are the excluded airports that would otherwise have been candidates, with the rank they'd have had
+	PinOverrides []PinOverride `json:"pin_overrides"`
+}
+
 // FindOptimalFBOLocations finds optimal locations for FBOs
-func FindOptimalFBOLocations(db *sqlx.DB, optimalDistance, maxDistance float64, requireLights bool, preferredSize *int) (string, error) {
+func FindOptimalFBOLocations(db *sqlx.DB, optimalDistance, maxDistance float64, requireLights bool, preferredSize *int, filter CandidateFilter, scorer Scorer) (string, error) {
 	// Define color functions
 	bold := color.New(color.Bold).SprintFunc()
 	cyan := color.New(color.FgCyan).SprintFunc()
 	green := color.New(color.FgGreen).SprintFunc()
 	yellow := color.New(color.FgYellow).SprintFunc()
 	red := color.New(color.FgRed).SprintFunc()
+
+	analysis, err := ScoreOptimalFBOLocations(db, optimalDistance, requireLights, preferredSize, filter, scorer)
+	var insufficient *InsufficientFBOsError
+	if errors.As(err, &insufficient) {
+		if insufficient.Total < 2 {
+			return bold(yellow("There are fewer than 2 FBOs in the network. No optimization analysis possible.")), nil
+		}
+		return bold(yellow(fmt.Sprintf(
+			"Found %d FBOs in total, but only %d have valid latitude/longitude information. "+
+				"At least 2 FBOs with coordinates are needed for optimization analysis.",
+			insufficient.Total, insufficient.WithCoords))), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	airportScores := analysis.Candidates
+
+	// Build result string
+	result := fmt.Sprintf("%s %s %.2f nm, %s %.2f nm\n",
+		bold("Using:"),
+		bold("optimal distance:"), optimalDistance,
+		bold("maximum distance:"), maxDistance)
+
+	// Add information about lights requirement
+	if requireLights {
+		result += fmt.Sprintf("%s %s\n",
+			bold("Requiring airports with lights:"),
+			green("Yes"))
+	} else {
+		result += fmt.Sprintf("%s %s %s\n",
+			bold("Requiring airports with lights:"),
+			yellow("No"),
+			yellow("(airports without lights receive a score penalty)"))
+	}
+
+	// Add information about preferred size if specified
+	if preferredSize != nil {
+		result += fmt.Sprintf("%s %s %s %s\n",
+			bold("Preferred airport size:"),
+			green(fmt.Sprintf("%d", *preferredSize)),
+			green("(exact match receives bonus points,"),
+			green("sizes within ±1 receive smaller bonus)"))
+	}
+
+	if !filter.IsEmpty() {
+		result += fmt.Sprintf("%s %s\n", bold("Candidate filters:"), filter)
+	}
+
+	if scorer != nil && scorer.Name() != DefaultScorerName {
+		result += fmt.Sprintf("%s %s\n", bold("Scoring:"), scorer.Name())
+	}
+
+	result += fmt.Sprintf("%s %d airports, %d existing FBOs, and %d candidate airports.\n",
+		bold("Found:"),
+		analysis.AirportCount, analysis.ExistingFBOCount, analysis.CandidateCount)
+
+	// Show top 10 recommended airports
+	result += fmt.Sprintf("\n%s\n", bold(cyan("Top recommended airports for new FBOs:")))
+	limit := 10
+	if len(airportScores) < limit {
+		limit = len(airportScores)
+	}
+
+	// Use color functions for scores
+
+	for i := 0; i < limit; i++ {
+		airport := airportScores[i].Airport
+		score := airportScores[i].Score
+
+		// Limit to top 5 connections
+		connections := airportScores[i].Connections
+		displayLimit := 5
+		if len(connections) > displayLimit {
+			connections = connections[:displayLimit]
+		}
+
+		// Color code the score based on its value
+		var coloredScore string
+		intScore := int(score)
+		if intScore >= 80 {
+			coloredScore = green(fmt.Sprintf("%d", intScore))
+		} else if intScore >= 50 {
+			coloredScore = yellow(fmt.Sprintf("%d", intScore))
+		} else {
+			coloredScore = red(fmt.Sprintf("%d", intScore))
+		}
+
+		// Format with consistent column alignment for easier scanning like a table
+		scoreSection := fmt.Sprintf("Score: %s", coloredScore)
+		connectionsSection := fmt.Sprintf("Connections: %d/%d", airportScores[i].EligibleConnections, airportScores[i].TotalConnections)
+
+		result += fmt.Sprintf("%-3d %-40s  %-15s  %-20s\n",
+			i+1,
+			bold(airport.Name)+" "+cyan("("+airport.ICAO+")"),
+			bold(scoreSection),
+			bold(connectionsSection))
+
+		// Add details about eligible connections (limited to top 5)
+		if len(connections) > 0 {
+			var connectionDetails []string
+			for _, conn := range connections {
+				connectionDetails = append(connectionDetails, fmt.Sprintf("%s (%d nm)", conn.ICAO, int(math.Round(conn.Distance))))
+			}
+
+			result += fmt.Sprintf("   %s\n",
+				strings.Join(connectionDetails, ", "))
+		}
+	}
+
+	// Note the excluded airports that would have made the list
+	var heldBack []PinOverride
+	for _, override := range analysis.PinOverrides {
+		if override.Rank <= 10 {
+			heldBack = append(heldBack, override)
+		}
+	}
+	result += formatPinOverrides(heldBack, bold, cyan, yellow)
+
+	return result, nil
+}
+
+// ScoreOptimalFBOLocations scores every candidate airport passing the filter as a location for a new FBO,
+// best first, using scorer (or the default scorer if nil). Candidates scoring zero are left out.
+func ScoreOptimalFBOLocations(db *sqlx.DB, optimalDistance float64, requireLights bool, preferredSize *int, filter CandidateFilter, scorer Scorer) (OptimalLocationsAnalysis, error) {
+	if scorer == nil {
+		scorer = NewDefaultScorer()
+	}
+
 	// Get all airports
 	var airports []models.Airport
 	err := db.Select(&airports, "SELECT * FROM airports WHERE latitude IS NOT NULL AND longitude IS NOT NULL")
 	if err != nil {
-		return "", fmt.Errorf("error fetching airports: %w", err)
+		return OptimalLocationsAnalysis{}, fmt.Errorf("error fetching airports: %w", err)
 	}
 
 	// Get existing FBOs
 	var existingFBOs []models.Airport
 	err = db.Select(&existingFBOs, "SELECT * FROM airports WHERE has_fbo = TRUE")
 	if err != nil {
-		return "", fmt.Errorf("error fetching existing FBOs: %w", err)
-	}
-
-	// Check if we have enough FBOs with valid coordinates
-	if len(existingFBOs) < 2 {
-		return bold(yellow("There are fewer than 2 FBOs in the network. No optimization analysis possible.")), nil
+		return OptimalLocationsAnalysis{}, fmt.Errorf("error fetching existing FBOs: %w", err)
 	}
 
 	// Count FBOs with valid coordinates
@@ -44,15 +205,13 @@ func FindOptimalFBOLocations(db *sqlx.DB, optimalDistance, maxDistance float64,
 		}
 	}
 
+	// Check if we have enough FBOs with valid coordinates
 	if validFBOCount < 2 {
-		return bold(yellow(fmt.Sprintf(
-			"Found %d FBOs in total, but only %d have valid latitude/longitude information. "+
-				"At least 2 FBOs with coordinates are needed for optimization analysis.",
-			len(existingFBOs), validFBOCount))), nil
+		return OptimalLocationsAnalysis{}, &InsufficientFBOsError{Total: len(existingFBOs), WithCoords: validFBOCount}
 	}
 
 	// Filter out airports that already have FBOs and apply other filters
-	var candidateAirports []models.Airport
+	var candidateAirports, excludedAirports []models.Airport
 	for _, airport := range airports {
 		// Skip airports that already have FBOs
 		hasExistingFBO := false
@@ -71,118 +230,33 @@ func FindOptimalFBOLocations(db *sqlx.DB, optimalDistance, maxDistance float64,
 			continue
 		}
 
-		candidateAirports = append(candidateAirports, airport)
-	}
-
-	// Calculate scores for each candidate airport
-	type AirportScore struct {
-		Airport models.Airport
-		Score   float64
-	}
-
-	var airportScores []AirportScore
-	for _, candidate := range candidateAirports {
-		// Skip airports without latitude/longitude
-		if candidate.Latitude == nil || candidate.Longitude == nil {
+		// Skip airports ruled out by the candidate filters
+		if !filter.Matches(airport) {
 			continue
 		}
 
-		// Calculate distances to existing FBOs
-		var distances []float64
-		for _, fbo := range existingFBOs {
-			// Skip FBOs without latitude/longitude
-			if fbo.Latitude == nil || fbo.Longitude == nil {
-				continue
-			}
-
-			distance := CalculateDistance(*candidate.Latitude, *candidate.Longitude, *fbo.Latitude, *fbo.Longitude)
-			distances = append(distances, distance)
-		}
-
-		// Skip if no distances were calculated
-		if len(distances) == 0 {
+		// Excluded airports are scored only to report what the exclusion held back
+		if airport.IsExcluded {
+			excludedAirports = append(excludedAirports, airport)
 			continue
 		}
 
-		// Count how many connections are within the optimal range
-		optimalConnections := 0
-		totalConnections := len(distances)
-
-		// Calculate a score based on how many connections are within the optimal range
-		// Higher score is better (100 is perfect)
-		score := 0.0
-
-		for _, distance := range distances {
-			// Calculate how close this connection is to the optimal distance (as a percentage)
-			// 100% means exactly at optimal distance, 0% means very far from optimal
-			connectionScore := 100.0 - math.Min(100.0, (math.Abs(distance-optimalDistance)/optimalDistance)*100.0)
-
-			// If the connection is within 20% of the optimal distance, count it as an optimal connection
-			if math.Abs(distance-optimalDistance) <= 0.2*optimalDistance {
-				optimalConnections++
-			}
-
-			// Add this connection's score to the total
-			score += connectionScore
-		}
-
-		// If there are no eligible connections, set the score to zero
-		if optimalConnections == 0 {
-			score = 0.0
-		} else {
-			// Average the scores across all connections
-			score = score / float64(totalConnections)
-
-			// Bonus for having many connections within optimal range
-			optimalRatio := float64(optimalConnections) / float64(totalConnections)
-			score += optimalRatio * 20.0 // Up to 20 bonus points for having all connections optimal
-		}
-
-		// Cap at 100
-		if score > 100.0 {
-			score = 100.0
-		}
-
-		// Apply size preference if specified
-		if preferredSize != nil && candidate.Size != nil {
-			size := *candidate.Size
-			preferredSizeVal := *preferredSize
-
-			if size == preferredSizeVal {
-				// Moderate positive weight for exact match
-				score += 15.0
-			} else if size == preferredSizeVal+1 || size == preferredSizeVal-1 {
-				// Lesser positive weight for one size above or below
-				score += 7.5
-			}
-		}
-
-		// Apply negative weight for airports without lights if not required
-		if !requireLights && !candidate.HasLights {
-			score -= 10.0
-		}
-
-		// Ensure score is not negative
-		if score < 0 {
-			score = 0
-		}
+		candidateAirports = append(candidateAirports, airport)
+	}
 
-		// Cap at 100
-		if score > 100.0 {
-			score = 100.0
+	// Calculate scores for each candidate airport
+	ctx := newScoringContext(airports, optimalDistance, requireLights, preferredSize)
+	var airportScores []CandidateScore
+	for _, candidate := range candidateAirports {
+		airportScore, ok := scorer.ScoreCandidate(ctx, candidate, existingFBOs)
+		if !ok {
+			continue
 		}
-
-		// Round down to nearest whole number
-		score = math.Floor(score)
-
-		airportScores = append(airportScores, AirportScore{
-			Airport: candidate,
-			Score:   score,
-		})
+		airportScores = append(airportScores, airportScore)
 	}
 
 	// Filter out airports with zero scores
-	var nonZeroScores []AirportScore
+	var nonZeroScores []CandidateScore
 	for _, as := range airportScores {
 		if as.Score > 0 {
 			nonZeroScores = append(nonZeroScores, as)
@@ -199,129 +273,171 @@ func FindOptimalFBOLocations(db *sqlx.DB, optimalDistance, maxDistance float64,
 		}
 	}
 
-	// Build result string
-	result := fmt.Sprintf("%s %s %.2f nm, %s %.2f nm\n",
-		bold("Using:"),
-		bold("optimal distance:"), optimalDistance,
-		bold("maximum distance:"), maxDistance)
-
-	// Add information about lights requirement
-	if requireLights {
-		result += fmt.Sprintf("%s %s\n",
-			bold("Requiring airports with lights:"),
-			green("Yes"))
-	} else {
-		result += fmt.Sprintf("%s %s %s\n",
-			bold("Requiring airports with lights:"),
-			yellow("No"),
-			yellow("(airports without lights receive a score penalty)"))
-	}
-
-	// Add information about preferred size if specified
-	if preferredSize != nil {
-		result += fmt.Sprintf("%s %s %s %s\n",
-			bold("Preferred airport size:"),
-			green(fmt.Sprintf("%d", *preferredSize)),
-			green("(exact match receives bonus points,"),
-			green("sizes within ±1 receive smaller bonus)"))
+	// Note where each excluded airport would have ranked among the candidates
+	pinOverrides := []PinOverride{}
+	for _, airport := range excludedAirports {
+		excludedScore, ok := scorer.ScoreCandidate(ctx, airport, existingFBOs)
+		if !ok || excludedScore.Score <= 0 {
+			continue
+		}
+		rank := 1
+		for _, as := range airportScores {
+			if as.Score > excludedScore.Score {
+				rank++
+			}
+		}
+		pinOverrides = append(pinOverrides, PinOverride{
+			Airport: airport,
+			Action:  ScenarioAdd,
+			Rank:    rank,
+			Score:   excludedScore.Score,
+		})
 	}
+	sort.SliceStable(pinOverrides, func(i, j int) bool {
+		return pinOverrides[i].Rank < pinOverrides[j].Rank
+	})
+
+	return OptimalLocationsAnalysis{
+		AirportCount:     len(airports),
+		ExistingFBOCount: len(existingFBOs),
+		CandidateCount:   len(candidateAirports),
+		Candidates:       airportScores,
+		PinOverrides:     pinOverrides,
+	}, nil
+}
 
-	result += fmt.Sprintf("%s %d airports, %d existing FBOs, and %d candidate airports.\n",
-		bold("Found:"),
-		len(airports), len(existingFBOs), len(candidateAirports))
+// ScoreCandidate scores a candidate airport by how close its connections to the FBO network are to the optimal distance.
+// Returns false if the candidate or every FBO lacks latitude/longitude information.
+func (s DefaultScorer) ScoreCandidate(ctx ScoringContext, candidate models.Airport, existingFBOs []models.Airport) (CandidateScore, bool) {
+	optimalDistance := ctx.OptimalDistance
 
-	// Show top 10 recommended airports
-	result += fmt.Sprintf("\n%s\n", bold(cyan("Top recommended airports for new FBOs:")))
-	limit := 10
-	if len(airportScores) < limit {
-		limit = len(airportScores)
+	// Skip airports without latitude/longitude
+	if candidate.Latitude == nil || candidate.Longitude == nil {
+		return CandidateScore{}, false
 	}
 
-	// Use color functions for scores
+	// Calculate distances to existing FBOs
+	var connections []CandidateConnection
+	totalConnections := 0
 
-	for i := 0; i < limit; i++ {
-		airport := airportScores[i].Airport
-		score := airportScores[i].Score
+	// Calculate a score based on how many connections are within the optimal range
+	// Higher score is better (100 is perfect)
+	score := 0.0
 
-		// Track connections and their contribution to score
-		type Connection struct {
-			ICAO         string
-			Distance     float64
-			Contribution float64
+	// Record each step of the calculation when explaining the score
+	var terms []ScoreTerm
+	explain := func(term string, value float64) {
+		if ctx.Explain {
+			terms = append(terms, ScoreTerm{Term: term, Value: value, Score: score})
 		}
+	}
 
-		var connections []Connection
-		totalConnections := 0
-
-		for _, fbo := range existingFBOs {
-			if fbo.Latitude == nil || fbo.Longitude == nil || airport.Latitude == nil || airport.Longitude == nil {
-				continue
-			}
+	for _, fbo := range existingFBOs {
+		// Skip FBOs without latitude/longitude
+		if fbo.Latitude == nil || fbo.Longitude == nil {
+			continue
+		}
 
-			distance := CalculateDistance(*airport.Latitude, *airport.Longitude, *fbo.Latitude, *fbo.Longitude)
-			totalConnections++
+		distance := CalculateDistance(*candidate.Latitude, *candidate.Longitude, *fbo.Latitude, *fbo.Longitude)
+		totalConnections++
 
-			// Calculate how much this connection contributes to the score
-			contribution := 100.0 - math.Min(100.0, (math.Abs(distance-optimalDistance)/optimalDistance)*100.0)
+		// Calculate how close this connection is to the optimal distance (as a percentage)
+		// 100% means exactly at optimal distance, 0% means very far from optimal
+		connectionScore := 100.0 - math.Min(100.0, (math.Abs(distance-optimalDistance)/optimalDistance)*100.0)
 
-			// Check if this connection is within the optimal range
-			if math.Abs(distance-optimalDistance) <= 0.2*optimalDistance {
-				connections = append(connections, Connection{
-					ICAO:         fbo.ICAO,
-					Distance:     distance,
-					Contribution: contribution,
-				})
-			}
+		// If the connection is within 20% of the optimal distance, count it as an optimal connection
+		if math.Abs(distance-optimalDistance) <= 0.2*optimalDistance {
+			connections = append(connections, CandidateConnection{
+				ICAO:         fbo.ICAO,
+				Distance:     distance,
+				Contribution: connectionScore,
+			})
 		}
 
-		// Sort connections by contribution (higher is better)
-		for i := 0; i < len(connections); i++ {
-			for j := i + 1; j < len(connections); j++ {
-				if connections[i].Contribution < connections[j].Contribution {
-					connections[i], connections[j] = connections[j], connections[i]
-				}
-			}
-		}
+		// Add this connection's score to the total
+		score += connectionScore
+	}
+	explain(fmt.Sprintf("Sum of %d connection scores", totalConnections), score)
 
-		// Limit to top 5 connections
-		eligibleConnections := len(connections)
-		displayLimit := 5
-		if len(connections) > displayLimit {
-			connections = connections[:displayLimit]
-		}
+	// Skip if no distances were calculated
+	if totalConnections == 0 {
+		return CandidateScore{}, false
+	}
 
-		// Color code the score based on its value
-		var coloredScore string
-		intScore := int(score)
-		if intScore >= 80 {
-			coloredScore = green(fmt.Sprintf("%d", intScore))
-		} else if intScore >= 50 {
-			coloredScore = yellow(fmt.Sprintf("%d", intScore))
-		} else {
-			coloredScore = red(fmt.Sprintf("%d", intScore))
-		}
+	// Count how many connections are within the optimal range
+	optimalConnections := len(connections)
 
-		// Format with consistent column alignment for easier scanning like a table
-		scoreSection := fmt.Sprintf("Score: %s", coloredScore)
-		connectionsSection := fmt.Sprintf("Connections: %d/%d", eligibleConnections, totalConnections)
+	// If there are no eligible connections, set the score to zero
+	if optimalConnections == 0 {
+		before := score
+		score = 0.0
+		explain("No connections within 20% of the optimal distance, so the score is zero", -before)
+	} else {
+		// Average the scores across all connections
+		before := score
+		score = score / float64(totalConnections)
+		explain(fmt.Sprintf("Average over %d connections", totalConnections), score-before)
+
+		// Bonus for having many connections within optimal range
+		optimalRatio := float64(optimalConnections) / float64(totalConnections)
+		score += optimalRatio * s.OptimalRatioBonus // Up to 20 bonus points (by default) for having all connections optimal
+		explain(fmt.Sprintf("Optimal ratio bonus (%d/%d connections x %.1f)", optimalConnections, totalConnections, s.OptimalRatioBonus),
+			optimalRatio*s.OptimalRatioBonus)
+	}
 
-		result += fmt.Sprintf("%-3d %-40s  %-15s  %-20s\n",
-			i+1,
-			bold(airport.Name)+" "+cyan("("+airport.ICAO+")"),
-			bold(scoreSection),
-			bold(connectionsSection))
+	// Cap at 100
+	if score > 100.0 {
+		before := score
+		score = 100.0
+		explain("Cap at 100", score-before)
+	}
 
-		// Add details about eligible connections (limited to top 5)
-		if len(connections) > 0 {
-			var connectionDetails []string
-			for _, conn := range connections {
-				connectionDetails = append(connectionDetails, fmt.Sprintf("%s (%d nm)", conn.ICAO, int(math.Round(conn.Distance))))
-			}
+	// Apply size preference if specified, and negative weight for airports without lights if not required
+	bonus := sizeBonus(ctx, candidate, s.ExactSizeBonus, s.NearSizeBonus)
+	penalty := lightsPenalty(ctx, candidate, s.NoLightsPenalty)
+	score += bonus - penalty
+	terms = append(terms, preferenceTerms(ctx, candidate, score, bonus, penalty)...)
+
+	// Ensure score is not negative
+	if score < 0 {
+		before := score
+		score = 0
+		explain("Floor at 0", score-before)
+	}
 
-			result += fmt.Sprintf("   %s\n",
-				strings.Join(connectionDetails, ", "))
+	// Cap at 100
+	if score > 100.0 {
+		before := score
+		score = 100.0
+		explain("Cap at 100", score-before)
+	}
+
+	// Round down to nearest whole number
+	if rounded := math.Floor(score); rounded != score {
+		before := score
+		score = rounded
+		explain("Round down to a whole number", score-before)
+	}
+
+	// Sort connections by contribution (higher is better)
+	for i := 0; i < len(connections); i++ {
+		for j := i + 1; j < len(connections); j++ {
+			if connections[i].Contribution < connections[j].Contribution {
+				connections[i], connections[j] = connections[j], connections[i]
+			}
 		}
 	}
 
-	return result, nil
+	candidateScore := CandidateScore{
+		Airport:             candidate,
+		Score:               score,
+		EligibleConnections: optimalConnections,
+		TotalConnections:    totalConnections,
+		Connections:         connections,
+		Terms:               terms,
+	}
+	if ctx.Explain {
+		candidateScore.AllConnections = allCandidateConnections(candidate, existingFBOs, optimalDistance)
+	}
+	return candidateScore, true
 }
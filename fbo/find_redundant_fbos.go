@@ -1,6 +1,7 @@
 package fbo
 
 import (
+	"errors"
 	"fmt"
 	"github.com/fatih/color"
 	"github.com/jmoiron/sqlx"
@@ -9,12 +10,36 @@ import (
 	"sort"
 )
 
+// NearbyFBO is an FBO and its distance from another airport
+type NearbyFBO struct {
+	ICAO     string  `json:"icao"`
+	Distance float64 `json:"distance"`
+}
+
+// RedundantFBO is an FBO recommended for removal
+type RedundantFBO struct {
+	FBO                 models.Airport `json:"fbo"`
+	Score               float64        `json:"score"`
+	NearestAlternatives []NearbyFBO    `json:"nearest_alternatives"`
+}
+
+// RedundancyAnalysis is the result of searching the FBO network for redundant FBOs
+type RedundancyAnalysis struct {
+	FBOCount          int            `json:"fbo_count"`
+	OptimizedFBOCount int            `json:"optimized_fbo_count"`
+	InitialMetrics    NetworkMetrics `json:"initial_metrics"`
+	OptimizedMetrics  NetworkMetrics `json:"optimized_metrics"`
+	Redundant         []RedundantFBO `json:"redundant"`
+	// PinOverrides are the pinned FBOs that scored above the redundancy threshold but were kept
+	PinOverrides []PinOverride `json:"pin_overrides"`
+}
+
 // FindRedundantFBOs identifies FBOs that don't contribute significantly to the overall network
 // Uses a redundancy threshold (default 100.0) and a small co-location distance (10nm)
 // to identify redundant FBOs. The algorithm uses a stable scoring system that produces
 // consistent results across different threshold values, making it more predictable and
 // less sensitive to small changes in the threshold.
-func FindRedundantFBOs(db *sqlx.DB, optimalDistance, maxDistance float64, requireLights bool, preferredSize *int, redundancyThreshold float64) (string, error) {
+func FindRedundantFBOs(db *sqlx.DB, optimalDistance, maxDistance float64, requireLights bool, preferredSize *int, redundancyThreshold float64, filter CandidateFilter, scorer Scorer) (string, error) {
 	// Define color functions
 	bold := color.New(color.Bold).SprintFunc()
 	cyan := color.New(color.FgCyan).SprintFunc()
@@ -22,37 +47,222 @@ func FindRedundantFBOs(db *sqlx.DB, optimalDistance, maxDistance float64, requir
 	yellow := color.New(color.FgYellow).SprintFunc()
 	red := color.New(color.FgRed).SprintFunc()
 
+	analysis, err := AnalyseRedundantFBOs(db, optimalDistance, requireLights, preferredSize, redundancyThreshold, filter, scorer)
+	var insufficient *InsufficientFBOsError
+	if errors.As(err, &insufficient) {
+		if insufficient.Total < 2 {
+			return bold(yellow("There are fewer than 2 FBOs in the network. No redundancy analysis possible.")), nil
+		}
+		return bold(yellow(fmt.Sprintf(
+			"Found %d FBOs in total, but only %d have valid latitude/longitude information. "+
+				"At least 2 FBOs with coordinates are needed for redundancy analysis.",
+			insufficient.Total, insufficient.WithCoords))), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Build result string in scenario format
+	result := fmt.Sprintf("%s\n\n", bold(cyan("FBO Redundancy Analysis:")))
+
+	// Add configuration information
+	result += fmt.Sprintf("%s %s %.2f nm, %s %.2f nm\n",
+		bold("Using:"),
+		bold("optimal distance:"), optimalDistance,
+		bold("maximum distance:"), maxDistance)
+
+	// Add information about lights requirement
+	if requireLights {
+		result += fmt.Sprintf("%s %s\n",
+			bold("Requiring airports with lights:"),
+			green("Yes"))
+	} else {
+		result += fmt.Sprintf("%s %s\n",
+			bold("Requiring airports with lights:"),
+			yellow("No"))
+	}
+
+	// Add information about preferred size if specified
+	if preferredSize != nil {
+		result += fmt.Sprintf("%s %d\n",
+			bold("Preferred airport size:"),
+			*preferredSize)
+	}
+
+	// Add information about redundancy threshold
+	result += fmt.Sprintf("%s %.1f %s\n",
+		bold("Redundancy threshold:"),
+		redundancyThreshold,
+		yellow("(scores range from 0-100, higher threshold = less aggressive)"))
+
+	if scorer != nil && scorer.Name() != DefaultScorerName {
+		result += fmt.Sprintf("%s %s\n", bold("Scoring:"), scorer.Name())
+	}
+
+	if !filter.IsEmpty() {
+		result += fmt.Sprintf("%s %s %s\n",
+			bold("Candidate filters:"),
+			filter,
+			yellow("(only matching FBOs are considered for removal)"))
+	}
+
+	result += fmt.Sprintf("%s %d existing FBOs in the network.\n\n",
+		bold("Found:"), analysis.FBOCount)
+
+	// If no redundant FBOs were found
+	if len(analysis.Redundant) == 0 {
+		result += bold(green("Scenario Assessment: ")) + fmt.Sprintf(
+			"Based on the analysis with a redundancy threshold of %.1f, no FBOs are considered redundant in the current network. "+
+				"The existing FBO distribution provides optimal coverage given the specified criteria.\n\n"+
+				"No changes are recommended at this time. If you wish to identify more FBOs for potential removal, "+
+				"you can lower the redundancy threshold by setting the FBO_REDUNDANCY_THRESHOLD environment variable.\n\n"+
+				"The redundancy score ranges from 0 to 100, with higher scores indicating FBOs that contribute less to the network. "+
+				"A threshold of 100 is very strict (no FBOs will be considered redundant), while a threshold of 50 is moderate, "+
+				"and a threshold of 0 would consider all FBOs for potential removal (not recommended).",
+			redundancyThreshold)
+		if len(analysis.PinOverrides) > 0 {
+			result += "\n" + formatPinOverrides(analysis.PinOverrides, bold, cyan, yellow)
+		}
+		return result, nil
+	}
+
+	initialMetrics := analysis.InitialMetrics
+	optimizedMetrics := analysis.OptimizedMetrics
+
+	// Add scenario description
+	result += bold(green("Scenario Assessment: ")) + fmt.Sprintf(
+		"The analysis identified %d FBOs that could be considered redundant without significantly impacting network coverage. "+
+			"With the current redundancy threshold of %.1f, only FBOs with scores above this value are considered for removal, "+
+			"ensuring that only the most redundant FBOs are identified while maintaining adequate network coverage.\n\n"+
+			"The redundancy score ranges from 0 to 100, with higher scores indicating FBOs that contribute less to the network. "+
+			"The scores are calculated using a stable algorithm that considers how each FBO affects the overall network metrics "+
+			"when removed. This approach ensures consistent results across different threshold values.\n\n",
+		len(analysis.Redundant), redundancyThreshold)
+
+	// Add before/after metrics comparison
+	result += bold("Network Metrics Comparison:\n")
+
+	// Calculate FBO count change
+	fboChangeSymbol := "-"
+	if analysis.OptimizedFBOCount >= analysis.FBOCount {
+		fboChangeSymbol = "+"
+	}
+	fboChangePercent := math.Abs(float64(analysis.OptimizedFBOCount-analysis.FBOCount) / float64(analysis.FBOCount) * 100)
+
+	result += fmt.Sprintf("  • %s: %d → %d (%s%.0f%%)\n",
+		bold("Total FBOs"),
+		analysis.FBOCount,
+		analysis.OptimizedFBOCount,
+		fboChangeSymbol,
+		fboChangePercent)
+
+	// Calculate average distance change
+	distChangeSymbol := "-"
+	if optimizedMetrics.AverageDistance > initialMetrics.AverageDistance {
+		distChangeSymbol = "+"
+	}
+	distChangePercent := math.Abs((optimizedMetrics.AverageDistance - initialMetrics.AverageDistance) / initialMetrics.AverageDistance * 100)
+
+	result += fmt.Sprintf("  • %s: %.2f nm → %.2f nm (%s%.2f%%)\n",
+		bold("Average distance between FBOs"),
+		initialMetrics.AverageDistance,
+		optimizedMetrics.AverageDistance,
+		distChangeSymbol,
+		distChangePercent)
+
+	// Calculate efficiency score change
+	effChangeSymbol := "-"
+	if optimizedMetrics.EfficiencyScore > initialMetrics.EfficiencyScore {
+		effChangeSymbol = "+"
+	}
+	effChangePercent := math.Abs((optimizedMetrics.EfficiencyScore - initialMetrics.EfficiencyScore) / initialMetrics.EfficiencyScore * 100)
+
+	result += fmt.Sprintf("  • %s: %.2f → %.2f (%s%.2f%%)\n\n",
+		bold("Network efficiency score"),
+		initialMetrics.EfficiencyScore,
+		optimizedMetrics.EfficiencyScore,
+		effChangeSymbol,
+		effChangePercent)
+
+	// List redundant FBOs
+	result += bold(yellow("Recommended FBOs for removal:")) + "\n"
+	for i, fboScore := range analysis.Redundant {
+		fbo := fboScore.FBO
+
+		// Color code the score based on its value
+		var coloredScore string
+		if fboScore.Score >= 20 {
+			coloredScore = red(fmt.Sprintf("%.1f", fboScore.Score))
+		} else if fboScore.Score >= 10 {
+			coloredScore = yellow(fmt.Sprintf("%.1f", fboScore.Score))
+		} else {
+			coloredScore = green(fmt.Sprintf("%.1f", fboScore.Score))
+		}
+
+		result += fmt.Sprintf("%d. %s %s - Redundancy Score: %s\n",
+			i+1,
+			bold(fbo.Name),
+			cyan("("+fbo.ICAO+")"),
+			coloredScore)
+
+		// Show up to 3 nearest FBOs
+		if len(fboScore.NearestAlternatives) > 0 {
+			result += "   Nearest alternative FBOs: "
+			for j, nearest := range fboScore.NearestAlternatives {
+				if j > 0 {
+					result += ", "
+				}
+				result += fmt.Sprintf("%s (%.0f nm)", nearest.ICAO, nearest.Distance)
+			}
+			result += "\n"
+		}
+	}
+
+	result += formatPinOverrides(analysis.PinOverrides, bold, cyan, yellow)
+
+	return result, nil
+}
+
+// AnalyseRedundantFBOs repeatedly removes the most redundant FBO from the network until no FBO
+// scores above the redundancy threshold, returning the FBOs removed and the network before and after.
+// Only FBOs passing the filter are considered for removal, though all FBOs count towards the network metrics.
+// FBOs are scored by scorer, or the default scorer if nil.
+func AnalyseRedundantFBOs(db *sqlx.DB, optimalDistance float64, requireLights bool, preferredSize *int, redundancyThreshold float64, filter CandidateFilter, scorer Scorer) (RedundancyAnalysis, error) {
+	if scorer == nil {
+		scorer = NewDefaultScorer()
+	}
+
 	// First check total number of FBOs without filtering for lat/long
 	var totalFBOs []models.Airport
 	err := db.Select(&totalFBOs, "SELECT * FROM airports WHERE has_fbo = TRUE")
 	if err != nil {
-		return "", fmt.Errorf("error fetching existing FBOs: %w", err)
-	}
-
-	if len(totalFBOs) < 2 {
-		return bold(yellow("There are fewer than 2 FBOs in the network. No redundancy analysis possible.")), nil
+		return RedundancyAnalysis{}, fmt.Errorf("error fetching existing FBOs: %w", err)
 	}
 
 	// Get existing FBOs with valid coordinates
 	var existingFBOs []models.Airport
 	err = db.Select(&existingFBOs, "SELECT * FROM airports WHERE has_fbo = TRUE AND latitude IS NOT NULL AND longitude IS NOT NULL")
 	if err != nil {
-		return "", fmt.Errorf("error fetching existing FBOs: %w", err)
+		return RedundancyAnalysis{}, fmt.Errorf("error fetching existing FBOs: %w", err)
 	}
 
 	if len(existingFBOs) < 2 {
-		return bold(yellow(fmt.Sprintf(
-			"Found %d FBOs in total, but only %d have valid latitude/longitude information. "+
-				"At least 2 FBOs with coordinates are needed for redundancy analysis.",
-			len(totalFBOs), len(existingFBOs)))), nil
+		return RedundancyAnalysis{}, &InsufficientFBOsError{Total: len(totalFBOs), WithCoords: len(existingFBOs)}
 	}
 
 	// Calculate initial network metrics
 	initialMetrics, err := calculateNetworkMetrics(existingFBOs, optimalDistance)
 	if err != nil {
-		return bold(yellow(fmt.Sprintf(
-			"Error calculating network metrics: %v", err))), nil
+		return RedundancyAnalysis{}, fmt.Errorf("error calculating network metrics: %w", err)
+	}
+
+	// Get all airports for scorers that look beyond the FBOs
+	var airports []models.Airport
+	err = db.Select(&airports, "SELECT * FROM airports WHERE latitude IS NOT NULL AND longitude IS NOT NULL")
+	if err != nil {
+		return RedundancyAnalysis{}, fmt.Errorf("error fetching airports: %w", err)
 	}
+	ctx := newScoringContext(airports, optimalDistance, requireLights, preferredSize)
 
 	// Structure to hold FBO scores
 	type FBOScore struct {
@@ -60,6 +270,9 @@ func FindRedundantFBOs(db *sqlx.DB, optimalDistance, maxDistance float64, requir
 		Score float64
 	}
 
+	// Pinned FBOs that would have been removed, with their highest redundancy score
+	pinnedScores := make(map[string]FBOScore)
+
 	// Recursive function to find redundant FBOs
 	var findRedundantFBOsRecursive func(fboList []models.Airport) ([]FBOScore, error)
 	findRedundantFBOsRecursive = func(fboList []models.Airport) ([]FBOScore, error) {
@@ -71,6 +284,11 @@ func FindRedundantFBOs(db *sqlx.DB, optimalDistance, maxDistance float64, requir
 
 		// For each FBO, calculate the impact of removing it
 		for i, fbo := range fboList {
+			// Leave FBOs ruled out by the candidate filters in the network
+			if !filter.Matches(fbo) {
+				continue
+			}
+
 			// Create a new list without this FBO
 			remainingFBOs := make([]models.Airport, 0, len(fboList)-1)
 			remainingFBOs = append(remainingFBOs, fboList[:i]...)
@@ -84,31 +302,14 @@ func FindRedundantFBOs(db *sqlx.DB, optimalDistance, maxDistance float64, requir
 			}
 
 			// Calculate redundancy score (higher means more redundant)
-			// A positive score means the network improves when this FBO is removed
-			// A negative score means the network gets worse when this FBO is removed
-			score := calculateRedundancyScore(initialMetrics, metrics)
-
-			// Apply size preference if specified
-			if preferredSize != nil && fbo.Size != nil {
-				size := *fbo.Size
-				preferredSizeVal := *preferredSize
-
-				if size != preferredSizeVal {
-					// Increase redundancy score for FBOs that don't match preferred size
-					if size == preferredSizeVal+1 || size == preferredSizeVal-1 {
-						// Smaller penalty for sizes close to preferred
-						score += 5.0
-					} else {
-						// Larger penalty for sizes far from preferred
-						score += 10.0
-					}
-				}
-			}
+			score := scorer.ScoreRedundancy(ctx, fbo, remainingFBOs, initialMetrics, metrics)
 
-			// Apply negative weight for airports with lights if required
-			if requireLights && fbo.HasLights {
-				// Decrease redundancy score for FBOs with lights when lights are required
-				score -= 10.0
+			// Keep pinned FBOs, noting the ones that would have been removed
+			if fbo.IsPinned {
+				if score > redundancyThreshold && score > pinnedScores[fbo.ICAO].Score {
+					pinnedScores[fbo.ICAO] = FBOScore{FBO: fbo, Score: score}
+				}
+				continue
 			}
 
 			fboScores = append(fboScores, FBOScore{
@@ -135,9 +336,10 @@ func FindRedundantFBOs(db *sqlx.DB, optimalDistance, maxDistance float64, requir
 
 	// Recursive removal until no more beneficial removals are found
 	for {
+		removedCount := len(allRedundantFBOs)
 		redundantFBOs, err := findRedundantFBOsRecursive(currentFBOs)
 		if err != nil {
-			return "", err
+			return RedundancyAnalysis{}, err
 		}
 
 		// If no redundant FBOs or no scores above threshold, we're done
@@ -219,59 +421,31 @@ func FindRedundantFBOs(db *sqlx.DB, optimalDistance, maxDistance float64, requir
 		}
 
 		// If we couldn't find any non-colocated FBO to remove or if we're down to minimum FBOs, stop
-		if len(currentFBOs) < 2 || len(allRedundantFBOs) == 0 || len(allRedundantFBOs) == len(existingFBOs)-1 {
+		if len(allRedundantFBOs) == removedCount || len(currentFBOs) < 2 || len(allRedundantFBOs) == len(existingFBOs)-1 {
 			break
 		}
 	}
 
-	// Build result string in scenario format
-	result := fmt.Sprintf("%s\n\n", bold(cyan("FBO Redundancy Analysis:")))
-
-	// Add configuration information
-	result += fmt.Sprintf("%s %s %.2f nm, %s %.2f nm\n",
-		bold("Using:"),
-		bold("optimal distance:"), optimalDistance,
-		bold("maximum distance:"), maxDistance)
-
-	// Add information about lights requirement
-	if requireLights {
-		result += fmt.Sprintf("%s %s\n",
-			bold("Requiring airports with lights:"),
-			green("Yes"))
-	} else {
-		result += fmt.Sprintf("%s %s\n",
-			bold("Requiring airports with lights:"),
-			yellow("No"))
+	analysis := RedundancyAnalysis{
+		FBOCount:          len(existingFBOs),
+		OptimizedFBOCount: len(existingFBOs),
+		InitialMetrics:    initialMetrics,
+		OptimizedMetrics:  initialMetrics,
+		PinOverrides:      []PinOverride{},
 	}
-
-	// Add information about preferred size if specified
-	if preferredSize != nil {
-		result += fmt.Sprintf("%s %d\n",
-			bold("Preferred airport size:"),
-			*preferredSize)
+	for _, pinned := range pinnedScores {
+		analysis.PinOverrides = append(analysis.PinOverrides, PinOverride{
+			Airport: pinned.FBO,
+			Action:  ScenarioRemove,
+			Score:   pinned.Score,
+		})
 	}
+	sort.Slice(analysis.PinOverrides, func(i, j int) bool {
+		return analysis.PinOverrides[i].Score > analysis.PinOverrides[j].Score
+	})
 
-	// Add information about redundancy threshold
-	result += fmt.Sprintf("%s %.1f %s\n",
-		bold("Redundancy threshold:"),
-		redundancyThreshold,
-		yellow("(scores range from 0-100, higher threshold = less aggressive)"))
-
-	result += fmt.Sprintf("%s %d existing FBOs in the network.\n\n",
-		bold("Found:"), len(existingFBOs))
-
-	// If no redundant FBOs were found
 	if len(allRedundantFBOs) == 0 {
-		result += bold(green("Scenario Assessment: ")) + fmt.Sprintf(
-			"Based on the analysis with a redundancy threshold of %.1f, no FBOs are considered redundant in the current network. "+
-				"The existing FBO distribution provides optimal coverage given the specified criteria.\n\n"+
-				"No changes are recommended at this time. If you wish to identify more FBOs for potential removal, "+
-				"you can lower the redundancy threshold by setting the FBO_REDUNDANCY_THRESHOLD environment variable.\n\n"+
-				"The redundancy score ranges from 0 to 100, with higher scores indicating FBOs that contribute less to the network. "+
-				"A threshold of 100 is very strict (no FBOs will be considered redundant), while a threshold of 50 is moderate, "+
-				"and a threshold of 0 would consider all FBOs for potential removal (not recommended).",
-			redundancyThreshold)
-		return result, nil
+		return analysis, nil
 	}
 
 	// Calculate metrics for the optimized network
@@ -284,131 +458,48 @@ func FindRedundantFBOs(db *sqlx.DB, optimalDistance, maxDistance float64, requir
 
 	optimizedMetrics, err := calculateNetworkMetrics(optimizedFBOs, optimalDistance)
 	if err != nil {
-		return "", err
+		return RedundancyAnalysis{}, err
 	}
 
-	// Add scenario description
-	result += bold(green("Scenario Assessment: ")) + fmt.Sprintf(
-		"The analysis identified %d FBOs that could be considered redundant without significantly impacting network coverage. "+
-			"With the current redundancy threshold of %.1f, only FBOs with scores above this value are considered for removal, "+
-			"ensuring that only the most redundant FBOs are identified while maintaining adequate network coverage.\n\n"+
-			"The redundancy score ranges from 0 to 100, with higher scores indicating FBOs that contribute less to the network. "+
-			"The scores are calculated using a stable algorithm that considers how each FBO affects the overall network metrics "+
-			"when removed. This approach ensures consistent results across different threshold values.\n\n",
-		len(allRedundantFBOs), redundancyThreshold)
-
-	// Add before/after metrics comparison
-	result += bold("Network Metrics Comparison:\n")
-
-	// Calculate FBO count change
-	fboChangeSymbol := "-"
-	if len(optimizedFBOs) >= len(existingFBOs) {
-		fboChangeSymbol = "+"
-	}
-	fboChangePercent := math.Abs(float64(len(optimizedFBOs)-len(existingFBOs)) / float64(len(existingFBOs)) * 100)
-
-	result += fmt.Sprintf("  • %s: %d → %d (%s%.0f%%)\n",
-		bold("Total FBOs"),
-		len(existingFBOs),
-		len(optimizedFBOs),
-		fboChangeSymbol,
-		fboChangePercent)
+	analysis.OptimizedFBOCount = len(optimizedFBOs)
+	analysis.OptimizedMetrics = optimizedMetrics
 
-	// Calculate average distance change
-	distChangeSymbol := "-"
-	if optimizedMetrics.averageDistance > initialMetrics.averageDistance {
-		distChangeSymbol = "+"
-	}
-	distChangePercent := math.Abs((optimizedMetrics.averageDistance - initialMetrics.averageDistance) / initialMetrics.averageDistance * 100)
-
-	result += fmt.Sprintf("  • %s: %.2f nm → %.2f nm (%s%.2f%%)\n",
-		bold("Average distance between FBOs"),
-		initialMetrics.averageDistance,
-		optimizedMetrics.averageDistance,
-		distChangeSymbol,
-		distChangePercent)
-
-	// Calculate efficiency score change
-	effChangeSymbol := "-"
-	if optimizedMetrics.efficiencyScore > initialMetrics.efficiencyScore {
-		effChangeSymbol = "+"
+	for _, fboScore := range allRedundantFBOs {
+		analysis.Redundant = append(analysis.Redundant, RedundantFBO{
+			FBO:                 fboScore.FBO,
+			Score:               fboScore.Score,
+			NearestAlternatives: NearestFBOs(fboScore.FBO, optimizedFBOs, 3),
+		})
 	}
-	effChangePercent := math.Abs((optimizedMetrics.efficiencyScore - initialMetrics.efficiencyScore) / initialMetrics.efficiencyScore * 100)
-
-	result += fmt.Sprintf("  • %s: %.2f → %.2f (%s%.2f%%)\n\n",
-		bold("Network efficiency score"),
-		initialMetrics.efficiencyScore,
-		optimizedMetrics.efficiencyScore,
-		effChangeSymbol,
-		effChangePercent)
-
-	// List redundant FBOs
-	result += bold(yellow("Recommended FBOs for removal:")) + "\n"
-	for i, fboScore := range allRedundantFBOs {
-		fbo := fboScore.FBO
-
-		// Color code the score based on its value
-		var coloredScore string
-		if fboScore.Score >= 20 {
-			coloredScore = red(fmt.Sprintf("%.1f", fboScore.Score))
-		} else if fboScore.Score >= 10 {
-			coloredScore = yellow(fmt.Sprintf("%.1f", fboScore.Score))
-		} else {
-			coloredScore = green(fmt.Sprintf("%.1f", fboScore.Score))
-		}
 
-		result += fmt.Sprintf("%d. %s %s - Redundancy Score: %s\n",
-			i+1,
-			bold(fbo.Name),
-			cyan("("+fbo.ICAO+")"),
-			coloredScore)
-
-		// Find nearest remaining FBOs
-		var nearestFBOs []struct {
-			ICAO     string
-			Distance float64
-		}
+	return analysis, nil
+}
 
-		for _, remainingFBO := range optimizedFBOs {
-			if fbo.Latitude != nil && fbo.Longitude != nil &&
-				remainingFBO.Latitude != nil && remainingFBO.Longitude != nil {
-
-				distance := CalculateDistance(
-					*fbo.Latitude, *fbo.Longitude,
-					*remainingFBO.Latitude, *remainingFBO.Longitude)
-
-				nearestFBOs = append(nearestFBOs, struct {
-					ICAO     string
-					Distance float64
-				}{
-					ICAO:     remainingFBO.ICAO,
-					Distance: distance,
-				})
-			}
+// NearestFBOs returns up to limit FBOs closest to an airport, nearest first
+func NearestFBOs(airport models.Airport, fbos []models.Airport, limit int) []NearbyFBO {
+	var nearest []NearbyFBO
+	for _, fbo := range fbos {
+		if airport.Latitude == nil || airport.Longitude == nil ||
+			fbo.Latitude == nil || fbo.Longitude == nil || fbo.ID == airport.ID {
+			continue
 		}
 
-		// Sort by distance
-		sort.Slice(nearestFBOs, func(i, j int) bool {
-			return nearestFBOs[i].Distance < nearestFBOs[j].Distance
+		nearest = append(nearest, NearbyFBO{
+			ICAO: fbo.ICAO,
+			Distance: CalculateDistance(
+				*airport.Latitude, *airport.Longitude,
+				*fbo.Latitude, *fbo.Longitude),
 		})
+	}
 
-		// Show up to 3 nearest FBOs
-		if len(nearestFBOs) > 0 {
-			limit := 3
-			if len(nearestFBOs) < limit {
-				limit = len(nearestFBOs)
-			}
+	// Sort by distance
+	sort.Slice(nearest, func(i, j int) bool {
+		return nearest[i].Distance < nearest[j].Distance
+	})
 
-			result += fmt.Sprintf("   Nearest alternative FBOs: ")
-			for j := 0; j < limit; j++ {
-				if j > 0 {
-					result += ", "
-				}
-				result += fmt.Sprintf("%s (%.0f nm)", nearestFBOs[j].ICAO, nearestFBOs[j].Distance)
-			}
-			result += "\n"
-		}
+	if len(nearest) > limit {
+		nearest = nearest[:limit]
 	}
 
-	return result, nil
+	return nearest
 }
@@ -0,0 +1,127 @@
+package fbo
+
+import (
+	"github.com/julietrb1/offair-cli/models"
+	"sort"
+)
+
+// Leg is a direct connection between two airports in the FBO network
+type Leg struct {
+	From     models.Airport
+	To       models.Airport
+	Distance float64
+}
+
+// BuildLegs returns every leg of maxDistance or less between airports with valid coordinates,
+// sorted from shortest to longest
+func BuildLegs(airports []models.Airport, maxDistance float64) []Leg {
+	var legs []Leg
+	for i := 0; i < len(airports); i++ {
+		for j := i + 1; j < len(airports); j++ {
+			if airports[i].Latitude == nil || airports[i].Longitude == nil ||
+				airports[j].Latitude == nil || airports[j].Longitude == nil {
+				continue
+			}
+
+			distance := CalculateDistance(
+				*airports[i].Latitude, *airports[i].Longitude,
+				*airports[j].Latitude, *airports[j].Longitude)
+			if distance > maxDistance {
+				continue
+			}
+
+			legs = append(legs, Leg{
+				From:     airports[i],
+				To:       airports[j],
+				Distance: distance,
+			})
+		}
+	}
+
+	sort.SliceStable(legs, func(i, j int) bool {
+		return legs[i].Distance < legs[j].Distance
+	})
+
+	return legs
+}
+
+// MinimumSpanningTree returns the legs of the minimum spanning tree (or forest, if the legs
+// don't connect every airport) using Kruskal's algorithm
+func MinimumSpanningTree(airports []models.Airport, legs []Leg) []Leg {
+	sorted := make([]Leg, len(legs))
+	copy(sorted, legs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Distance < sorted[j].Distance
+	})
+
+	sets := newDisjointSet(airports)
+	var tree []Leg
+	for _, leg := range sorted {
+		if sets.union(leg.From.ICAO, leg.To.ICAO) {
+			tree = append(tree, leg)
+		}
+	}
+
+	return tree
+}
+
+// ConnectedComponents groups airports that can reach one another via legs, largest component first
+func ConnectedComponents(airports []models.Airport, legs []Leg) [][]models.Airport {
+	sets := newDisjointSet(airports)
+	for _, leg := range legs {
+		sets.union(leg.From.ICAO, leg.To.ICAO)
+	}
+
+	componentIndex := make(map[string]int)
+	var components [][]models.Airport
+	for _, airport := range airports {
+		root := sets.find(airport.ICAO)
+		index, exists := componentIndex[root]
+		if !exists {
+			index = len(components)
+			componentIndex[root] = index
+			components = append(components, nil)
+		}
+		components[index] = append(components[index], airport)
+	}
+
+	sort.SliceStable(components, func(i, j int) bool {
+		return len(components[i]) > len(components[j])
+	})
+
+	return components
+}
+
+// disjointSet is a union-find structure keyed by ICAO
+type disjointSet struct {
+	parent map[string]string
+}
+
+// newDisjointSet creates a disjoint set with each airport in its own set
+func newDisjointSet(airports []models.Airport) *disjointSet {
+	sets := &disjointSet{parent: make(map[string]string, len(airports))}
+	for _, airport := range airports {
+		sets.parent[airport.ICAO] = airport.ICAO
+	}
+	return sets
+}
+
+// find returns the representative of the set containing icao
+func (s *disjointSet) find(icao string) string {
+	for s.parent[icao] != icao {
+		s.parent[icao] = s.parent[s.parent[icao]]
+		icao = s.parent[icao]
+	}
+	return icao
+}
+
+// union merges the sets containing a and b, returning false if they were already in the same set
+func (s *disjointSet) union(a, b string) bool {
+	rootA := s.find(a)
+	rootB := s.find(b)
+	if rootA == rootB {
+		return false
+	}
+	s.parent[rootB] = rootA
+	return true
+}
@@ -6,11 +6,10 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/julietrb1/offair-cli/models"
 	"math"
-	"strings"
 )
 
 // ListDistancesBetweenFBOs lists the distances between all FBOs in a more organized and insightful way
-func ListDistancesBetweenFBOs(db *sqlx.DB) (string, error) {
+func ListDistancesBetweenFBOs(db *sqlx.DB, clustering ClusterSettings) (string, error) {
 	// Define color functions
 	bold := color.New(color.Bold).SprintFunc()
 	cyan := color.New(color.FgCyan).SprintFunc()
@@ -148,54 +147,12 @@ func ListDistancesBetweenFBOs(db *sqlx.DB) (string, error) {
 	result += "\n"
 
 	// Group FBOs by proximity
-	// We'll create clusters based on distance thresholds
-	result += fmt.Sprintf("%s\n", bold(yellow("FBO Clusters:")))
-
-	// Define distance threshold for clustering
-	shortDistance := 300.0 // nm
-
-	// Find clusters of closely located FBOs
-	visited := make(map[string]bool)
-	clusterCount := 0
-
-	for i := 0; i < len(fbos); i++ {
-		if visited[fbos[i].ICAO] {
-			continue
-		}
-
-		// Start a new cluster
-		var cluster []string
-		cluster = append(cluster, fbos[i].ICAO)
-		visited[fbos[i].ICAO] = true
-
-		// Find all FBOs close to this one
-		for j := 0; j < len(fbos); j++ {
-			if i == j || visited[fbos[j].ICAO] {
-				continue
-			}
-
-			distance := CalculateDistance(fbos[i].Latitude, fbos[i].Longitude, fbos[j].Latitude, fbos[j].Longitude)
-			if distance <= shortDistance {
-				cluster = append(cluster, fbos[j].ICAO)
-				visited[fbos[j].ICAO] = true
-			}
-		}
-
-		// Only show clusters with at least 2 FBOs
-		if len(cluster) >= 2 {
-			clusterCount++
-			result += fmt.Sprintf("  %s %d: %s (within %.0f nm)\n",
-				bold("Cluster"),
-				clusterCount,
-				strings.Join(cluster, ", "),
-				shortDistance)
-		}
-	}
-
-	// If no clusters were found
-	if clusterCount == 0 {
-		result += fmt.Sprintf("  %s\n", yellow("No clusters found within "+fmt.Sprintf("%.0f", shortDistance)+" nm"))
+	clusters, err := ClusterFBOs(fbos, clustering)
+	if err != nil {
+		return "", err
 	}
+	result += fmt.Sprintf("%s\n", bold(yellow("FBO Clusters:")))
+	result += formatFBOClusters(clusters, bold, yellow)
 
 	// Add a note about viewing all distances
 	if len(distances) > 10 {
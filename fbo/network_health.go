@@ -0,0 +1,237 @@
+package fbo
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/models"
+	"time"
+)
+
+// NetworkSnapshot is a network summary recorded at a point in time, with the settings it was calculated with
+type NetworkSnapshot struct {
+	ID              int64     `json:"id" db:"id"`
+	TakenAt         time.Time `json:"taken_at" db:"taken_at"`
+	Reason          string    `json:"reason" db:"reason"`
+	OptimalDistance float64   `json:"optimal_distance" db:"optimal_distance"`
+	MaxDistance     float64   `json:"max_distance" db:"max_distance"`
+	CoverageRadius  float64   `json:"coverage_radius" db:"coverage_radius"`
+	FBOCount        int       `json:"fbo_count" db:"fbo_count"`
+	// The network metrics are nil if the network had fewer than 2 FBOs with coordinates
+	AverageDistance        *float64 `json:"average_distance" db:"average_distance"`
+	EfficiencyScore        *float64 `json:"efficiency_score" db:"efficiency_score"`
+	OptimalConnections     *int     `json:"optimal_connections" db:"optimal_connections"`
+	TotalConnections       *int     `json:"total_connections" db:"total_connections"`
+	LegsWithinMax          int      `json:"legs_within_max" db:"legs_within_max"`
+	Components             int      `json:"components" db:"components"`
+	LargestComponent       int      `json:"largest_component" db:"largest_component"`
+	IsolatedFBOs           int      `json:"isolated_fbos" db:"isolated_fbos"`
+	LongestGap             float64  `json:"longest_gap" db:"longest_gap"`
+	CoveredAirports        int      `json:"covered_airports" db:"covered_airports"`
+	TotalAirports          int      `json:"total_airports" db:"total_airports"`
+	CoveragePercent        float64  `json:"coverage_percent" db:"coverage_percent"`
+	AverageNearestDistance float64  `json:"average_nearest_distance" db:"average_nearest_distance"`
+}
+
+// Summary returns the network summary the snapshot recorded
+func (s NetworkSnapshot) Summary() NetworkSummary {
+	summary := NetworkSummary{
+		FBOCount:         s.FBOCount,
+		LegsWithinMax:    s.LegsWithinMax,
+		Components:       s.Components,
+		LargestComponent: s.LargestComponent,
+		IsolatedFBOs:     s.IsolatedFBOs,
+		LongestGap:       s.LongestGap,
+		Coverage: Coverage{
+			CoveredAirports:        s.CoveredAirports,
+			TotalAirports:          s.TotalAirports,
+			CoveragePercent:        s.CoveragePercent,
+			AverageNearestDistance: s.AverageNearestDistance,
+		},
+	}
+
+	if s.AverageDistance != nil && s.EfficiencyScore != nil && s.OptimalConnections != nil && s.TotalConnections != nil {
+		summary.Metrics = &NetworkMetrics{
+			AverageDistance:    *s.AverageDistance,
+			EfficiencyScore:    *s.EfficiencyScore,
+			OptimalConnections: *s.OptimalConnections,
+			TotalConnections:   *s.TotalConnections,
+		}
+	}
+
+	return summary
+}
+
+// NetworkHealth reports the FBO network's metrics, connectivity and coverage, the change since the last snapshot,
+// and the most recent snapshots
+func NetworkHealth(db *sqlx.DB, optimalDistance, maxDistance, coverageRadius float64) (string, error) {
+	// Define color functions
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	summary, err := AnalyseNetworkHealth(db, optimalDistance, maxDistance, coverageRadius)
+	if err != nil {
+		return "", err
+	}
+
+	snapshots, err := ListNetworkSnapshots(db, 10)
+	if err != nil {
+		return "", err
+	}
+
+	result := fmt.Sprintf("%s\n", bold(cyan("Network health")))
+	result += fmt.Sprintf("%s %s %.2f nm, %s %.2f nm, %s %.2f nm\n",
+		bold("Using:"),
+		bold("optimal distance:"), optimalDistance,
+		bold("maximum distance:"), maxDistance,
+		bold("coverage radius:"), coverageRadius)
+	result += fmt.Sprintf("%s %d of %d airports within %.0f nm of an FBO, %d of %d FBO pairs within 20%% of the optimal distance\n\n",
+		bold("Coverage:"),
+		summary.Coverage.CoveredAirports, summary.Coverage.TotalAirports, coverageRadius,
+		optimalConnections(summary), totalConnections(summary))
+
+	// Compare with the last snapshot, if it was taken with the same settings
+	if len(snapshots) > 0 {
+		last := snapshots[0]
+		if last.OptimalDistance == optimalDistance && last.MaxDistance == maxDistance && last.CoverageRadius == coverageRadius {
+			result += fmt.Sprintf("%s %s (%s)\n", bold("Last snapshot:"),
+				last.TakenAt.Local().Format("2006-01-02 15:04"), last.Reason)
+			result += formatSummaryComparison([]string{"Last snapshot", "Now"}, []NetworkSummary{last.Summary(), summary})
+		} else {
+			result += formatSummaryComparison([]string{"Now"}, []NetworkSummary{summary})
+			result += fmt.Sprintf("%s\n", yellow("The last snapshot used different settings, so it isn't compared."))
+		}
+	} else {
+		result += formatSummaryComparison([]string{"Now"}, []NetworkSummary{summary})
+	}
+
+	// Recent history, newest first
+	result += fmt.Sprintf("\n%s\n", bold(cyan("Recent snapshots:")))
+	if len(snapshots) == 0 {
+		result += "None yet. Snapshots are taken after every FBO change and sync.\n"
+		return result, nil
+	}
+
+	result += bold(fmt.Sprintf("%-16s  %4s  %9s  %10s  %9s  %10s  %s", "Taken", "FBOs", "Avg leg", "Efficiency", "Coverage", "Components", "Reason")) + "\n"
+	for _, snapshot := range snapshots {
+		averageLeg, efficiency := "n/a", "n/a"
+		if snapshot.AverageDistance != nil {
+			averageLeg = fmt.Sprintf("%.1f", *snapshot.AverageDistance)
+		}
+		if snapshot.EfficiencyScore != nil {
+			efficiency = fmt.Sprintf("%.1f", *snapshot.EfficiencyScore)
+		}
+
+		result += fmt.Sprintf("%-16s  %4d  %9s  %10s  %8.1f%%  %10d  %s\n",
+			snapshot.TakenAt.Local().Format("2006-01-02 15:04"),
+			snapshot.FBOCount, averageLeg, efficiency, snapshot.CoveragePercent, snapshot.Components,
+			snapshot.Reason)
+	}
+
+	return result, nil
+}
+
+// optimalConnections returns the summary's optimal connections, or zero without metrics
+func optimalConnections(summary NetworkSummary) int {
+	if summary.Metrics == nil {
+		return 0
+	}
+	return summary.Metrics.OptimalConnections
+}
+
+// totalConnections returns the summary's FBO pairs, or zero without metrics
+func totalConnections(summary NetworkSummary) int {
+	if summary.Metrics == nil {
+		return 0
+	}
+	return summary.Metrics.TotalConnections
+}
+
+// AnalyseNetworkHealth summarises the FBO network as it is in the database
+func AnalyseNetworkHealth(db *sqlx.DB, optimalDistance, maxDistance, coverageRadius float64) (NetworkSummary, error) {
+	var airports []models.Airport
+	err := db.Select(&airports, "SELECT * FROM airports WHERE latitude IS NOT NULL AND longitude IS NOT NULL")
+	if err != nil {
+		return NetworkSummary{}, fmt.Errorf("error fetching airports: %w", err)
+	}
+
+	var fbos []models.Airport
+	err = db.Select(&fbos, "SELECT * FROM airports WHERE has_fbo = TRUE")
+	if err != nil {
+		return NetworkSummary{}, fmt.Errorf("error fetching FBOs: %w", err)
+	}
+
+	return SummariseNetwork(airports, fbos, optimalDistance, maxDistance, coverageRadius), nil
+}
+
+// RecordNetworkSnapshot summarises the FBO network as it is now and saves the summary as a snapshot
+func RecordNetworkSnapshot(db *sqlx.DB, reason string, optimalDistance, maxDistance, coverageRadius float64) (NetworkSnapshot, error) {
+	summary, err := AnalyseNetworkHealth(db, optimalDistance, maxDistance, coverageRadius)
+	if err != nil {
+		return NetworkSnapshot{}, err
+	}
+
+	snapshot := NetworkSnapshot{
+		TakenAt:                time.Now().UTC(),
+		Reason:                 reason,
+		OptimalDistance:        optimalDistance,
+		MaxDistance:            maxDistance,
+		CoverageRadius:         coverageRadius,
+		FBOCount:               summary.FBOCount,
+		LegsWithinMax:          summary.LegsWithinMax,
+		Components:             summary.Components,
+		LargestComponent:       summary.LargestComponent,
+		IsolatedFBOs:           summary.IsolatedFBOs,
+		LongestGap:             summary.LongestGap,
+		CoveredAirports:        summary.Coverage.CoveredAirports,
+		TotalAirports:          summary.Coverage.TotalAirports,
+		CoveragePercent:        summary.Coverage.CoveragePercent,
+		AverageNearestDistance: summary.Coverage.AverageNearestDistance,
+	}
+	if summary.Metrics != nil {
+		snapshot.AverageDistance = &summary.Metrics.AverageDistance
+		snapshot.EfficiencyScore = &summary.Metrics.EfficiencyScore
+		snapshot.OptimalConnections = &summary.Metrics.OptimalConnections
+		snapshot.TotalConnections = &summary.Metrics.TotalConnections
+	}
+
+	res, err := db.NamedExec(`
+		INSERT INTO network_snapshots (
+			taken_at, reason, optimal_distance, max_distance, coverage_radius, fbo_count,
+			average_distance, efficiency_score, optimal_connections, total_connections,
+			legs_within_max, components, largest_component, isolated_fbos, longest_gap,
+			covered_airports, total_airports, coverage_percent, average_nearest_distance
+		) VALUES (
+			:taken_at, :reason, :optimal_distance, :max_distance, :coverage_radius, :fbo_count,
+			:average_distance, :efficiency_score, :optimal_connections, :total_connections,
+			:legs_within_max, :components, :largest_component, :isolated_fbos, :longest_gap,
+			:covered_airports, :total_airports, :coverage_percent, :average_nearest_distance
+		)
+	`, snapshot)
+	if err != nil {
+		return NetworkSnapshot{}, fmt.Errorf("error saving network snapshot: %w", err)
+	}
+
+	snapshot.ID, err = res.LastInsertId()
+	if err != nil {
+		return NetworkSnapshot{}, fmt.Errorf("error saving network snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// ListNetworkSnapshots returns the most recent network snapshots, newest first, or all of them if limit is 0
+func ListNetworkSnapshots(db *sqlx.DB, limit int) ([]NetworkSnapshot, error) {
+	query := "SELECT * FROM network_snapshots ORDER BY taken_at DESC, id DESC"
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	var snapshots []NetworkSnapshot
+	err := db.Select(&snapshots, query)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching network snapshots: %w", err)
+	}
+	return snapshots, nil
+}
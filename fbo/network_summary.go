@@ -0,0 +1,192 @@
+package fbo
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/julietrb1/offair-cli/models"
+	"math"
+)
+
+// NetworkSummary describes an FBO network's legs, connectivity and coverage
+type NetworkSummary struct {
+	FBOCount int `json:"fbo_count"`
+	// Metrics is nil if the network has fewer than 2 FBOs with coordinates
+	Metrics          *NetworkMetrics `json:"metrics"`
+	LegsWithinMax    int             `json:"legs_within_max"`
+	Components       int             `json:"components"`
+	LargestComponent int             `json:"largest_component"`
+	IsolatedFBOs     int             `json:"isolated_fbos"`
+	// LongestGap is the longest leg needed to connect every FBO, the longest minimum spanning tree leg
+	LongestGap float64  `json:"longest_gap"`
+	Coverage   Coverage `json:"coverage"`
+}
+
+// SummariseNetwork calculates the network metrics, connectivity via legs of maxDistance or less,
+// and coverage of airports within coverageRadius of an FBO
+func SummariseNetwork(airports, fbos []models.Airport, optimalDistance, maxDistance, coverageRadius float64) NetworkSummary {
+	var located []models.Airport
+	for _, fbo := range fbos {
+		if fbo.Latitude != nil && fbo.Longitude != nil {
+			located = append(located, fbo)
+		}
+	}
+
+	summary := NetworkSummary{
+		FBOCount: len(fbos),
+		Coverage: CalculateCoverage(airports, located, coverageRadius),
+	}
+
+	if metrics, err := calculateNetworkMetrics(located, optimalDistance); err == nil {
+		summary.Metrics = &metrics
+	}
+
+	legs := BuildLegs(located, maxDistance)
+	summary.LegsWithinMax = len(legs)
+
+	components := ConnectedComponents(located, legs)
+	summary.Components = len(components)
+	for _, component := range components {
+		if len(component) > summary.LargestComponent {
+			summary.LargestComponent = len(component)
+		}
+		if len(component) == 1 {
+			summary.IsolatedFBOs++
+		}
+	}
+
+	for _, leg := range MinimumSpanningTree(located, BuildLegs(located, math.Inf(1))) {
+		if leg.Distance > summary.LongestGap {
+			summary.LongestGap = leg.Distance
+		}
+	}
+
+	return summary
+}
+
+// networkImprovement measures how much better the after network is than the before network: the change in
+// efficiency score and coverage percentage, plus the reduction in longest gap as a percentage of the gap before
+func networkImprovement(before, after NetworkSummary) float64 {
+	improvement := efficiencyScore(after) - efficiencyScore(before) +
+		after.Coverage.CoveragePercent - before.Coverage.CoveragePercent
+	if before.LongestGap > 0 {
+		improvement -= (after.LongestGap - before.LongestGap) / before.LongestGap * 100.0
+	}
+	return improvement
+}
+
+// efficiencyScore returns the summary's efficiency score, or zero without metrics
+func efficiencyScore(summary NetworkSummary) float64 {
+	if summary.Metrics == nil {
+		return 0
+	}
+	return summary.Metrics.EfficiencyScore
+}
+
+// summaryRow is one measure of a network, for showing networks side by side
+type summaryRow struct {
+	label string
+	// values holds the measure for each network, or nil where it isn't available
+	values []*float64
+	format string
+	// higherIsBetter is nil when neither direction is better
+	higherIsBetter *bool
+}
+
+// summaryRows lists the measures of each network summary, in display order
+func summaryRows(summaries []NetworkSummary) []summaryRow {
+	higher, lower := true, false
+	value := func(v float64) *float64 { return &v }
+
+	rows := []summaryRow{
+		{label: "FBOs", format: "%.0f"},
+		{label: "Average leg (nm)", format: "%.1f"},
+		{label: "Efficiency score", format: "%.1f", higherIsBetter: &higher},
+		{label: "Optimal connections", format: "%.0f", higherIsBetter: &higher},
+		{label: "Legs within max", format: "%.0f", higherIsBetter: &higher},
+		{label: "Connected components", format: "%.0f", higherIsBetter: &lower},
+		{label: "Isolated FBOs", format: "%.0f", higherIsBetter: &lower},
+		{label: "Longest gap (nm)", format: "%.1f", higherIsBetter: &lower},
+		{label: "Coverage (%)", format: "%.1f", higherIsBetter: &higher},
+		{label: "Average nearest FBO (nm)", format: "%.1f", higherIsBetter: &lower},
+	}
+
+	for _, summary := range summaries {
+		var averageLeg, efficiency, optimalConnections *float64
+		if summary.Metrics != nil {
+			averageLeg = value(summary.Metrics.AverageDistance)
+			efficiency = value(summary.Metrics.EfficiencyScore)
+			optimalConnections = value(float64(summary.Metrics.OptimalConnections))
+		}
+
+		values := []*float64{
+			value(float64(summary.FBOCount)),
+			averageLeg,
+			efficiency,
+			optimalConnections,
+			value(float64(summary.LegsWithinMax)),
+			value(float64(summary.Components)),
+			value(float64(summary.IsolatedFBOs)),
+			value(summary.LongestGap),
+			value(summary.Coverage.CoveragePercent),
+			value(summary.Coverage.AverageNearestDistance),
+		}
+		for i := range rows {
+			rows[i].values = append(rows[i].values, values[i])
+		}
+	}
+
+	return rows
+}
+
+// formatSummaryComparison formats network summaries as a table, one column per network. With two networks,
+// a change column shows the difference, green where the second network is better and red where it's worse.
+func formatSummaryComparison(headings []string, summaries []NetworkSummary) string {
+	bold := color.New(color.Bold).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	// Columns are wide enough for the longest heading
+	width := 12
+	for _, heading := range headings {
+		if len(heading) > width {
+			width = len(heading)
+		}
+	}
+	column := func(text string) string { return fmt.Sprintf("  %*s", width, text) }
+
+	result := fmt.Sprintf("%-26s", "")
+	for _, heading := range headings {
+		result += bold(column(heading))
+	}
+	if len(summaries) == 2 {
+		result += bold(column("Change"))
+	}
+	result += "\n"
+
+	for _, row := range summaryRows(summaries) {
+		result += fmt.Sprintf("%-26s", row.label)
+		for _, value := range row.values {
+			if value == nil {
+				result += column("n/a")
+			} else {
+				result += column(fmt.Sprintf(row.format, *value))
+			}
+		}
+
+		if len(summaries) == 2 && row.values[0] != nil && row.values[1] != nil {
+			change := *row.values[1] - *row.values[0]
+			text := column(fmt.Sprintf("%+"+row.format[1:], change))
+			if fmt.Sprintf(row.format, math.Abs(change)) != fmt.Sprintf(row.format, 0.0) && row.higherIsBetter != nil {
+				if (change > 0) == *row.higherIsBetter {
+					text = green(text)
+				} else {
+					text = red(text)
+				}
+			}
+			result += text
+		}
+		result += "\n"
+	}
+
+	return result
+}
@@ -0,0 +1,175 @@
+package fbo
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/models"
+	"sort"
+	"strings"
+)
+
+// CurrentNetworkName is the name given to the FBO network as it is in the database
+const CurrentNetworkName = "Current network"
+
+// NetworkVariant is a named set of FBOs, either saved or the current network
+type NetworkVariant struct {
+	Name string           `json:"name"`
+	FBOs []models.Airport `json:"fbos"`
+}
+
+// VariantComparison compares two FBO network variants
+type VariantComparison struct {
+	A        string         `json:"a"`
+	B        string         `json:"b"`
+	OnlyInA  []string       `json:"only_in_a"`
+	OnlyInB  []string       `json:"only_in_b"`
+	Shared   []string       `json:"shared"`
+	SummaryA NetworkSummary `json:"summary_a"`
+	SummaryB NetworkSummary `json:"summary_b"`
+}
+
+// CompareNetworkVariants compares two FBO network variants side by side
+func CompareNetworkVariants(db *sqlx.DB, a, b NetworkVariant, optimalDistance, maxDistance, coverageRadius float64) (string, error) {
+	// Define color functions
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+
+	comparison, err := AnalyseNetworkVariants(db, a, b, optimalDistance, maxDistance, coverageRadius)
+	if err != nil {
+		return "", err
+	}
+
+	result := fmt.Sprintf("%s %s %s %s\n", bold(cyan("Comparing")), bold(a.Name), bold(cyan("with")), bold(b.Name))
+	result += fmt.Sprintf("%s %s %.2f nm, %s %.2f nm, %s %.2f nm\n",
+		bold("Using:"),
+		bold("optimal distance:"), optimalDistance,
+		bold("maximum distance:"), maxDistance,
+		bold("coverage radius:"), coverageRadius)
+
+	// FBO set differences
+	result += "\n"
+	for _, set := range []struct {
+		label string
+		icaos []string
+	}{
+		{fmt.Sprintf("Only in %s", a.Name), comparison.OnlyInA},
+		{fmt.Sprintf("Only in %s", b.Name), comparison.OnlyInB},
+		{"In both", comparison.Shared},
+	} {
+		icaos := "none"
+		if len(set.icaos) > 0 {
+			icaos = cyan(strings.Join(set.icaos, ", "))
+		}
+		result += fmt.Sprintf("%s (%d): %s\n", bold(set.label), len(set.icaos), icaos)
+	}
+
+	result += "\n" + formatSummaryComparison([]string{a.Name, b.Name}, []NetworkSummary{comparison.SummaryA, comparison.SummaryB})
+
+	return result, nil
+}
+
+// AnalyseNetworkVariants works out which FBOs differ between two network variants, and summarises each
+func AnalyseNetworkVariants(db *sqlx.DB, a, b NetworkVariant, optimalDistance, maxDistance, coverageRadius float64) (VariantComparison, error) {
+	var airports []models.Airport
+	err := db.Select(&airports, "SELECT * FROM airports WHERE latitude IS NOT NULL AND longitude IS NOT NULL")
+	if err != nil {
+		return VariantComparison{}, fmt.Errorf("error fetching airports: %w", err)
+	}
+
+	inA := make(map[string]bool)
+	for _, fbo := range a.FBOs {
+		inA[fbo.ICAO] = true
+	}
+	inB := make(map[string]bool)
+	for _, fbo := range b.FBOs {
+		inB[fbo.ICAO] = true
+	}
+
+	comparison := VariantComparison{
+		A:        a.Name,
+		B:        b.Name,
+		OnlyInA:  []string{},
+		OnlyInB:  []string{},
+		Shared:   []string{},
+		SummaryA: SummariseNetwork(airports, a.FBOs, optimalDistance, maxDistance, coverageRadius),
+		SummaryB: SummariseNetwork(airports, b.FBOs, optimalDistance, maxDistance, coverageRadius),
+	}
+	for icao := range inA {
+		if inB[icao] {
+			comparison.Shared = append(comparison.Shared, icao)
+		} else {
+			comparison.OnlyInA = append(comparison.OnlyInA, icao)
+		}
+	}
+	for icao := range inB {
+		if !inA[icao] {
+			comparison.OnlyInB = append(comparison.OnlyInB, icao)
+		}
+	}
+	sort.Strings(comparison.OnlyInA)
+	sort.Strings(comparison.OnlyInB)
+	sort.Strings(comparison.Shared)
+
+	return comparison, nil
+}
+
+// CurrentNetwork returns the FBO network as it is in the database
+func CurrentNetwork(db *sqlx.DB) (NetworkVariant, error) {
+	var fbos []models.Airport
+	err := db.Select(&fbos, "SELECT * FROM airports WHERE has_fbo = TRUE ORDER BY icao")
+	if err != nil {
+		return NetworkVariant{}, fmt.Errorf("error fetching FBOs: %w", err)
+	}
+	return NetworkVariant{Name: CurrentNetworkName, FBOs: fbos}, nil
+}
+
+// NetworkVariantFromScenario returns the current network with a scenario's changes applied, named after the scenario
+func NetworkVariantFromScenario(db *sqlx.DB, scenario Scenario) (NetworkVariant, error) {
+	current, err := CurrentNetwork(db)
+	if err != nil {
+		return NetworkVariant{}, err
+	}
+	current.Name = scenario.Name
+
+	if len(scenario.Add) == 0 && len(scenario.Remove) == 0 {
+		return current, nil
+	}
+
+	fbos, _, _, err := applyScenario(db, scenario, current.FBOs)
+	if err != nil {
+		return NetworkVariant{}, err
+	}
+	current.FBOs = fbos
+	return current, nil
+}
+
+// SaveNetworkVariant saves a named network variant, replacing any existing variant with the same name
+func SaveNetworkVariant(db *sqlx.DB, variant NetworkVariant) error {
+	if len(variant.FBOs) == 0 {
+		return fmt.Errorf("network variant %s has no FBOs", variant.Name)
+	}
+	return savePlannedFBOs(db, networkVariantKind, variant.Name, variant.FBOs)
+}
+
+// ListNetworkVariants lists the names of all saved network variants
+func ListNetworkVariants(db *sqlx.DB) ([]string, error) {
+	return listPlannedFBOs(db, networkVariantKind)
+}
+
+// GetNetworkVariant returns a saved network variant
+func GetNetworkVariant(db *sqlx.DB, name string) (NetworkVariant, error) {
+	fbos, err := getPlannedFBOs(db, networkVariantKind, name)
+	if err != nil {
+		return NetworkVariant{}, err
+	}
+	if len(fbos) == 0 {
+		return NetworkVariant{}, fmt.Errorf("network variant %s not found", name)
+	}
+	return NetworkVariant{Name: name, FBOs: fbos}, nil
+}
+
+// DeleteNetworkVariant deletes a saved network variant
+func DeleteNetworkVariant(db *sqlx.DB, name string) error {
+	return deletePlannedFBOs(db, networkVariantKind, name)
+}
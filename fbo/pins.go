@@ -0,0 +1,133 @@
+package fbo
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/models"
+	"strings"
+)
+
+// PinOverride is a recommendation an analysis held back because an FBO is pinned or an airport is excluded
+type PinOverride struct {
+	Airport models.Airport `json:"airport"`
+	// Action is what would have been recommended: ScenarioAdd at an excluded airport, or ScenarioRemove or
+	// "move" for a pinned FBO
+	Action string `json:"action"`
+	// Rank is where the recommendation would have ranked or the step it would have been taken at, or 0 if unranked
+	Rank  int     `json:"rank,omitempty"`
+	Score float64 `json:"score"`
+}
+
+// pinOverrideMove is the action for a pinned FBO that would have been moved to another airport
+const pinOverrideMove = "move"
+
+// Description describes the held back recommendation in a few words
+func (o PinOverride) Description() string {
+	var description string
+	switch o.Action {
+	case ScenarioAdd:
+		description = "would be recommended for a new FBO, but is excluded"
+	case pinOverrideMove:
+		description = "would be recommended to move, but is pinned"
+	default:
+		description = "would be recommended for removal, but is pinned"
+	}
+	if o.Rank > 0 {
+		description += fmt.Sprintf(" (#%d)", o.Rank)
+	}
+	return description
+}
+
+// PinnedAirports lists the pinned FBOs and the excluded airports
+type PinnedAirports struct {
+	Pinned   []models.Airport `json:"pinned"`
+	Excluded []models.Airport `json:"excluded"`
+}
+
+// ListPinnedAirports formats the pinned FBOs and excluded airports
+func ListPinnedAirports(db *sqlx.DB) (string, error) {
+	// Define color functions
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	pins, err := LoadPinnedAirports(db)
+	if err != nil {
+		return "", err
+	}
+
+	format := func(heading, empty string, airports []models.Airport) string {
+		section := fmt.Sprintf("%s\n", bold(cyan(heading)))
+		if len(airports) == 0 {
+			return section + fmt.Sprintf("  %s\n", yellow(empty))
+		}
+		for _, airport := range airports {
+			note := ""
+			if airport.IsPinned && !airport.HasFBO {
+				note = " " + yellow("(no FBO yet)")
+			}
+			section += fmt.Sprintf("  %s %s%s\n", bold(airport.Name), cyan("("+airport.ICAO+")"), note)
+		}
+		return section
+	}
+
+	result := format("Pinned FBOs (never suggested for removal or relocation):", "None pinned.", pins.Pinned)
+	result += "\n" + format("Excluded airports (never recommended for an FBO):", "None excluded.", pins.Excluded)
+	return result, nil
+}
+
+// LoadPinnedAirports reads the pinned and excluded airports, ordered by ICAO
+func LoadPinnedAirports(db *sqlx.DB) (PinnedAirports, error) {
+	pins := PinnedAirports{Pinned: []models.Airport{}, Excluded: []models.Airport{}}
+
+	err := db.Select(&pins.Pinned, "SELECT * FROM airports WHERE is_pinned = TRUE ORDER BY icao")
+	if err != nil {
+		return PinnedAirports{}, fmt.Errorf("error fetching pinned airports: %w", err)
+	}
+
+	err = db.Select(&pins.Excluded, "SELECT * FROM airports WHERE is_excluded = TRUE ORDER BY icao")
+	if err != nil {
+		return PinnedAirports{}, fmt.Errorf("error fetching excluded airports: %w", err)
+	}
+
+	return pins, nil
+}
+
+// SetAirportPinned pins or unpins an airport, so analyses never suggest removing or moving its FBO
+func SetAirportPinned(db *sqlx.DB, icao string, pinned bool) error {
+	return setAirportFlag(db, "is_pinned", icao, pinned)
+}
+
+// SetAirportExcluded excludes or includes an airport, so analyses never recommend it for an FBO
+func SetAirportExcluded(db *sqlx.DB, icao string, excluded bool) error {
+	return setAirportFlag(db, "is_excluded", icao, excluded)
+}
+
+// setAirportFlag sets one of the pin columns on an airport in the local database
+func setAirportFlag(db *sqlx.DB, column, icao string, value bool) error {
+	icao = strings.ToUpper(strings.TrimSpace(icao))
+
+	result, err := db.Exec("UPDATE airports SET "+column+" = ? WHERE icao = ?", value, icao)
+	if err != nil {
+		return fmt.Errorf("error updating airport %s: %w", icao, err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("airport %s not found in the local database", icao)
+	}
+	return nil
+}
+
+// formatPinOverrides lists the recommendations held back by pins and exclusions, or returns an empty string
+func formatPinOverrides(overrides []PinOverride, bold, cyan, yellow func(a ...interface{}) string) string {
+	if len(overrides) == 0 {
+		return ""
+	}
+
+	result := fmt.Sprintf("\n%s\n", bold(yellow("Held back by pins and exclusions:")))
+	for _, override := range overrides {
+		result += fmt.Sprintf("  %s %s %s\n",
+			bold(override.Airport.Name), cyan("("+override.Airport.ICAO+")"), override.Description())
+	}
+	return result
+}
@@ -0,0 +1,534 @@
+package fbo
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/models"
+	"math"
+	"sort"
+	"strings"
+)
+
+// maxLocalSearchPasses caps how many times local search revisits every pick
+const maxLocalSearchPasses = 10
+
+// PlannedFBO is one airport selected by the placement planner and what it adds to the network
+type PlannedFBO struct {
+	Airport                models.Airport `json:"airport"`
+	CoveredGain            int            `json:"covered_gain"`
+	CoveragePercent        float64        `json:"coverage_percent"`
+	AverageNearestDistance float64        `json:"average_nearest_distance"`
+	OptimalConnections     int            `json:"optimal_connections"`
+	ConnectionsWithinMax   int            `json:"connections_within_max"`
+	NearestFBO             *NearbyFBO     `json:"nearest_fbo"`
+}
+
+// PlacementPlan is a set of new FBOs chosen together to maximise coverage of the airport database
+type PlacementPlan struct {
+	AirportCount     int          `json:"airport_count"`
+	ExistingFBOCount int          `json:"existing_fbo_count"`
+	CandidateCount   int          `json:"candidate_count"`
+	CoverageRadius   float64      `json:"coverage_radius"`
+	LocalSearch      bool         `json:"local_search"`
+	Swaps            int          `json:"swaps"`
+	InitialCoverage  Coverage     `json:"initial_coverage"`
+	FinalCoverage    Coverage     `json:"final_coverage"`
+	Picks            []PlannedFBO `json:"picks"`
+	// PinOverrides are the excluded airports that would have covered more than a pick, at the first pick they'd beat
+	PinOverrides []PinOverride `json:"pin_overrides"`
+}
+
+// FindFBOPlacements plans count new FBOs and formats the plan
+func FindFBOPlacements(db *sqlx.DB, count int, optimalDistance, maxDistance, coverageRadius float64, requireLights, localSearch bool, filter CandidateFilter) (string, error) {
+	// Define color functions
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	plan, err := PlanFBOPlacements(db, count, optimalDistance, maxDistance, coverageRadius, requireLights, localSearch, filter)
+	if err != nil {
+		return "", err
+	}
+
+	method := "greedy"
+	if localSearch {
+		method = "greedy with local search"
+	}
+
+	result := fmt.Sprintf("%s %s %.2f nm, %s %.2f nm, %s %.2f nm, %s %s\n",
+		bold("Using:"),
+		bold("optimal distance:"), optimalDistance,
+		bold("maximum distance:"), maxDistance,
+		bold("coverage radius:"), coverageRadius,
+		bold("method:"), method)
+	if !filter.IsEmpty() {
+		result += fmt.Sprintf("%s %s\n", bold("Candidate filters:"), filter)
+	}
+	result += fmt.Sprintf("%s %d airports, %d existing FBOs, and %d candidate airports.\n",
+		bold("Found:"),
+		plan.AirportCount, plan.ExistingFBOCount, plan.CandidateCount)
+	result += fmt.Sprintf("%s %d/%d airports (%.1f%%) within %.0f nm of an FBO\n",
+		bold("Current coverage:"),
+		plan.InitialCoverage.CoveredAirports, plan.InitialCoverage.TotalAirports,
+		plan.InitialCoverage.CoveragePercent, coverageRadius)
+
+	if len(plan.Picks) == 0 {
+		result += bold(yellow("\nNo candidate airport adds coverage while staying within the maximum distance of the network.")) + "\n"
+		return result, nil
+	}
+
+	result += fmt.Sprintf("\n%s\n", bold(cyan(fmt.Sprintf("Planned %d new FBOs, in order of marginal gain:", len(plan.Picks)))))
+
+	previous := plan.InitialCoverage
+	for i, pick := range plan.Picks {
+		gainSection := fmt.Sprintf("+%d airports (+%.1f%%), -%.0f nm average",
+			pick.CoveredGain, pick.CoveragePercent-previous.CoveragePercent,
+			previous.AverageNearestDistance-pick.AverageNearestDistance)
+		previous.CoveragePercent = pick.CoveragePercent
+		previous.AverageNearestDistance = pick.AverageNearestDistance
+
+		result += fmt.Sprintf("%-3d %-40s  %-40s  %s\n",
+			i+1,
+			bold(pick.Airport.Name)+" "+cyan("("+pick.Airport.ICAO+")"),
+			green(gainSection),
+			bold(fmt.Sprintf("Coverage: %.1f%%", pick.CoveragePercent)))
+
+		var details []string
+		if pick.NearestFBO != nil {
+			details = append(details, fmt.Sprintf("nearest FBO %s (%d nm)", pick.NearestFBO.ICAO, int(math.Round(pick.NearestFBO.Distance))))
+		}
+		details = append(details, fmt.Sprintf("%d connections within max distance, %d near optimal",
+			pick.ConnectionsWithinMax, pick.OptimalConnections))
+		result += fmt.Sprintf("    %s\n", strings.Join(details, ", "))
+	}
+
+	if len(plan.Picks) < count {
+		result += fmt.Sprintf("\n%s\n", yellow(fmt.Sprintf(
+			"Only %d of %d FBOs planned: no further candidate adds coverage while staying within the maximum distance of the network.",
+			len(plan.Picks), count)))
+	}
+
+	if localSearch {
+		result += fmt.Sprintf("\n%s %d\n", bold("Local search swaps:"), plan.Swaps)
+	}
+
+	result += fmt.Sprintf("%s %d/%d airports (%.1f%%), average distance to nearest FBO %.0f nm (was %.0f nm)\n",
+		bold("Planned coverage:"),
+		plan.FinalCoverage.CoveredAirports, plan.FinalCoverage.TotalAirports, plan.FinalCoverage.CoveragePercent,
+		plan.FinalCoverage.AverageNearestDistance, plan.InitialCoverage.AverageNearestDistance)
+	result += formatPinOverrides(plan.PinOverrides, bold, cyan, yellow)
+
+	return result, nil
+}
+
+// PlanFBOPlacements selects up to count airports for new FBOs that together cover the most airports
+// within coverageRadius, re-scoring the remaining candidates after each pick. Every pick must be within
+// maxDistance of the network, so the plan stays connected. With localSearch, picks are then swapped
+// for other candidates while that improves the plan. Only airports passing the filter and not excluded are candidates.
+func PlanFBOPlacements(db *sqlx.DB, count int, optimalDistance, maxDistance, coverageRadius float64, requireLights, localSearch bool, filter CandidateFilter) (PlacementPlan, error) {
+	if count < 1 {
+		return PlacementPlan{}, fmt.Errorf("number of FBOs to plan must be at least 1")
+	}
+	if coverageRadius <= 0 {
+		return PlacementPlan{}, fmt.Errorf("coverage radius must be greater than zero")
+	}
+
+	var airports []models.Airport
+	if err := db.Select(&airports, "SELECT * FROM airports"); err != nil {
+		return PlacementPlan{}, fmt.Errorf("error fetching airports: %w", err)
+	}
+
+	var existing, candidates, excluded []models.Airport
+	for _, airport := range airports {
+		if airport.Latitude == nil || airport.Longitude == nil {
+			continue
+		}
+		if airport.HasFBO {
+			existing = append(existing, airport)
+		} else if (!requireLights || airport.HasLights) && filter.Matches(airport) {
+			if airport.IsExcluded {
+				excluded = append(excluded, airport)
+			} else {
+				candidates = append(candidates, airport)
+			}
+		}
+	}
+
+	planner := newPlacementPlanner(airports, existing, candidates, coverageRadius, maxDistance)
+
+	plan := PlacementPlan{
+		AirportCount:     len(airports),
+		ExistingFBOCount: len(existing),
+		CandidateCount:   len(candidates),
+		CoverageRadius:   coverageRadius,
+		LocalSearch:      localSearch,
+		InitialCoverage:  CalculateCoverage(airports, existing, coverageRadius),
+		PinOverrides:     []PinOverride{},
+	}
+
+	allCandidates := make([]int, len(candidates))
+	for i := range candidates {
+		allCandidates[i] = i
+	}
+	picks := planner.greedy(allCandidates, count, true)
+
+	if localSearch {
+		picks, plan.Swaps = planner.improve(picks)
+		// Re-order the improved set greedily so each pick's marginal gain is reported against the ones before it
+		picks = planner.greedy(picks, len(picks), false)
+	}
+
+	// Check each pick against the excluded airports that could have joined the network at that point
+	excludedCovers := make([][]siteCover, len(excluded))
+	for i, airport := range excluded {
+		excludedCovers[i] = planner.coversOf(airport)
+	}
+	overridden := make(map[int]bool)
+	nearest := planner.nearestFor(nil)
+
+	network := append([]models.Airport{}, existing...)
+	for step, pick := range picks {
+		airport := candidates[pick]
+
+		pickGain := planner.gain(nearest, pick)
+		for i, excludedAirport := range excluded {
+			if overridden[i] || !planner.connectable(excludedAirport, network) {
+				continue
+			}
+			if gain := planner.coverGain(nearest, excludedCovers[i]); gain.better(pickGain) {
+				overridden[i] = true
+				plan.PinOverrides = append(plan.PinOverrides, PinOverride{
+					Airport: excludedAirport,
+					Action:  ScenarioAdd,
+					Rank:    step + 1,
+					Score:   float64(gain.covered),
+				})
+			}
+		}
+		for _, cover := range planner.covers[pick] {
+			if cover.distance < nearest[cover.airport] {
+				nearest[cover.airport] = cover.distance
+			}
+		}
+
+		planned := PlannedFBO{Airport: airport}
+		for _, member := range network {
+			distance := CalculateDistance(*airport.Latitude, *airport.Longitude, *member.Latitude, *member.Longitude)
+			if distance <= maxDistance {
+				planned.ConnectionsWithinMax++
+			}
+			// Within 20% of the optimal distance, as for the network metrics
+			if math.Abs(distance-optimalDistance) <= 0.2*optimalDistance {
+				planned.OptimalConnections++
+			}
+		}
+		if nearest := NearestFBOs(airport, network, 1); len(nearest) > 0 {
+			planned.NearestFBO = &nearest[0]
+		}
+
+		before := CalculateCoverage(airports, network, coverageRadius)
+		network = append(network, airport)
+		after := CalculateCoverage(airports, network, coverageRadius)
+		planned.CoveredGain = after.CoveredAirports - before.CoveredAirports
+		planned.CoveragePercent = after.CoveragePercent
+		planned.AverageNearestDistance = after.AverageNearestDistance
+
+		plan.Picks = append(plan.Picks, planned)
+	}
+	plan.FinalCoverage = CalculateCoverage(airports, network, coverageRadius)
+
+	return plan, nil
+}
+
+// siteCover is an airport within the coverage radius of a candidate site
+type siteCover struct {
+	airport  int
+	distance float64
+}
+
+// placementGain is what adding a site adds to the plan: newly covered airports first, then how much
+// closer covered airports get to their nearest FBO
+type placementGain struct {
+	covered  int
+	distance float64
+}
+
+// better reports whether this gain beats another
+func (g placementGain) better(other placementGain) bool {
+	if g.covered != other.covered {
+		return g.covered > other.covered
+	}
+	return g.distance > other.distance+1e-9
+}
+
+// placementPlanner scores sets of candidate sites against the airports they cover
+type placementPlanner struct {
+	demand      airportIndex
+	existing    []models.Airport
+	candidates  []models.Airport
+	covers      [][]siteCover
+	radius      float64
+	maxDistance float64
+}
+
+// newPlacementPlanner precomputes which airports each candidate covers
+func newPlacementPlanner(airports, existing, candidates []models.Airport, radius, maxDistance float64) *placementPlanner {
+	p := &placementPlanner{
+		demand:      newAirportIndex(airports),
+		existing:    existing,
+		candidates:  candidates,
+		covers:      make([][]siteCover, len(candidates)),
+		radius:      radius,
+		maxDistance: maxDistance,
+	}
+
+	for i, candidate := range candidates {
+		p.covers[i] = p.coversOf(candidate)
+	}
+
+	return p
+}
+
+// coversOf lists the airports within the coverage radius of a site
+func (p *placementPlanner) coversOf(site models.Airport) []siteCover {
+	var covers []siteCover
+	p.demand.within(*site.Latitude, *site.Longitude, p.radius, func(i int, distance float64) {
+		covers = append(covers, siteCover{airport: i, distance: distance})
+	})
+	return covers
+}
+
+// nearestFor returns each airport's distance to the nearest FBO in the existing network plus picks,
+// or +Inf if none is within the coverage radius
+func (p *placementPlanner) nearestFor(picks []int) []float64 {
+	nearest := make([]float64, len(p.demand.airports))
+	for i := range nearest {
+		nearest[i] = math.Inf(1)
+	}
+
+	apply := func(covers []siteCover) {
+		for _, cover := range covers {
+			if cover.distance < nearest[cover.airport] {
+				nearest[cover.airport] = cover.distance
+			}
+		}
+	}
+	for _, fbo := range p.existing {
+		apply(p.coversOf(fbo))
+	}
+	for _, pick := range picks {
+		apply(p.covers[pick])
+	}
+
+	return nearest
+}
+
+// objective totals the covered airports and their distance to the nearest FBO, capped at the coverage radius
+func (p *placementPlanner) objective(nearest []float64) (int, float64) {
+	var covered int
+	var total float64
+	for _, distance := range nearest {
+		if !math.IsInf(distance, 1) {
+			covered++
+		}
+		total += math.Min(distance, p.radius)
+	}
+	return covered, total
+}
+
+// gain scores adding a candidate against the current nearest distances
+func (p *placementPlanner) gain(nearest []float64, candidate int) placementGain {
+	return p.coverGain(nearest, p.covers[candidate])
+}
+
+// coverGain scores adding a site covering covers against the current nearest distances
+func (p *placementPlanner) coverGain(nearest []float64, covers []siteCover) placementGain {
+	var gain placementGain
+	for _, cover := range covers {
+		current := math.Min(nearest[cover.airport], p.radius)
+		if math.IsInf(nearest[cover.airport], 1) {
+			gain.covered++
+		}
+		if cover.distance < current {
+			gain.distance += current - cover.distance
+		}
+	}
+	return gain
+}
+
+// greedy picks up to count candidates from pool, each time taking the one with the largest gain that is
+// within the maximum distance of the network so far. With stopWhenNoGain, it stops once nothing helps.
+func (p *placementPlanner) greedy(pool []int, count int, stopWhenNoGain bool) []int {
+	nearest := p.nearestFor(nil)
+	picked := make(map[int]bool)
+
+	// A candidate can join once it is within the maximum distance of a member of the network
+	connectable := make(map[int]bool)
+	markConnectable := func(member models.Airport) {
+		for _, candidate := range pool {
+			if p.withinMaxDistance(p.candidates[candidate], member) {
+				connectable[candidate] = true
+			}
+		}
+	}
+	for _, fbo := range p.existing {
+		markConnectable(fbo)
+	}
+
+	var picks []int
+	for len(picks) < count {
+		best := -1
+		var bestGain placementGain
+		for _, candidate := range pool {
+			if picked[candidate] {
+				continue
+			}
+			// With no network yet, the first pick can be anywhere
+			if (len(p.existing) > 0 || len(picks) > 0) && !connectable[candidate] {
+				continue
+			}
+
+			gain := p.gain(nearest, candidate)
+			if best == -1 || gain.better(bestGain) {
+				best = candidate
+				bestGain = gain
+			}
+		}
+
+		if best == -1 || (stopWhenNoGain && bestGain.covered == 0 && bestGain.distance <= 1e-9) {
+			break
+		}
+
+		picks = append(picks, best)
+		picked[best] = true
+		for _, cover := range p.covers[best] {
+			if cover.distance < nearest[cover.airport] {
+				nearest[cover.airport] = cover.distance
+			}
+		}
+		markConnectable(p.candidates[best])
+	}
+
+	return picks
+}
+
+// improve swaps picks for other candidates while a swap covers more airports, or the same airports
+// more closely, and keeps every pick connected to the network. It returns the improved picks and
+// the number of swaps made.
+func (p *placementPlanner) improve(picks []int) ([]int, int) {
+	picks = append([]int{}, picks...)
+	swaps := 0
+
+	currentCovered, currentTotal := p.objective(p.nearestFor(picks))
+	for pass := 0; pass < maxLocalSearchPasses; pass++ {
+		improved := false
+
+		for i := range picks {
+			others := make([]int, 0, len(picks)-1)
+			others = append(others, picks[:i]...)
+			others = append(others, picks[i+1:]...)
+			inSet := make(map[int]bool)
+			for _, pick := range picks {
+				inSet[pick] = true
+			}
+
+			nearest := p.nearestFor(others)
+			baseCovered, baseTotal := p.objective(nearest)
+
+			type swap struct {
+				candidate int
+				covered   int
+				total     float64
+			}
+			var swapsForPick []swap
+			for candidate := range p.candidates {
+				if inSet[candidate] {
+					continue
+				}
+				gain := p.gain(nearest, candidate)
+				covered, total := baseCovered+gain.covered, baseTotal-gain.distance
+				if covered > currentCovered || (covered == currentCovered && total < currentTotal-1e-9) {
+					swapsForPick = append(swapsForPick, swap{candidate, covered, total})
+				}
+			}
+
+			sort.Slice(swapsForPick, func(a, b int) bool {
+				if swapsForPick[a].covered != swapsForPick[b].covered {
+					return swapsForPick[a].covered > swapsForPick[b].covered
+				}
+				return swapsForPick[a].total < swapsForPick[b].total
+			})
+
+			for _, s := range swapsForPick {
+				trial := append(append([]int{}, others...), s.candidate)
+				if !p.connected(trial) {
+					continue
+				}
+				picks[i] = s.candidate
+				currentCovered, currentTotal = s.covered, s.total
+				swaps++
+				improved = true
+				break
+			}
+		}
+
+		if !improved {
+			break
+		}
+	}
+
+	return picks, swaps
+}
+
+// connected reports whether every pick can reach an existing FBO through legs within the maximum distance,
+// or, with no existing FBOs, whether the picks form a single network
+func (p *placementPlanner) connected(picks []int) bool {
+	members := append([]models.Airport{}, p.existing...)
+	for _, pick := range picks {
+		members = append(members, p.candidates[pick])
+	}
+
+	components := ConnectedComponents(members, BuildLegs(members, p.maxDistance))
+	if len(p.existing) == 0 {
+		return len(components) <= 1
+	}
+
+	existingICAOs := make(map[string]bool)
+	for _, fbo := range p.existing {
+		existingICAOs[fbo.ICAO] = true
+	}
+	for _, component := range components {
+		hasExisting := false
+		for _, airport := range component {
+			if existingICAOs[airport.ICAO] {
+				hasExisting = true
+				break
+			}
+		}
+		if !hasExisting {
+			return false
+		}
+	}
+	return true
+}
+
+// connectable reports whether a site could join the network, being within the maximum distance of a member, or
+// whether the network is still empty
+func (p *placementPlanner) connectable(site models.Airport, network []models.Airport) bool {
+	if len(network) == 0 {
+		return true
+	}
+	for _, member := range network {
+		if p.withinMaxDistance(site, member) {
+			return true
+		}
+	}
+	return false
+}
+
+// withinMaxDistance reports whether two airports are within the maximum leg distance
+func (p *placementPlanner) withinMaxDistance(a, b models.Airport) bool {
+	return CalculateDistance(*a.Latitude, *a.Longitude, *b.Latitude, *b.Longitude) <= p.maxDistance
+}
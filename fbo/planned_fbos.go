@@ -0,0 +1,127 @@
+package fbo
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/models"
+)
+
+const (
+	// plannedSetKind marks a planned FBO set: airports to add to the network
+	plannedSetKind = "plan"
+	// networkVariantKind marks a network variant: a whole network, stored with the planned sets but kept apart
+	networkVariantKind = "variant"
+)
+
+// plannedKindLabels names each kind in error messages
+var plannedKindLabels = map[string]string{
+	plannedSetKind:     "planned FBO set",
+	networkVariantKind: "network variant",
+}
+
+// SavePlannedFBOSet saves a named set of planned FBO airports, replacing any existing set with the same name
+func SavePlannedFBOSet(db *sqlx.DB, name string, airports []models.Airport) error {
+	return savePlannedFBOs(db, plannedSetKind, name, airports)
+}
+
+// ListPlannedFBOSets lists the names of all saved planned FBO sets
+func ListPlannedFBOSets(db *sqlx.DB) ([]string, error) {
+	return listPlannedFBOs(db, plannedSetKind)
+}
+
+// GetPlannedFBOSet returns the airports in a saved planned FBO set
+func GetPlannedFBOSet(db *sqlx.DB, name string) ([]models.Airport, error) {
+	return getPlannedFBOs(db, plannedSetKind, name)
+}
+
+// DeletePlannedFBOSet deletes a saved planned FBO set
+func DeletePlannedFBOSet(db *sqlx.DB, name string) error {
+	return deletePlannedFBOs(db, plannedSetKind, name)
+}
+
+// savePlannedFBOs saves a named set of airports of a kind, replacing any existing set of that kind with the same name
+func savePlannedFBOs(db *sqlx.DB, kind, name string, airports []models.Airport) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM planned_fbos WHERE kind = ? AND plan_name = ?", kind, name)
+	if err != nil {
+		return fmt.Errorf("error clearing %s %s: %w", plannedKindLabels[kind], name, err)
+	}
+
+	for _, airport := range airports {
+		_, err = tx.Exec(`
+			INSERT INTO planned_fbos (kind, plan_name, icao, airport_id)
+			VALUES (?, ?, ?, ?)
+		`, kind, name, airport.ICAO, airport.ID)
+		if err != nil {
+			return fmt.Errorf("error adding %s to %s %s: %w", airport.ICAO, plannedKindLabels[kind], name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error saving %s %s: %w", plannedKindLabels[kind], name, err)
+	}
+
+	return nil
+}
+
+// listPlannedFBOs lists the names of all saved sets of a kind
+func listPlannedFBOs(db *sqlx.DB, kind string) ([]string, error) {
+	var names []string
+	err := db.Select(&names, "SELECT DISTINCT plan_name FROM planned_fbos WHERE kind = ? ORDER BY plan_name", kind)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %ss: %w", plannedKindLabels[kind], err)
+	}
+	return names, nil
+}
+
+// getPlannedFBOs returns the airports in a saved set of a kind
+func getPlannedFBOs(db *sqlx.DB, kind, name string) ([]models.Airport, error) {
+	var airports []models.Airport
+	err := db.Select(&airports, `
+		SELECT a.* FROM airports a
+		JOIN planned_fbos p ON p.airport_id = a.id
+		WHERE p.kind = ? AND p.plan_name = ?
+		ORDER BY a.icao
+	`, kind, name)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s %s: %w", plannedKindLabels[kind], name, err)
+	}
+	return airports, nil
+}
+
+// deletePlannedFBOs deletes a saved set of a kind
+func deletePlannedFBOs(db *sqlx.DB, kind, name string) error {
+	_, err := db.Exec("DELETE FROM planned_fbos WHERE kind = ? AND plan_name = ?", kind, name)
+	if err != nil {
+		return fmt.Errorf("error deleting %s %s: %w", plannedKindLabels[kind], name, err)
+	}
+	return nil
+}
+
+// ScenarioFromPlannedFBOSet returns a what-if scenario that adds an FBO at each airport in a saved planned set,
+// along with the planned airports that already have FBOs
+func ScenarioFromPlannedFBOSet(db *sqlx.DB, name string) (Scenario, []models.Airport, error) {
+	airports, err := GetPlannedFBOSet(db, name)
+	if err != nil {
+		return Scenario{}, nil, err
+	}
+	if len(airports) == 0 {
+		return Scenario{}, nil, fmt.Errorf("planned FBO set %s not found", name)
+	}
+
+	var scenario Scenario
+	var existing []models.Airport
+	for _, airport := range airports {
+		if airport.HasFBO {
+			existing = append(existing, airport)
+			continue
+		}
+		scenario.Add = append(scenario.Add, airport.ICAO)
+	}
+	return scenario, existing, nil
+}
@@ -0,0 +1,402 @@
+package fbo
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/models"
+	"math"
+	"sort"
+)
+
+const (
+	// rebalanceOpenCandidates is how many of the best-scoring airports are tried as openings at each step
+	rebalanceOpenCandidates = 10
+	// maxRebalanceSteps caps the length of a rebalance plan
+	maxRebalanceSteps = 20
+	// minRebalanceBenefit is the smallest improvement worth a step, so rounding noise doesn't add steps
+	minRebalanceBenefit = 0.01
+)
+
+// RebalanceBudget limits the network a rebalance plan may build. A zero MaxFBOs or Budget means no limit.
+type RebalanceBudget struct {
+	MaxFBOs int `json:"max_fbos"`
+	// SizeCosts is the cost of an FBO at an airport of each size. Airports without a size, or with a size missing
+	// from the table, cost as much as the most expensive size.
+	SizeCosts map[int]float64 `json:"size_costs,omitempty"`
+	Budget    float64         `json:"budget"`
+}
+
+// Validate checks the budget limits something and has the costs it needs
+func (b RebalanceBudget) Validate() error {
+	if b.MaxFBOs < 0 || b.Budget < 0 {
+		return fmt.Errorf("the maximum FBO count and budget can't be negative")
+	}
+	if b.MaxFBOs == 0 && b.Budget == 0 {
+		return fmt.Errorf("set a maximum FBO count or a budget to plan within")
+	}
+	if b.Budget > 0 && len(b.SizeCosts) == 0 {
+		return fmt.Errorf("a budget needs a cost for each airport size")
+	}
+	return nil
+}
+
+// cost returns what an FBO at the airport costs under the size cost table
+func (b RebalanceBudget) cost(airport models.Airport) float64 {
+	if airport.Size != nil {
+		if cost, ok := b.SizeCosts[*airport.Size]; ok {
+			return cost
+		}
+	}
+
+	var highest float64
+	for _, cost := range b.SizeCosts {
+		highest = math.Max(highest, cost)
+	}
+	return highest
+}
+
+// RebalanceStep is one action of a rebalance plan and the network after it
+type RebalanceStep struct {
+	// Action is ScenarioAdd to open an FBO or ScenarioRemove to close one
+	Action  string         `json:"action"`
+	Airport models.Airport `json:"airport"`
+	// Benefit is the step's improvement to the network, as for relocations
+	Benefit float64 `json:"benefit"`
+	// Cost is the FBO's cost, spent when opening and saved when closing
+	Cost      float64 `json:"cost"`
+	FBOCount  int     `json:"fbo_count"`
+	TotalCost float64 `json:"total_cost"`
+	// Forced is true when the step was taken to get within the budget rather than for its benefit
+	Forced bool           `json:"forced"`
+	After  NetworkSummary `json:"after"`
+}
+
+// RebalancePlan is an ordered list of FBOs to open and close, within a budget
+type RebalancePlan struct {
+	Budget       RebalanceBudget `json:"budget"`
+	Before       NetworkSummary  `json:"before"`
+	StartingCost float64         `json:"starting_cost"`
+	Steps        []RebalanceStep `json:"steps"`
+	// WithinBudget is false when the plan ends with the network still over the budget
+	WithinBudget bool `json:"within_budget"`
+	// PinOverrides are the steps held back because an FBO is pinned or an airport excluded, at the step they'd
+	// have been taken
+	PinOverrides []PinOverride `json:"pin_overrides"`
+}
+
+// Scenario returns the plan's changes as a what-if scenario
+func (p RebalancePlan) Scenario() Scenario {
+	scenario := Scenario{Add: []string{}, Remove: []string{}}
+	for _, step := range p.Steps {
+		if step.Action == ScenarioAdd {
+			scenario.Add = append(scenario.Add, step.Airport.ICAO)
+		} else {
+			scenario.Remove = append(scenario.Remove, step.Airport.ICAO)
+		}
+	}
+	return scenario
+}
+
+// RebalanceFBONetwork plans FBOs to open and close, best first, within the budget, and formats the plan step by step
+func RebalanceFBONetwork(db *sqlx.DB, budget RebalanceBudget, optimalDistance, maxDistance, coverageRadius float64, requireLights bool, preferredSize *int, filter CandidateFilter, scorer Scorer) (string, error) {
+	plan, err := PlanFBORebalance(db, budget, optimalDistance, maxDistance, coverageRadius, requireLights, preferredSize, filter, scorer)
+	var insufficient *InsufficientFBOsError
+	if errors.As(err, &insufficient) {
+		bold := color.New(color.Bold).SprintFunc()
+		yellow := color.New(color.FgYellow).SprintFunc()
+		return bold(yellow("At least 2 FBOs with latitude/longitude information are needed to plan a rebalance.")), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return FormatRebalancePlan(plan, optimalDistance, maxDistance, coverageRadius, filter, scorer), nil
+}
+
+// FormatRebalancePlan formats a rebalance plan step by step, with the network before and after
+func FormatRebalancePlan(plan RebalancePlan, optimalDistance, maxDistance, coverageRadius float64, filter CandidateFilter, scorer Scorer) string {
+	// Define color functions
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	budget := plan.Budget
+	result := fmt.Sprintf("%s %s %.2f nm, %s %.2f nm, %s %.2f nm\n",
+		bold("Using:"),
+		bold("optimal distance:"), optimalDistance,
+		bold("maximum distance:"), maxDistance,
+		bold("coverage radius:"), coverageRadius)
+	if budget.MaxFBOs > 0 {
+		result += fmt.Sprintf("%s %d (now %d)\n", bold("Maximum FBOs:"), budget.MaxFBOs, plan.Before.FBOCount)
+	}
+	if budget.Budget > 0 {
+		result += fmt.Sprintf("%s %.1f (now %.1f)\n", bold("Budget:"), budget.Budget, plan.StartingCost)
+	}
+	if !filter.IsEmpty() {
+		result += fmt.Sprintf("%s %s\n", bold("Candidate filters:"), filter)
+	}
+	if scorer != nil && scorer.Name() != DefaultScorerName {
+		result += fmt.Sprintf("%s %s\n", bold("Scoring:"), scorer.Name())
+	}
+
+	if !plan.WithinBudget {
+		result += fmt.Sprintf("%s\n", red("The plan doesn't get the network within the budget. Loosen the candidate filters or unpin FBOs so more FBOs can be closed."))
+	}
+	if len(plan.Steps) == 0 {
+		if plan.WithinBudget {
+			result += fmt.Sprintf("\n%s\n", green("No change within the budget improves the network."))
+		}
+		result += formatPinOverrides(plan.PinOverrides, bold, cyan, yellow)
+		return result
+	}
+
+	result += fmt.Sprintf("\n%s\n", bold(cyan("Rebalance plan, one step at a time:")))
+	result += bold(fmt.Sprintf("%-3s %-6s %-36s  %8s  %4s  %10s  %8s  %9s  %11s", "#", "Action", "Airport", "Benefit", "FBOs", "Efficiency", "Coverage", "Components", "Longest gap")) + "\n"
+	for i, step := range plan.Steps {
+		action := green(fmt.Sprintf("%-6s", "Open"))
+		if step.Action == ScenarioRemove {
+			action = red(fmt.Sprintf("%-6s", "Close"))
+		}
+
+		benefit := fmt.Sprintf("%+8.1f", step.Benefit)
+		if step.Forced {
+			benefit = yellow(benefit)
+		}
+
+		result += fmt.Sprintf("%-3d %s %-36s  %s  %4d  %10.1f  %7.1f%%  %9d  %8.0f nm\n",
+			i+1, action,
+			step.Airport.Name+" ("+step.Airport.ICAO+")",
+			benefit, step.FBOCount, efficiencyScore(step.After), step.After.Coverage.CoveragePercent,
+			step.After.Components, step.After.LongestGap)
+		if budget.Budget > 0 {
+			result += fmt.Sprintf("    cost %.1f, total %.1f\n", step.Cost, step.TotalCost)
+		}
+	}
+
+	for _, step := range plan.Steps {
+		if step.Forced {
+			result += fmt.Sprintf("\n%s\n", yellow("Steps with a highlighted benefit close FBOs to get within the budget."))
+			break
+		}
+	}
+
+	result += "\n" + formatSummaryComparison([]string{"Now", "After plan"}, []NetworkSummary{plan.Before, plan.Steps[len(plan.Steps)-1].After})
+	result += formatPinOverrides(plan.PinOverrides, bold, cyan, yellow)
+
+	return result
+}
+
+// PlanFBORebalance builds a rebalance plan one step at a time. While the network is over the budget, it closes the
+// FBO whose removal hurts least. Otherwise it takes the opening or closing with the most benefit that stays within
+// the budget and doesn't split the network, stopping when nothing helps. Openings are tried at the best-scoring
+// airports, closings at FBOs passing the filter, and no airport is changed twice. Pinned FBOs are never closed and
+// excluded airports never opened, but steps they held back are noted.
+func PlanFBORebalance(db *sqlx.DB, budget RebalanceBudget, optimalDistance, maxDistance, coverageRadius float64, requireLights bool, preferredSize *int, filter CandidateFilter, scorer Scorer) (RebalancePlan, error) {
+	if err := budget.Validate(); err != nil {
+		return RebalancePlan{}, err
+	}
+	if scorer == nil {
+		scorer = NewDefaultScorer()
+	}
+
+	var airports []models.Airport
+	err := db.Select(&airports, "SELECT * FROM airports WHERE latitude IS NOT NULL AND longitude IS NOT NULL")
+	if err != nil {
+		return RebalancePlan{}, fmt.Errorf("error fetching airports: %w", err)
+	}
+
+	var allFBOs []models.Airport
+	err = db.Select(&allFBOs, "SELECT * FROM airports WHERE has_fbo = TRUE")
+	if err != nil {
+		return RebalancePlan{}, fmt.Errorf("error fetching FBOs: %w", err)
+	}
+
+	var fbos []models.Airport
+	for _, fbo := range allFBOs {
+		if fbo.Latitude != nil && fbo.Longitude != nil {
+			fbos = append(fbos, fbo)
+		}
+	}
+	if len(fbos) < 2 {
+		return RebalancePlan{}, &InsufficientFBOsError{Total: len(allFBOs), WithCoords: len(fbos)}
+	}
+
+	plan := RebalancePlan{
+		Budget:       budget,
+		Before:       SummariseNetwork(airports, fbos, optimalDistance, maxDistance, coverageRadius),
+		Steps:        []RebalanceStep{},
+		PinOverrides: []PinOverride{},
+	}
+	for _, fbo := range fbos {
+		plan.StartingCost += budget.cost(fbo)
+	}
+
+	ctx := newScoringContext(airports, optimalDistance, requireLights, preferredSize)
+	current, currentCost, summary := fbos, plan.StartingCost, plan.Before
+	touched := make(map[string]bool)
+	overridden := make(map[string]bool)
+
+	for len(plan.Steps) < maxRebalanceSteps {
+		overBudget := (budget.MaxFBOs > 0 && len(current) > budget.MaxFBOs) ||
+			(budget.Budget > 0 && currentCost > budget.Budget)
+
+		// Steps closing pinned FBOs or opening excluded airports are held back, only to be noted
+		var options, held []RebalanceStep
+		splits := func(after NetworkSummary) bool {
+			return after.Components > summary.Components || after.IsolatedFBOs > summary.IsolatedFBOs
+		}
+
+		// Closing an FBO
+		for i, fbo := range current {
+			if touched[fbo.ICAO] || !filter.Matches(fbo) || len(current) <= 2 {
+				continue
+			}
+			remaining := make([]models.Airport, 0, len(current)-1)
+			remaining = append(remaining, current[:i]...)
+			remaining = append(remaining, current[i+1:]...)
+
+			after := SummariseNetwork(airports, remaining, optimalDistance, maxDistance, coverageRadius)
+			if splits(after) && !overBudget {
+				continue
+			}
+			cost := budget.cost(fbo)
+			step := RebalanceStep{
+				Action:    ScenarioRemove,
+				Airport:   fbo,
+				Benefit:   networkImprovement(summary, after),
+				Cost:      cost,
+				FBOCount:  len(remaining),
+				TotalCost: currentCost - cost,
+				Forced:    overBudget,
+				After:     after,
+			}
+			if fbo.IsPinned {
+				held = append(held, step)
+			} else {
+				options = append(options, step)
+			}
+		}
+
+		// Opening an FBO, if the budget allows one more
+		if !overBudget && (budget.MaxFBOs == 0 || len(current) < budget.MaxFBOs) {
+			var candidates, excluded []CandidateScore
+			for _, airport := range airports {
+				if airport.HasFBO || touched[airport.ICAO] || (requireLights && !airport.HasLights) || !filter.Matches(airport) {
+					continue
+				}
+				if budget.Budget > 0 && currentCost+budget.cost(airport) > budget.Budget {
+					continue
+				}
+				score, ok := scorer.ScoreCandidate(ctx, airport, current)
+				if !ok {
+					continue
+				}
+				if airport.IsExcluded {
+					excluded = append(excluded, score)
+				} else {
+					candidates = append(candidates, score)
+				}
+			}
+			for _, scores := range [][]CandidateScore{candidates, excluded} {
+				sort.SliceStable(scores, func(i, j int) bool {
+					return scores[i].Score > scores[j].Score
+				})
+			}
+			if len(candidates) > rebalanceOpenCandidates {
+				candidates = candidates[:rebalanceOpenCandidates]
+			}
+			if len(excluded) > rebalanceOpenCandidates {
+				excluded = excluded[:rebalanceOpenCandidates]
+			}
+
+			for _, candidate := range append(candidates, excluded...) {
+				opened := candidate.Airport
+				opened.HasFBO = true
+				added := append(append([]models.Airport{}, current...), opened)
+
+				after := SummariseNetwork(airports, added, optimalDistance, maxDistance, coverageRadius)
+				if splits(after) {
+					continue
+				}
+				cost := budget.cost(opened)
+				step := RebalanceStep{
+					Action:    ScenarioAdd,
+					Airport:   opened,
+					Benefit:   networkImprovement(summary, after),
+					Cost:      cost,
+					FBOCount:  len(added),
+					TotalCost: currentCost + cost,
+					After:     after,
+				}
+				if opened.IsExcluded {
+					held = append(held, step)
+				} else {
+					options = append(options, step)
+				}
+			}
+		}
+
+		// When over the budget, avoid splitting the network if any closing allows it
+		if overBudget {
+			var whole []RebalanceStep
+			for _, option := range options {
+				if !splits(option.After) {
+					whole = append(whole, option)
+				}
+			}
+			if len(whole) > 0 {
+				options = whole
+			}
+		}
+
+		var best *RebalanceStep
+		for i := range options {
+			if best == nil || options[i].Benefit > best.Benefit {
+				best = &options[i]
+			}
+		}
+
+		// Note held back steps that would have beaten the one taken
+		for _, step := range held {
+			if overridden[step.Airport.ICAO] || (best != nil && step.Benefit <= best.Benefit) ||
+				(!overBudget && step.Benefit < minRebalanceBenefit) {
+				continue
+			}
+			overridden[step.Airport.ICAO] = true
+			plan.PinOverrides = append(plan.PinOverrides, PinOverride{
+				Airport: step.Airport,
+				Action:  step.Action,
+				Rank:    len(plan.Steps) + 1,
+				Score:   step.Benefit,
+			})
+		}
+
+		if best == nil || (!overBudget && best.Benefit < minRebalanceBenefit) {
+			break
+		}
+
+		plan.Steps = append(plan.Steps, *best)
+		touched[best.Airport.ICAO] = true
+		currentCost, summary = best.TotalCost, best.After
+		if best.Action == ScenarioAdd {
+			current = append(append([]models.Airport{}, current...), best.Airport)
+		} else {
+			var remaining []models.Airport
+			for _, fbo := range current {
+				if fbo.ICAO != best.Airport.ICAO {
+					remaining = append(remaining, fbo)
+				}
+			}
+			current = remaining
+		}
+	}
+
+	plan.WithinBudget = (budget.MaxFBOs == 0 || len(current) <= budget.MaxFBOs) &&
+		(budget.Budget == 0 || currentCost <= budget.Budget)
+
+	return plan, nil
+}
@@ -0,0 +1,231 @@
+package fbo
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/models"
+	"sort"
+)
+
+// relocationCandidatesPerFBO is how many of the best-scoring nearby airports are tried for each FBO
+const relocationCandidatesPerFBO = 5
+
+// Relocation is a move of an FBO to a nearby airport that improves the network
+type Relocation struct {
+	From models.Airport `json:"from"`
+	To   models.Airport `json:"to"`
+	// Distance is how far the FBO moves
+	Distance float64 `json:"distance"`
+	// CandidateScore is the destination's score as a location for a new FBO, against the rest of the network
+	CandidateScore float64 `json:"candidate_score"`
+	// Betweenness is the moving FBO's share of shortest routes, from the centrality analysis
+	Betweenness float64 `json:"betweenness"`
+	// EfficiencyChange and CoverageChange are in percentage points, and LongestGapChange in nm (negative is better)
+	EfficiencyChange float64 `json:"efficiency_change"`
+	CoverageChange   float64 `json:"coverage_change"`
+	LongestGapChange float64 `json:"longest_gap_change"`
+	// Improvement combines the changes: efficiency and coverage points, plus the longest gap's change as a
+	// percentage of the original gap
+	Improvement float64        `json:"improvement"`
+	After       NetworkSummary `json:"after"`
+}
+
+// RelocationAnalysis is the result of searching for FBO moves that improve the network
+type RelocationAnalysis struct {
+	Radius float64        `json:"radius"`
+	Before NetworkSummary `json:"before"`
+	// Considered is the number of moves evaluated
+	Considered int `json:"considered"`
+	// Relocations holds the best move for each FBO that has one, ranked by improvement
+	Relocations []Relocation `json:"relocations"`
+	// PinOverrides are the pinned FBOs that have a move that would improve the network
+	PinOverrides []PinOverride `json:"pin_overrides"`
+}
+
+// SuggestFBORelocations reports moves of existing FBOs to nearby airports that improve the network's efficiency,
+// coverage and longest gap without splitting it
+func SuggestFBORelocations(db *sqlx.DB, radius, optimalDistance, maxDistance, coverageRadius float64, requireLights bool, preferredSize *int, filter CandidateFilter, scorer Scorer) (string, error) {
+	// Define color functions
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	analysis, err := AnalyseFBORelocations(db, radius, optimalDistance, maxDistance, coverageRadius, requireLights, preferredSize, filter, scorer)
+	var insufficient *InsufficientFBOsError
+	if errors.As(err, &insufficient) {
+		return bold(yellow("At least 2 FBOs with latitude/longitude information are needed to suggest relocations.")), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	result := fmt.Sprintf("%s %s %.2f nm, %s %.2f nm, %s %.2f nm, %s %.2f nm\n",
+		bold("Using:"),
+		bold("relocation radius:"), radius,
+		bold("optimal distance:"), optimalDistance,
+		bold("maximum distance:"), maxDistance,
+		bold("coverage radius:"), coverageRadius)
+	if !filter.IsEmpty() {
+		result += fmt.Sprintf("%s %s\n", bold("Candidate filters:"), filter)
+	}
+	if scorer != nil && scorer.Name() != DefaultScorerName {
+		result += fmt.Sprintf("%s %s\n", bold("Scoring:"), scorer.Name())
+	}
+	result += fmt.Sprintf("%s %d moves of %d FBOs, trying the %d best-scoring airports within %.0f nm of each.\n",
+		bold("Evaluated:"), analysis.Considered, analysis.Before.FBOCount, relocationCandidatesPerFBO, radius)
+
+	if len(analysis.Relocations) == 0 {
+		result += fmt.Sprintf("\n%s\n", green("No move improves the network without splitting it. The FBOs are well placed."))
+		result += formatPinOverrides(analysis.PinOverrides, bold, cyan, yellow)
+		return result, nil
+	}
+
+	change := func(value float64, format string, lowerIsBetter bool) string {
+		text := fmt.Sprintf(format, value)
+		if (value > 0) != lowerIsBetter && value != 0 {
+			return green(text)
+		}
+		if value != 0 {
+			return red(text)
+		}
+		return text
+	}
+
+	result += fmt.Sprintf("\n%s\n", bold(cyan("Suggested relocations, best first:")))
+	for i, relocation := range analysis.Relocations {
+		result += fmt.Sprintf("%-3d %s %s %s %s %s, %.0f nm (candidate score %d)\n",
+			i+1,
+			bold(relocation.From.Name), cyan("("+relocation.From.ICAO+")"),
+			"to",
+			bold(relocation.To.Name), cyan("("+relocation.To.ICAO+")"),
+			relocation.Distance, int(relocation.CandidateScore))
+		result += fmt.Sprintf("    %s %s  %s %s  %s %s  %s %.1f\n",
+			bold("Efficiency:"), change(relocation.EfficiencyChange, "%+.1f", false),
+			bold("Coverage:"), change(relocation.CoverageChange, "%+.1f%%", false),
+			bold("Longest gap:"), change(relocation.LongestGapChange, "%+.0f nm", true),
+			bold("Improvement:"), relocation.Improvement)
+		if relocation.Betweenness > 0 {
+			result += fmt.Sprintf("    %s\n", yellow(fmt.Sprintf("This FBO carries %.1f%% of shortest routes; check them after moving it.", relocation.Betweenness*100)))
+		}
+	}
+	result += formatPinOverrides(analysis.PinOverrides, bold, cyan, yellow)
+
+	return result, nil
+}
+
+// AnalyseFBORelocations tries moving each FBO to the best-scoring eligible airports within radius of it. A move
+// counts when the network's components and isolated FBOs don't increase and the combined improvement is positive.
+// Only each FBO's best move is kept, and pinned FBOs' moves are noted instead of suggested.
+func AnalyseFBORelocations(db *sqlx.DB, radius, optimalDistance, maxDistance, coverageRadius float64, requireLights bool, preferredSize *int, filter CandidateFilter, scorer Scorer) (RelocationAnalysis, error) {
+	if scorer == nil {
+		scorer = NewDefaultScorer()
+	}
+
+	var airports []models.Airport
+	err := db.Select(&airports, "SELECT * FROM airports WHERE latitude IS NOT NULL AND longitude IS NOT NULL")
+	if err != nil {
+		return RelocationAnalysis{}, fmt.Errorf("error fetching airports: %w", err)
+	}
+
+	var allFBOs []models.Airport
+	err = db.Select(&allFBOs, "SELECT * FROM airports WHERE has_fbo = TRUE")
+	if err != nil {
+		return RelocationAnalysis{}, fmt.Errorf("error fetching FBOs: %w", err)
+	}
+
+	var fbos []models.Airport
+	for _, fbo := range allFBOs {
+		if fbo.Latitude != nil && fbo.Longitude != nil {
+			fbos = append(fbos, fbo)
+		}
+	}
+	if len(fbos) < 2 {
+		return RelocationAnalysis{}, &InsufficientFBOsError{Total: len(allFBOs), WithCoords: len(fbos)}
+	}
+
+	analysis := RelocationAnalysis{
+		Radius:       radius,
+		Before:       SummariseNetwork(airports, fbos, optimalDistance, maxDistance, coverageRadius),
+		Relocations:  []Relocation{},
+		PinOverrides: []PinOverride{},
+	}
+	centralities := FBOCentralities(fbos, BuildLegs(fbos, maxDistance))
+
+	ctx := newScoringContext(airports, optimalDistance, requireLights, preferredSize)
+	for i, fbo := range fbos {
+		others := make([]models.Airport, 0, len(fbos)-1)
+		others = append(others, fbos[:i]...)
+		others = append(others, fbos[i+1:]...)
+
+		// Score the eligible airports nearby against the rest of the network, keeping the best few
+		var candidates []CandidateScore
+		distances := make(map[string]float64)
+		ctx.index.within(*fbo.Latitude, *fbo.Longitude, radius, func(j int, distance float64) {
+			candidate := ctx.index.airports[j]
+			if candidate.HasFBO || candidate.IsExcluded || (requireLights && !candidate.HasLights) || !filter.Matches(candidate) {
+				return
+			}
+			score, ok := scorer.ScoreCandidate(ctx, candidate, others)
+			if !ok {
+				return
+			}
+			candidates = append(candidates, score)
+			distances[candidate.ICAO] = distance
+		})
+		sort.SliceStable(candidates, func(a, b int) bool {
+			return candidates[a].Score > candidates[b].Score
+		})
+		if len(candidates) > relocationCandidatesPerFBO {
+			candidates = candidates[:relocationCandidatesPerFBO]
+		}
+
+		var best *Relocation
+		for _, candidate := range candidates {
+			analysis.Considered++
+
+			moved := append(append([]models.Airport{}, others...), candidate.Airport)
+			after := SummariseNetwork(airports, moved, optimalDistance, maxDistance, coverageRadius)
+			if after.Components > analysis.Before.Components || after.IsolatedFBOs > analysis.Before.IsolatedFBOs {
+				continue
+			}
+
+			relocation := Relocation{
+				From:             fbo,
+				To:               candidate.Airport,
+				Distance:         distances[candidate.Airport.ICAO],
+				CandidateScore:   candidate.Score,
+				Betweenness:      centralities[i].Betweenness,
+				EfficiencyChange: efficiencyScore(after) - efficiencyScore(analysis.Before),
+				CoverageChange:   after.Coverage.CoveragePercent - analysis.Before.Coverage.CoveragePercent,
+				LongestGapChange: after.LongestGap - analysis.Before.LongestGap,
+				Improvement:      networkImprovement(analysis.Before, after),
+				After:            after,
+			}
+
+			if relocation.Improvement > 0 && (best == nil || relocation.Improvement > best.Improvement) {
+				best = &relocation
+			}
+		}
+
+		// Pinned FBOs stay put, but note the move they'd otherwise get
+		if best != nil && fbo.IsPinned {
+			analysis.PinOverrides = append(analysis.PinOverrides, PinOverride{
+				Airport: fbo,
+				Action:  pinOverrideMove,
+				Score:   best.Improvement,
+			})
+		} else if best != nil {
+			analysis.Relocations = append(analysis.Relocations, *best)
+		}
+	}
+
+	sort.SliceStable(analysis.Relocations, func(i, j int) bool {
+		return analysis.Relocations[i].Improvement > analysis.Relocations[j].Improvement
+	})
+
+	return analysis, nil
+}
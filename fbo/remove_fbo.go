@@ -8,8 +8,13 @@ import (
 
 // RemoveFBO removes an FBO from an airport
 func RemoveFBO(db *sqlx.DB, icao string) error {
+	return removeFBO(db, icao)
+}
+
+// removeFBO removes an FBO from an airport using db, which may be a transaction
+func removeFBO(db sqlx.Ext, icao string) error {
 	var airport models.Airport
-	err := db.Get(&airport, "SELECT * FROM airports WHERE icao = ?", icao)
+	err := sqlx.Get(db, &airport, "SELECT * FROM airports WHERE icao = ?", icao)
 	if err != nil {
 		return fmt.Errorf("airport with ICAO %s not found: %w", icao, err)
 	}
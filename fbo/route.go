@@ -0,0 +1,107 @@
+package fbo
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/models"
+	"math"
+)
+
+// FindFBORoute finds the shortest multi-hop route between two airports that stops only at FBOs,
+// with no leg longer than maxDistance. The returned route includes the departure and destination airports.
+func FindFBORoute(db *sqlx.DB, fromICAO, toICAO string, maxDistance float64) ([]models.Airport, error) {
+	var from, to models.Airport
+	err := db.Get(&from, "SELECT * FROM airports WHERE icao = ?", fromICAO)
+	if err != nil {
+		return nil, fmt.Errorf("airport with ICAO %s not found: %w", fromICAO, err)
+	}
+	err = db.Get(&to, "SELECT * FROM airports WHERE icao = ?", toICAO)
+	if err != nil {
+		return nil, fmt.Errorf("airport with ICAO %s not found: %w", toICAO, err)
+	}
+
+	if from.Latitude == nil || from.Longitude == nil {
+		return nil, fmt.Errorf("airport %s does not have latitude or longitude information", fromICAO)
+	}
+	if to.Latitude == nil || to.Longitude == nil {
+		return nil, fmt.Errorf("airport %s does not have latitude or longitude information", toICAO)
+	}
+
+	var fbos []models.Airport
+	err = db.Select(&fbos, "SELECT * FROM airports WHERE has_fbo = TRUE AND latitude IS NOT NULL AND longitude IS NOT NULL")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching existing FBOs: %w", err)
+	}
+
+	// Nodes are the departure, the destination and every FBO in between
+	nodes := []models.Airport{from, to}
+	for _, fbo := range fbos {
+		if fbo.ID != from.ID && fbo.ID != to.ID {
+			nodes = append(nodes, fbo)
+		}
+	}
+
+	route := shortestRoute(nodes, 0, 1, maxDistance)
+	if route == nil {
+		return nil, fmt.Errorf("no route from %s to %s via FBOs with legs of %.0f nm or less", fromICAO, toICAO, maxDistance)
+	}
+
+	return route, nil
+}
+
+// shortestRoute runs Dijkstra's algorithm over the airports in nodes, connecting any pair within maxDistance.
+// Returns nil if the destination cannot be reached.
+func shortestRoute(nodes []models.Airport, start, end int, maxDistance float64) []models.Airport {
+	dist := make([]float64, len(nodes))
+	prev := make([]int, len(nodes))
+	done := make([]bool, len(nodes))
+	for i := range nodes {
+		dist[i] = math.Inf(1)
+		prev[i] = -1
+	}
+	dist[start] = 0
+
+	for {
+		// Pick the closest unvisited node
+		current := -1
+		for i := range nodes {
+			if !done[i] && !math.IsInf(dist[i], 1) && (current == -1 || dist[i] < dist[current]) {
+				current = i
+			}
+		}
+		if current == -1 || current == end {
+			break
+		}
+		done[current] = true
+
+		for i := range nodes {
+			if done[i] {
+				continue
+			}
+
+			distance := CalculateDistance(
+				*nodes[current].Latitude, *nodes[current].Longitude,
+				*nodes[i].Latitude, *nodes[i].Longitude)
+			if distance > maxDistance {
+				continue
+			}
+
+			if dist[current]+distance < dist[i] {
+				dist[i] = dist[current] + distance
+				prev[i] = current
+			}
+		}
+	}
+
+	if math.IsInf(dist[end], 1) {
+		return nil
+	}
+
+	// Walk back from the destination to build the route
+	var route []models.Airport
+	for i := end; i != -1; i = prev[i] {
+		route = append([]models.Airport{nodes[i]}, route...)
+	}
+
+	return route
+}
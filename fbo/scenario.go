@@ -0,0 +1,262 @@
+package fbo
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/models"
+)
+
+const (
+	// ScenarioAdd marks a scenario change that adds an FBO
+	ScenarioAdd = "add"
+	// ScenarioRemove marks a scenario change that removes an FBO
+	ScenarioRemove = "remove"
+)
+
+// Scenario is a set of hypothetical FBO additions and removals
+type Scenario struct {
+	Name   string   `json:"name,omitempty"`
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+// ScenarioComparison is the FBO network before and after a scenario's changes
+type ScenarioComparison struct {
+	Scenario Scenario         `json:"scenario"`
+	Added    []models.Airport `json:"added"`
+	Removed  []models.Airport `json:"removed"`
+	Before   NetworkSummary   `json:"before"`
+	After    NetworkSummary   `json:"after"`
+}
+
+// scenarioChange is a row of the scenario_changes table
+type scenarioChange struct {
+	ICAO   string `db:"icao"`
+	Action string `db:"action"`
+}
+
+// WhatIfScenario compares the FBO network before and after a scenario's changes, without changing the database
+func WhatIfScenario(db *sqlx.DB, scenario Scenario, optimalDistance, maxDistance, coverageRadius float64) (string, error) {
+	// Define color functions
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	comparison, err := CompareScenario(db, scenario, optimalDistance, maxDistance, coverageRadius)
+	if err != nil {
+		return "", err
+	}
+
+	name := "unsaved scenario"
+	if scenario.Name != "" {
+		name = scenario.Name
+	}
+	result := fmt.Sprintf("%s %s\n", bold(cyan("What if:")), bold(name))
+	result += fmt.Sprintf("%s %s %.2f nm, %s %.2f nm, %s %.2f nm\n",
+		bold("Using:"),
+		bold("optimal distance:"), optimalDistance,
+		bold("maximum distance:"), maxDistance,
+		bold("coverage radius:"), coverageRadius)
+
+	for _, airport := range comparison.Added {
+		result += fmt.Sprintf("  %s %s %s\n", green("+"), bold(airport.Name), cyan("("+airport.ICAO+")"))
+	}
+	for _, airport := range comparison.Removed {
+		result += fmt.Sprintf("  %s %s %s\n", red("-"), bold(airport.Name), cyan("("+airport.ICAO+")"))
+	}
+
+	result += "\n" + formatSummaryComparison([]string{"Before", "After"}, []NetworkSummary{comparison.Before, comparison.After})
+
+	return result, nil
+}
+
+// CompareScenario summarises the FBO network as it is and with a scenario's changes applied
+func CompareScenario(db *sqlx.DB, scenario Scenario, optimalDistance, maxDistance, coverageRadius float64) (ScenarioComparison, error) {
+	var airports []models.Airport
+	err := db.Select(&airports, "SELECT * FROM airports WHERE latitude IS NOT NULL AND longitude IS NOT NULL")
+	if err != nil {
+		return ScenarioComparison{}, fmt.Errorf("error fetching airports: %w", err)
+	}
+
+	var fbos []models.Airport
+	err = db.Select(&fbos, "SELECT * FROM airports WHERE has_fbo = TRUE")
+	if err != nil {
+		return ScenarioComparison{}, fmt.Errorf("error fetching FBOs: %w", err)
+	}
+
+	after, added, removed, err := applyScenario(db, scenario, fbos)
+	if err != nil {
+		return ScenarioComparison{}, err
+	}
+
+	return ScenarioComparison{
+		Scenario: scenario,
+		Added:    added,
+		Removed:  removed,
+		Before:   SummariseNetwork(airports, fbos, optimalDistance, maxDistance, coverageRadius),
+		After:    SummariseNetwork(airports, after, optimalDistance, maxDistance, coverageRadius),
+	}, nil
+}
+
+// applyScenario returns the FBOs with a scenario's changes applied, and the airports added and removed
+func applyScenario(db sqlx.Queryer, scenario Scenario, fbos []models.Airport) ([]models.Airport, []models.Airport, []models.Airport, error) {
+	if len(scenario.Add) == 0 && len(scenario.Remove) == 0 {
+		return nil, nil, nil, fmt.Errorf("the scenario has no changes")
+	}
+
+	seen := make(map[string]bool)
+	for _, icao := range append(append([]string{}, scenario.Add...), scenario.Remove...) {
+		if seen[icao] {
+			return nil, nil, nil, fmt.Errorf("%s appears more than once in the scenario", icao)
+		}
+		seen[icao] = true
+	}
+
+	var added []models.Airport
+	for _, icao := range scenario.Add {
+		var airport models.Airport
+		err := sqlx.Get(db, &airport, "SELECT * FROM airports WHERE icao = ?", icao)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("airport with ICAO %s not found: %w", icao, err)
+		}
+		if airport.HasFBO {
+			return nil, nil, nil, fmt.Errorf("airport %s already has an FBO", icao)
+		}
+		if airport.Latitude == nil || airport.Longitude == nil {
+			return nil, nil, nil, fmt.Errorf("airport %s does not have latitude or longitude information", icao)
+		}
+		airport.HasFBO = true
+		added = append(added, airport)
+	}
+
+	removing := make(map[string]bool)
+	for _, icao := range scenario.Remove {
+		removing[icao] = true
+	}
+
+	var after, removed []models.Airport
+	for _, fbo := range fbos {
+		if removing[fbo.ICAO] {
+			removed = append(removed, fbo)
+			delete(removing, fbo.ICAO)
+			continue
+		}
+		after = append(after, fbo)
+	}
+	for _, icao := range scenario.Remove {
+		if removing[icao] {
+			return nil, nil, nil, fmt.Errorf("airport %s does not have an FBO", icao)
+		}
+	}
+
+	return append(after, added...), added, removed, nil
+}
+
+// CommitScenario applies a scenario's changes to the FBOs in the database, all or nothing
+func CommitScenario(db *sqlx.DB, scenario Scenario) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, icao := range scenario.Remove {
+		if err := removeFBO(tx, icao); err != nil {
+			return err
+		}
+	}
+	for _, icao := range scenario.Add {
+		if err := addFBO(tx, icao); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing scenario: %w", err)
+	}
+
+	return nil
+}
+
+// SaveScenario saves a named scenario, replacing any existing scenario with the same name
+func SaveScenario(db *sqlx.DB, scenario Scenario) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM scenario_changes WHERE scenario_name = ?", scenario.Name)
+	if err != nil {
+		return fmt.Errorf("error clearing scenario %s: %w", scenario.Name, err)
+	}
+
+	changes := map[string][]string{ScenarioAdd: scenario.Add, ScenarioRemove: scenario.Remove}
+	for _, action := range []string{ScenarioAdd, ScenarioRemove} {
+		for _, icao := range changes[action] {
+			var airportID string
+			err = tx.Get(&airportID, "SELECT id FROM airports WHERE icao = ?", icao)
+			if err != nil {
+				return fmt.Errorf("airport with ICAO %s not found: %w", icao, err)
+			}
+
+			_, err = tx.Exec(`
+				INSERT INTO scenario_changes (scenario_name, icao, airport_id, action)
+				VALUES (?, ?, ?, ?)
+			`, scenario.Name, icao, airportID, action)
+			if err != nil {
+				return fmt.Errorf("error adding %s to scenario %s: %w", icao, scenario.Name, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error saving scenario %s: %w", scenario.Name, err)
+	}
+
+	return nil
+}
+
+// ListScenarios lists the names of all saved scenarios
+func ListScenarios(db *sqlx.DB) ([]string, error) {
+	var names []string
+	err := db.Select(&names, "SELECT DISTINCT scenario_name FROM scenario_changes ORDER BY scenario_name")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching scenarios: %w", err)
+	}
+	return names, nil
+}
+
+// GetScenario returns a saved scenario
+func GetScenario(db *sqlx.DB, name string) (Scenario, error) {
+	var changes []scenarioChange
+	err := db.Select(&changes, "SELECT icao, action FROM scenario_changes WHERE scenario_name = ? ORDER BY icao", name)
+	if err != nil {
+		return Scenario{}, fmt.Errorf("error fetching scenario %s: %w", name, err)
+	}
+	if len(changes) == 0 {
+		return Scenario{}, fmt.Errorf("scenario %s not found", name)
+	}
+
+	scenario := Scenario{Name: name}
+	for _, change := range changes {
+		switch change.Action {
+		case ScenarioAdd:
+			scenario.Add = append(scenario.Add, change.ICAO)
+		case ScenarioRemove:
+			scenario.Remove = append(scenario.Remove, change.ICAO)
+		}
+	}
+	return scenario, nil
+}
+
+// DeleteScenario deletes a saved scenario
+func DeleteScenario(db *sqlx.DB, name string) error {
+	_, err := db.Exec("DELETE FROM scenario_changes WHERE scenario_name = ?", name)
+	if err != nil {
+		return fmt.Errorf("error deleting scenario %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,433 @@
+package fbo
+
+import (
+	"fmt"
+	"github.com/julietrb1/offair-cli/models"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultScorerName scores candidates by how close their legs are to the optimal distance
+	DefaultScorerName = "default"
+	// CoverageScorerName scores candidates by how many airports they bring within the coverage radius
+	CoverageScorerName = "coverage"
+	// DemandScorerName scores candidates by the airport demand they bring within the optimal distance
+	DemandScorerName = "demand"
+)
+
+// ScorerNames lists the scoring strategies that can be selected by name
+var ScorerNames = []string{DefaultScorerName, CoverageScorerName, DemandScorerName}
+
+// Scorer scores airports for the optimal location and redundancy analyses
+type Scorer interface {
+	// Name returns the name the scorer is selected by
+	Name() string
+	// ScoreCandidate scores a candidate airport as a location for a new FBO, higher being better.
+	// Returns false if the candidate can't be scored.
+	ScoreCandidate(ctx ScoringContext, candidate models.Airport, existingFBOs []models.Airport) (CandidateScore, bool)
+	// ScoreRedundancy scores how redundant an FBO is, higher being a better candidate for removal.
+	// remainingFBOs is the network without the FBO, and original and without are the metrics before and after.
+	ScoreRedundancy(ctx ScoringContext, fbo models.Airport, remainingFBOs []models.Airport, original, without NetworkMetrics) float64
+}
+
+// ScoringContext holds the analysis settings and airports a Scorer may use
+type ScoringContext struct {
+	Airports        []models.Airport
+	OptimalDistance float64
+	RequireLights   bool
+	PreferredSize   *int
+	// Explain records each step of a candidate's score in CandidateScore.Terms and AllConnections
+	Explain bool
+
+	index airportIndex
+}
+
+// newScoringContext builds the context for scoring against airports
+func newScoringContext(airports []models.Airport, optimalDistance float64, requireLights bool, preferredSize *int) ScoringContext {
+	return ScoringContext{
+		Airports:        airports,
+		OptimalDistance: optimalDistance,
+		RequireLights:   requireLights,
+		PreferredSize:   preferredSize,
+		index:           newAirportIndex(airports),
+	}
+}
+
+// NewScorer creates the scorer with the given name, overriding its default weights with any in weights.
+// coverageRadius is used by scorers that count covered airports.
+func NewScorer(name string, weights map[string]float64, coverageRadius float64) (Scorer, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", DefaultScorerName:
+		scorer := NewDefaultScorer()
+		if err := applyWeights(DefaultScorerName, scorer.weights(), weights); err != nil {
+			return nil, err
+		}
+		return *scorer, nil
+	case CoverageScorerName:
+		if coverageRadius <= 0 {
+			return nil, fmt.Errorf("coverage radius must be greater than zero")
+		}
+		scorer := NewCoverageScorer(coverageRadius)
+		if err := applyWeights(CoverageScorerName, scorer.weights(), weights); err != nil {
+			return nil, err
+		}
+		return *scorer, nil
+	case DemandScorerName:
+		scorer := NewDemandScorer()
+		if err := applyWeights(DemandScorerName, scorer.weights(), weights); err != nil {
+			return nil, err
+		}
+		return *scorer, nil
+	default:
+		return nil, fmt.Errorf("unknown scorer %q (available scorers: %s)", name, strings.Join(ScorerNames, ", "))
+	}
+}
+
+// applyWeights sets a scorer's weight fields from weights, rejecting any name the scorer doesn't have
+func applyWeights(scorerName string, fields map[string]*float64, weights map[string]float64) error {
+	for weightName, value := range weights {
+		field, ok := fields[weightName]
+		if !ok {
+			var known []string
+			for knownName := range fields {
+				known = append(known, knownName)
+			}
+			sort.Strings(known)
+			return fmt.Errorf("unknown weight %q for the %s scorer (available weights: %s)",
+				weightName, scorerName, strings.Join(known, ", "))
+		}
+		*field = value
+	}
+	return nil
+}
+
+// DefaultScorer is the original scoring: candidates score by how close their legs are to the optimal distance,
+// and FBOs are redundant when the network's efficiency improves without them
+type DefaultScorer struct {
+	OptimalRatioBonus          float64
+	ExactSizeBonus             float64
+	NearSizeBonus              float64
+	NoLightsPenalty            float64
+	RedundancyEfficiencyWeight float64
+	RedundancyRatioWeight      float64
+	RedundancyDistanceWeight   float64
+	RedundancyNearSizePenalty  float64
+	RedundancyFarSizePenalty   float64
+	RedundancyLightsCredit     float64
+}
+
+// NewDefaultScorer creates the default scorer with its standard weights
+func NewDefaultScorer() *DefaultScorer {
+	return &DefaultScorer{
+		OptimalRatioBonus:          20.0,
+		ExactSizeBonus:             15.0,
+		NearSizeBonus:              7.5,
+		NoLightsPenalty:            10.0,
+		RedundancyEfficiencyWeight: 20.0,
+		RedundancyRatioWeight:      15.0,
+		RedundancyDistanceWeight:   10.0,
+		RedundancyNearSizePenalty:  5.0,
+		RedundancyFarSizePenalty:   10.0,
+		RedundancyLightsCredit:     10.0,
+	}
+}
+
+// Name returns the name of the default scorer
+func (s DefaultScorer) Name() string {
+	return DefaultScorerName
+}
+
+// ScoreRedundancy scores an FBO by how the network metrics change without it, adjusted for size and lights
+func (s DefaultScorer) ScoreRedundancy(ctx ScoringContext, fbo models.Airport, remainingFBOs []models.Airport, original, without NetworkMetrics) float64 {
+	// A positive score means the network improves when this FBO is removed
+	// A negative score means the network gets worse when this FBO is removed
+	score := s.calculateRedundancyScore(original, without)
+
+	return score + redundancyPreferenceAdjustment(ctx, fbo, s.RedundancyNearSizePenalty, s.RedundancyFarSizePenalty, s.RedundancyLightsCredit)
+}
+
+// weights maps the configurable weight names to the scorer's fields
+func (s *DefaultScorer) weights() map[string]*float64 {
+	return map[string]*float64{
+		"optimal_ratio_bonus":          &s.OptimalRatioBonus,
+		"exact_size_bonus":             &s.ExactSizeBonus,
+		"near_size_bonus":              &s.NearSizeBonus,
+		"no_lights_penalty":            &s.NoLightsPenalty,
+		"redundancy_efficiency_weight": &s.RedundancyEfficiencyWeight,
+		"redundancy_ratio_weight":      &s.RedundancyRatioWeight,
+		"redundancy_distance_weight":   &s.RedundancyDistanceWeight,
+		"redundancy_near_size_penalty": &s.RedundancyNearSizePenalty,
+		"redundancy_far_size_penalty":  &s.RedundancyFarSizePenalty,
+		"redundancy_lights_credit":     &s.RedundancyLightsCredit,
+	}
+}
+
+// CoverageScorer scores purely on coverage: candidates by the airports they bring within the coverage radius,
+// and FBOs by how much of what they cover is also covered by the rest of the network
+type CoverageScorer struct {
+	Radius                    float64
+	NewCoverageWeight         float64
+	CloserWeight              float64
+	ExactSizeBonus            float64
+	NearSizeBonus             float64
+	NoLightsPenalty           float64
+	RedundancyNearSizePenalty float64
+	RedundancyFarSizePenalty  float64
+	RedundancyLightsCredit    float64
+}
+
+// NewCoverageScorer creates a coverage scorer with its standard weights
+func NewCoverageScorer(radius float64) *CoverageScorer {
+	return &CoverageScorer{
+		Radius:                    radius,
+		NewCoverageWeight:         10.0,
+		CloserWeight:              1.0,
+		ExactSizeBonus:            15.0,
+		NearSizeBonus:             7.5,
+		NoLightsPenalty:           10.0,
+		RedundancyNearSizePenalty: 5.0,
+		RedundancyFarSizePenalty:  10.0,
+		RedundancyLightsCredit:    10.0,
+	}
+}
+
+// Name returns the name of the coverage scorer
+func (s CoverageScorer) Name() string {
+	return CoverageScorerName
+}
+
+// ScoreCandidate scores a candidate by the airports it would newly cover and the covered airports it would be closer to
+func (s CoverageScorer) ScoreCandidate(ctx ScoringContext, candidate models.Airport, existingFBOs []models.Airport) (CandidateScore, bool) {
+	if candidate.Latitude == nil || candidate.Longitude == nil {
+		return CandidateScore{}, false
+	}
+
+	fbos := newAirportIndex(existingFBOs)
+	if len(fbos.airports) == 0 {
+		return CandidateScore{}, false
+	}
+
+	score := 0.0
+	newlyCovered, closer := 0, 0
+	ctx.index.within(*candidate.Latitude, *candidate.Longitude, s.Radius, func(i int, distance float64) {
+		airport := ctx.index.airports[i]
+		nearest := fbos.nearestDistance(*airport.Latitude, *airport.Longitude)
+		if nearest > s.Radius {
+			score += s.NewCoverageWeight
+			newlyCovered++
+		} else if distance < nearest {
+			score += s.CloserWeight
+			closer++
+		}
+	})
+
+	var terms []ScoreTerm
+	if ctx.Explain {
+		terms = append(terms,
+			ScoreTerm{
+				Term:  fmt.Sprintf("%d airports newly covered x %.1f", newlyCovered, s.NewCoverageWeight),
+				Value: float64(newlyCovered) * s.NewCoverageWeight,
+				Score: float64(newlyCovered) * s.NewCoverageWeight,
+			},
+			ScoreTerm{
+				Term:  fmt.Sprintf("%d covered airports brought closer x %.1f", closer, s.CloserWeight),
+				Value: float64(closer) * s.CloserWeight,
+				Score: score,
+			})
+	}
+
+	bonus := sizeBonus(ctx, candidate, s.ExactSizeBonus, s.NearSizeBonus)
+	penalty := lightsPenalty(ctx, candidate, s.NoLightsPenalty)
+	score += bonus - penalty
+	terms = append(terms, preferenceTerms(ctx, candidate, score, bonus, penalty)...)
+	if score < 0 {
+		if ctx.Explain {
+			terms = append(terms, ScoreTerm{Term: "Floor at 0", Value: -score, Score: 0})
+		}
+		score = 0
+	}
+	if rounded := math.Floor(score); ctx.Explain && rounded != score {
+		terms = append(terms, ScoreTerm{Term: "Round down to a whole number", Value: rounded - score, Score: rounded})
+	}
+
+	eligible, total, connections := candidateConnections(candidate, existingFBOs, ctx.OptimalDistance)
+	candidateScore := CandidateScore{
+		Airport:             candidate,
+		Score:               math.Floor(score),
+		EligibleConnections: eligible,
+		TotalConnections:    total,
+		Connections:         connections,
+		Terms:               terms,
+	}
+	if ctx.Explain {
+		candidateScore.AllConnections = allCandidateConnections(candidate, existingFBOs, ctx.OptimalDistance)
+	}
+	return candidateScore, true
+}
+
+// ScoreRedundancy scores an FBO by the percentage of the airports it covers that the rest of the network also covers,
+// adjusted for size and lights
+func (s CoverageScorer) ScoreRedundancy(ctx ScoringContext, fbo models.Airport, remainingFBOs []models.Airport, original, without NetworkMetrics) float64 {
+	if fbo.Latitude == nil || fbo.Longitude == nil {
+		return 0
+	}
+
+	remaining := newAirportIndex(remainingFBOs)
+	covered, stillCovered := 0, 0
+	ctx.index.within(*fbo.Latitude, *fbo.Longitude, s.Radius, func(i int, distance float64) {
+		airport := ctx.index.airports[i]
+		covered++
+		if remaining.nearestDistance(*airport.Latitude, *airport.Longitude) <= s.Radius {
+			stillCovered++
+		}
+	})
+
+	score := 100.0
+	if covered > 0 {
+		score = float64(stillCovered) / float64(covered) * 100.0
+	}
+
+	return score + redundancyPreferenceAdjustment(ctx, fbo, s.RedundancyNearSizePenalty, s.RedundancyFarSizePenalty, s.RedundancyLightsCredit)
+}
+
+// weights maps the configurable weight names to the scorer's fields
+func (s *CoverageScorer) weights() map[string]*float64 {
+	return map[string]*float64{
+		"new_coverage_weight":          &s.NewCoverageWeight,
+		"closer_weight":                &s.CloserWeight,
+		"exact_size_bonus":             &s.ExactSizeBonus,
+		"near_size_bonus":              &s.NearSizeBonus,
+		"no_lights_penalty":            &s.NoLightsPenalty,
+		"redundancy_near_size_penalty": &s.RedundancyNearSizePenalty,
+		"redundancy_far_size_penalty":  &s.RedundancyFarSizePenalty,
+		"redundancy_lights_credit":     &s.RedundancyLightsCredit,
+	}
+}
+
+// sizeBonus returns the bonus for a candidate matching or being next to the preferred size
+func sizeBonus(ctx ScoringContext, candidate models.Airport, exactSizeBonus, nearSizeBonus float64) float64 {
+	if ctx.PreferredSize != nil && candidate.Size != nil {
+		if *candidate.Size == *ctx.PreferredSize {
+			return exactSizeBonus
+		} else if *candidate.Size == *ctx.PreferredSize+1 || *candidate.Size == *ctx.PreferredSize-1 {
+			return nearSizeBonus
+		}
+	}
+	return 0
+}
+
+// lightsPenalty returns the penalty for a candidate having no lights when lights aren't required
+func lightsPenalty(ctx ScoringContext, candidate models.Airport, noLightsPenalty float64) float64 {
+	if !ctx.RequireLights && !candidate.HasLights {
+		return noLightsPenalty
+	}
+	return 0
+}
+
+// preferenceTerms explains the size bonus and lights penalty that brought a candidate's score to score,
+// or returns nil when not explaining
+func preferenceTerms(ctx ScoringContext, candidate models.Airport, score, bonus, penalty float64) []ScoreTerm {
+	if !ctx.Explain {
+		return nil
+	}
+
+	var terms []ScoreTerm
+	if bonus != 0 {
+		terms = append(terms, ScoreTerm{
+			Term:  fmt.Sprintf("Preferred size bonus (size %d, preferred %d)", *candidate.Size, *ctx.PreferredSize),
+			Value: bonus,
+			Score: score + penalty,
+		})
+	}
+	if penalty != 0 {
+		terms = append(terms, ScoreTerm{Term: "No lights penalty", Value: -penalty, Score: score})
+	}
+	return terms
+}
+
+// redundancyPreferenceAdjustment makes FBOs that don't match the preferred size more redundant,
+// and FBOs with lights less redundant when lights are required
+func redundancyPreferenceAdjustment(ctx ScoringContext, fbo models.Airport, nearSizePenalty, farSizePenalty, lightsCredit float64) float64 {
+	adjustment := 0.0
+
+	// Apply size preference if specified
+	if ctx.PreferredSize != nil && fbo.Size != nil {
+		size := *fbo.Size
+		preferredSizeVal := *ctx.PreferredSize
+
+		if size != preferredSizeVal {
+			// Increase redundancy score for FBOs that don't match preferred size
+			if size == preferredSizeVal+1 || size == preferredSizeVal-1 {
+				// Smaller penalty for sizes close to preferred
+				adjustment += nearSizePenalty
+			} else {
+				// Larger penalty for sizes far from preferred
+				adjustment += farSizePenalty
+			}
+		}
+	}
+
+	// Apply negative weight for airports with lights if required
+	if ctx.RequireLights && fbo.HasLights {
+		// Decrease redundancy score for FBOs with lights when lights are required
+		adjustment -= lightsCredit
+	}
+
+	return adjustment
+}
+
+// candidateConnections counts a candidate's connections to the FBO network and lists those within 20% of the
+// optimal distance, best first
+func candidateConnections(candidate models.Airport, existingFBOs []models.Airport, optimalDistance float64) (int, int, []CandidateConnection) {
+	var connections []CandidateConnection
+	total := 0
+
+	for _, fbo := range existingFBOs {
+		if fbo.Latitude == nil || fbo.Longitude == nil {
+			continue
+		}
+
+		distance := CalculateDistance(*candidate.Latitude, *candidate.Longitude, *fbo.Latitude, *fbo.Longitude)
+		total++
+
+		if math.Abs(distance-optimalDistance) <= 0.2*optimalDistance {
+			connections = append(connections, CandidateConnection{
+				ICAO:         fbo.ICAO,
+				Distance:     distance,
+				Contribution: 100.0 - math.Min(100.0, (math.Abs(distance-optimalDistance)/optimalDistance)*100.0),
+			})
+		}
+	}
+
+	sort.SliceStable(connections, func(i, j int) bool {
+		return connections[i].Contribution > connections[j].Contribution
+	})
+
+	return len(connections), total, connections
+}
+
+// allCandidateConnections lists the candidate's connection to every FBO, with the contribution each would make
+// under the default scorer, closest to the optimal distance first
+func allCandidateConnections(candidate models.Airport, existingFBOs []models.Airport, optimalDistance float64) []CandidateConnection {
+	var connections []CandidateConnection
+	for _, fbo := range existingFBOs {
+		if fbo.Latitude == nil || fbo.Longitude == nil {
+			continue
+		}
+
+		distance := CalculateDistance(*candidate.Latitude, *candidate.Longitude, *fbo.Latitude, *fbo.Longitude)
+		connections = append(connections, CandidateConnection{
+			ICAO:         fbo.ICAO,
+			Distance:     distance,
+			Contribution: 100.0 - math.Min(100.0, (math.Abs(distance-optimalDistance)/optimalDistance)*100.0),
+		})
+	}
+
+	sort.SliceStable(connections, func(i, j int) bool {
+		return connections[i].Contribution > connections[j].Contribution
+	})
+
+	return connections
+}
@@ -6,10 +6,11 @@ import (
 	"math"
 )
 
+// earthRadiusNM is the Earth's radius in nm
+const earthRadiusNM = 3440.0
+
 // CalculateDistance calculates the distance between two points using the Haversine formula
 func CalculateDistance(lat1, lon1, lat2, lon2 float64) float64 {
-	const earthRadiusNM = 3440.0 // Earth radius in nm
-
 	// Convert latitude and longitude from degrees to radians
 	lat1Rad := lat1 * (math.Pi / 180.0)
 	lon1Rad := lon1 * (math.Pi / 180.0)
@@ -76,30 +77,30 @@ func calculateNetworkMetrics(fboList []models.Airport, optimalDistance float64)
 	}
 
 	// Calculate average distance
-	metrics.averageDistance = totalDistance / float64(connections)
+	metrics.AverageDistance = totalDistance / float64(connections)
 
 	// Calculate efficiency score (higher is better)
 	// Based on how many connections are optimal and how close the average is to optimal
 	optimalRatio := float64(optimalConnections) / float64(connections)
-	distanceScore := 100.0 - math.Min(100.0, (math.Abs(metrics.averageDistance-optimalDistance)/optimalDistance)*100.0)
+	distanceScore := 100.0 - math.Min(100.0, (math.Abs(metrics.AverageDistance-optimalDistance)/optimalDistance)*100.0)
 
-	metrics.efficiencyScore = (optimalRatio * 50.0) + (distanceScore * 0.5)
-	metrics.optimalConnections = optimalConnections
-	metrics.totalConnections = connections
+	metrics.EfficiencyScore = (optimalRatio * 50.0) + (distanceScore * 0.5)
+	metrics.OptimalConnections = optimalConnections
+	metrics.TotalConnections = connections
 
 	return metrics, nil
 }
 
-// calculateRedundancyScore calculates how redundant an FBO is
+// calculateRedundancyScore calculates how redundant an FBO is from the network metrics with and without it
 // Higher score means more redundant (better candidate for removal)
-func calculateRedundancyScore(originalMetrics, newMetrics NetworkMetrics) float64 {
+func (s DefaultScorer) calculateRedundancyScore(originalMetrics, newMetrics NetworkMetrics) float64 {
 	// Calculate percentage changes
-	avgDistanceChange := (newMetrics.averageDistance - originalMetrics.averageDistance) / originalMetrics.averageDistance
-	efficiencyChange := (newMetrics.efficiencyScore - originalMetrics.efficiencyScore) / originalMetrics.efficiencyScore
+	avgDistanceChange := (newMetrics.AverageDistance - originalMetrics.AverageDistance) / originalMetrics.AverageDistance
+	efficiencyChange := (newMetrics.EfficiencyScore - originalMetrics.EfficiencyScore) / originalMetrics.EfficiencyScore
 
 	// Calculate optimal connection ratio change
-	originalRatio := float64(originalMetrics.optimalConnections) / float64(originalMetrics.totalConnections)
-	newRatio := float64(newMetrics.optimalConnections) / float64(newMetrics.totalConnections)
+	originalRatio := float64(originalMetrics.OptimalConnections) / float64(originalMetrics.TotalConnections)
+	newRatio := float64(newMetrics.OptimalConnections) / float64(newMetrics.TotalConnections)
 	ratioChange := newRatio - originalRatio
 
 	// Combine factors into a score
@@ -108,17 +109,17 @@ func calculateRedundancyScore(originalMetrics, newMetrics NetworkMetrics) float6
 
 	// Apply logarithmic scaling to make the algorithm more stable
 	// This will spread out the scores more evenly and reduce sensitivity to small changes
-	efficiencyComponent := math.Log1p(math.Abs(efficiencyChange)) * 20.0
+	efficiencyComponent := math.Log1p(math.Abs(efficiencyChange)) * s.RedundancyEfficiencyWeight
 	if efficiencyChange < 0 {
 		efficiencyComponent = -efficiencyComponent
 	}
 
-	ratioComponent := math.Log1p(math.Abs(ratioChange)) * 15.0
+	ratioComponent := math.Log1p(math.Abs(ratioChange)) * s.RedundancyRatioWeight
 	if ratioChange < 0 {
 		ratioComponent = -ratioComponent
 	}
 
-	distanceComponent := math.Log1p(math.Abs(avgDistanceChange)) * 10.0
+	distanceComponent := math.Log1p(math.Abs(avgDistanceChange)) * s.RedundancyDistanceWeight
 	if avgDistanceChange > 0 {
 		distanceComponent = -distanceComponent
 	}
@@ -0,0 +1,301 @@
+package fbo
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/models"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+const (
+	// JobOriginOutOfRange is the reason a job is unserved when no FBO is within range of its origin
+	JobOriginOutOfRange = "origin out of range"
+	// JobLegTooLong is the reason a job is unserved when the job itself is longer than the aircraft's range
+	JobLegTooLong = "job longer than range"
+	// simulationTopOrigins is how many of the origins with the most unserved jobs are reported
+	simulationTopOrigins = 10
+	// MaxSimulationJobs caps the jobs in one simulation, so a single run can't tie up the CLI or server
+	MaxSimulationJobs = 100000
+)
+
+// SimulationSettings control a job-demand simulation
+type SimulationSettings struct {
+	Jobs int `json:"jobs"`
+	// Range is the aircraft's range in nm, for both the deadhead leg and the job
+	Range float64 `json:"range"`
+	// Seed makes the simulation reproducible: the same seed and database give the same jobs
+	Seed int64 `json:"seed"`
+}
+
+// DefaultSimulationSettings returns 1,000 jobs with seed 1. The range has no default, so callers set it.
+func DefaultSimulationSettings() SimulationSettings {
+	return SimulationSettings{
+		Jobs: 1000,
+		Seed: 1,
+	}
+}
+
+// Validate checks the settings can run a simulation
+func (s SimulationSettings) Validate() error {
+	if s.Jobs < 1 || s.Jobs > MaxSimulationJobs {
+		return fmt.Errorf("the simulation needs from 1 to %d jobs", MaxSimulationJobs)
+	}
+	if s.Range <= 0 {
+		return fmt.Errorf("the aircraft range must be greater than zero")
+	}
+	return nil
+}
+
+// SimulationBucket is a count of jobs sharing a label, and their share of the jobs it's counted against
+type SimulationBucket struct {
+	Label string  `json:"label"`
+	Count int     `json:"count"`
+	Share float64 `json:"share"`
+}
+
+// JobSimulation is the result of simulating random jobs against the FBO network
+type JobSimulation struct {
+	Settings     SimulationSettings `json:"settings"`
+	AirportCount int                `json:"airport_count"`
+	FBOCount     int                `json:"fbo_count"`
+	Served       int                `json:"served"`
+	// ServedPercent is the percentage of jobs served
+	ServedPercent float64 `json:"served_percent"`
+	// AverageDeadhead and MaxDeadhead are the distances flown from the nearest FBO to served jobs' origins, in nm
+	AverageDeadhead float64 `json:"average_deadhead"`
+	MaxDeadhead     float64 `json:"max_deadhead"`
+	// DeadheadDistribution buckets the served jobs by deadhead distance, as shares of the served jobs
+	DeadheadDistribution []SimulationBucket `json:"deadhead_distribution"`
+	// UnservedByReason, UnservedByRegion and UnservedOrigins break down the unserved jobs, as shares of them
+	UnservedByReason []SimulationBucket `json:"unserved_by_reason"`
+	UnservedByRegion []SimulationBucket `json:"unserved_by_region"`
+	UnservedOrigins  []SimulationBucket `json:"unserved_origins"`
+}
+
+// SimulateJobs simulates random jobs against the FBO network and formats how many it can serve, how far aircraft
+// deadhead to them, and where the unserved jobs are
+func SimulateJobs(db *sqlx.DB, settings SimulationSettings) (string, error) {
+	// Define color functions
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	simulation, err := AnalyseJobSimulation(db, settings)
+	if err != nil {
+		return "", err
+	}
+
+	result := fmt.Sprintf("%s %s %d, %s %.0f nm, %s %d\n",
+		bold("Using:"),
+		bold("jobs:"), settings.Jobs,
+		bold("aircraft range:"), settings.Range,
+		bold("seed:"), settings.Seed)
+	result += fmt.Sprintf("%s %d airports (weighted by size) and %d FBOs.\n",
+		bold("Drawing from:"), simulation.AirportCount, simulation.FBOCount)
+
+	served := fmt.Sprintf("%d/%d (%.1f%%)", simulation.Served, settings.Jobs, simulation.ServedPercent)
+	switch {
+	case simulation.ServedPercent >= 90:
+		served = green(served)
+	case simulation.ServedPercent >= 60:
+		served = yellow(served)
+	default:
+		served = red(served)
+	}
+	result += fmt.Sprintf("\n%s %s\n", bold("Jobs served:"), served)
+	if simulation.Served > 0 {
+		result += fmt.Sprintf("%s %.0f nm average, %.0f nm longest\n",
+			bold("Deadhead from the nearest FBO:"), simulation.AverageDeadhead, simulation.MaxDeadhead)
+	}
+
+	formatBuckets := func(heading string, buckets []SimulationBucket) string {
+		if len(buckets) == 0 {
+			return ""
+		}
+		section := fmt.Sprintf("\n%s\n", bold(cyan(heading)))
+		for _, bucket := range buckets {
+			section += fmt.Sprintf("  %-28s %6d  %5.1f%%\n", bucket.Label, bucket.Count, bucket.Share*100)
+		}
+		return section
+	}
+
+	result += formatBuckets("Served jobs by deadhead:", simulation.DeadheadDistribution)
+	if simulation.Served == settings.Jobs {
+		result += fmt.Sprintf("\n%s\n", green("Every job can be served."))
+		return result, nil
+	}
+	result += formatBuckets("Unserved jobs by reason:", simulation.UnservedByReason)
+	result += formatBuckets("Unserved jobs by origin region:", simulation.UnservedByRegion)
+	result += formatBuckets("Origins with the most unserved jobs:", simulation.UnservedOrigins)
+
+	return result, nil
+}
+
+// AnalyseJobSimulation draws random jobs between airports with coordinates, picking each end with a weight of its
+// size plus one, and checks each against the FBO network. A job is served when an FBO is within range of its origin
+// and the job is within range; the aircraft deadheads from the nearest FBO.
+func AnalyseJobSimulation(db *sqlx.DB, settings SimulationSettings) (JobSimulation, error) {
+	if err := settings.Validate(); err != nil {
+		return JobSimulation{}, err
+	}
+
+	var airports []models.Airport
+	err := db.Select(&airports, "SELECT * FROM airports WHERE latitude IS NOT NULL AND longitude IS NOT NULL ORDER BY icao")
+	if err != nil {
+		return JobSimulation{}, fmt.Errorf("error fetching airports: %w", err)
+	}
+	if len(airports) < 2 {
+		return JobSimulation{}, fmt.Errorf("at least 2 airports with latitude/longitude information are needed to simulate jobs")
+	}
+
+	var allFBOs []models.Airport
+	err = db.Select(&allFBOs, "SELECT * FROM airports WHERE has_fbo = TRUE")
+	if err != nil {
+		return JobSimulation{}, fmt.Errorf("error fetching FBOs: %w", err)
+	}
+
+	var fbos []models.Airport
+	for _, fbo := range allFBOs {
+		if fbo.Latitude != nil && fbo.Longitude != nil {
+			fbos = append(fbos, fbo)
+		}
+	}
+	if len(fbos) == 0 {
+		return JobSimulation{}, &InsufficientFBOsError{Total: len(allFBOs), WithCoords: len(fbos)}
+	}
+	fboIndex := newAirportIndex(fbos)
+
+	// Cumulative weights, so a uniform draw up to the total picks an airport in proportion to its weight
+	cumulative := make([]float64, len(airports))
+	var total float64
+	for i, airport := range airports {
+		weight := 1.0
+		if airport.Size != nil && *airport.Size > 0 {
+			weight += float64(*airport.Size)
+		}
+		total += weight
+		cumulative[i] = total
+	}
+	random := rand.New(rand.NewSource(settings.Seed))
+	draw := func() int {
+		return sort.SearchFloat64s(cumulative, random.Float64()*total)
+	}
+
+	simulation := JobSimulation{
+		Settings:     settings,
+		AirportCount: len(airports),
+		FBOCount:     len(fbos),
+	}
+
+	// Airports are drawn repeatedly, so remember each one's nearest FBO
+	nearestFBO := make(map[int]float64)
+	var deadheads []float64
+	reasons := make(map[string]int)
+	regions := make(map[string]int)
+	origins := make(map[string]int)
+
+	for job := 0; job < settings.Jobs; job++ {
+		from := draw()
+		to := draw()
+		for to == from {
+			to = draw()
+		}
+		origin, destination := airports[from], airports[to]
+
+		deadhead, ok := nearestFBO[from]
+		if !ok {
+			deadhead = fboIndex.nearestDistance(*origin.Latitude, *origin.Longitude)
+			nearestFBO[from] = deadhead
+		}
+		distance := CalculateDistance(*origin.Latitude, *origin.Longitude, *destination.Latitude, *destination.Longitude)
+
+		switch {
+		case deadhead > settings.Range:
+			reasons[JobOriginOutOfRange]++
+		case distance > settings.Range:
+			reasons[JobLegTooLong]++
+		default:
+			deadheads = append(deadheads, deadhead)
+			continue
+		}
+
+		region := origin.CountryCode
+		if origin.State != nil && *origin.State != "" {
+			region = *origin.State + ", " + origin.CountryCode
+		}
+		regions[region]++
+		origins[origin.Name+" ("+origin.ICAO+")"]++
+	}
+
+	simulation.Served = len(deadheads)
+	simulation.ServedPercent = float64(simulation.Served) / float64(settings.Jobs) * 100.0
+	unserved := settings.Jobs - simulation.Served
+
+	if simulation.Served > 0 {
+		buckets := []struct {
+			label string
+			upTo  float64
+		}{
+			{"At an FBO", 0},
+			{fmt.Sprintf("Up to %.0f nm", settings.Range/4), settings.Range / 4},
+			{fmt.Sprintf("Up to %.0f nm", settings.Range/2), settings.Range / 2},
+			{fmt.Sprintf("Up to %.0f nm", settings.Range*3/4), settings.Range * 3 / 4},
+			{fmt.Sprintf("Up to %.0f nm", settings.Range), settings.Range},
+		}
+		counts := make([]int, len(buckets))
+		var totalDeadhead float64
+		for _, deadhead := range deadheads {
+			totalDeadhead += deadhead
+			simulation.MaxDeadhead = math.Max(simulation.MaxDeadhead, deadhead)
+			for i, bucket := range buckets {
+				if deadhead <= bucket.upTo {
+					counts[i]++
+					break
+				}
+			}
+		}
+		simulation.AverageDeadhead = totalDeadhead / float64(simulation.Served)
+		for i, bucket := range buckets {
+			simulation.DeadheadDistribution = append(simulation.DeadheadDistribution, SimulationBucket{
+				Label: bucket.label,
+				Count: counts[i],
+				Share: float64(counts[i]) / float64(simulation.Served),
+			})
+		}
+	}
+
+	simulation.UnservedByReason = simulationBuckets(reasons, unserved, 0)
+	simulation.UnservedByRegion = simulationBuckets(regions, unserved, 0)
+	simulation.UnservedOrigins = simulationBuckets(origins, unserved, simulationTopOrigins)
+
+	return simulation, nil
+}
+
+// simulationBuckets turns counts into buckets, most first, as shares of total, keeping up to limit (0 for all)
+func simulationBuckets(counts map[string]int, total, limit int) []SimulationBucket {
+	buckets := []SimulationBucket{}
+	for label, count := range counts {
+		buckets = append(buckets, SimulationBucket{
+			Label: label,
+			Count: count,
+			Share: float64(count) / float64(total),
+		})
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		if buckets[i].Count != buckets[j].Count {
+			return buckets[i].Count > buckets[j].Count
+		}
+		return buckets[i].Label < buckets[j].Label
+	})
+	if limit > 0 && len(buckets) > limit {
+		buckets = buckets[:limit]
+	}
+
+	return buckets
+}
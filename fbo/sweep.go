@@ -0,0 +1,283 @@
+package fbo
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/models"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	// robustSweepShare is the share of settings a candidate must be in the top N of to count as robust
+	robustSweepShare = 0.75
+	// MaxSweepSettings caps the combinations in one sweep, as each optimal distance and lights setting reruns the
+	// optimal location analysis over every airport
+	MaxSweepSettings = 50
+)
+
+// SweepRanges are the settings a parameter sweep runs the optimal location analysis with, in every combination
+type SweepRanges struct {
+	OptimalDistances []float64 `json:"optimal_distances"`
+	MaxDistances     []float64 `json:"max_distances"`
+	RequireLights    []bool    `json:"require_lights"`
+	// TopN is how many of each setting's best candidates count as recommended
+	TopN int `json:"top_n"`
+}
+
+// Validate checks the ranges have at least one of each setting, no setting twice, and no more than MaxSweepSettings
+// combinations
+func (r SweepRanges) Validate() error {
+	if len(r.OptimalDistances) == 0 || len(r.MaxDistances) == 0 || len(r.RequireLights) == 0 {
+		return fmt.Errorf("the sweep needs at least one optimal distance, max distance and lights setting")
+	}
+	for _, distances := range [][]float64{r.OptimalDistances, r.MaxDistances} {
+		seen := make(map[float64]bool)
+		for _, distance := range distances {
+			if distance <= 0 {
+				return fmt.Errorf("sweep distances must be greater than zero")
+			}
+			if seen[distance] {
+				return fmt.Errorf("the sweep has %g nm more than once", distance)
+			}
+			seen[distance] = true
+		}
+	}
+	seenLights := make(map[bool]bool)
+	for _, requireLights := range r.RequireLights {
+		if seenLights[requireLights] {
+			return fmt.Errorf("the sweep has the lights setting %t more than once", requireLights)
+		}
+		seenLights[requireLights] = true
+	}
+	if settings := len(r.OptimalDistances) * len(r.MaxDistances) * len(r.RequireLights); settings > MaxSweepSettings {
+		return fmt.Errorf("the sweep has %d settings, more than the %d it can run", settings, MaxSweepSettings)
+	}
+	if r.TopN < 1 {
+		return fmt.Errorf("the sweep's top N must be at least 1")
+	}
+	return nil
+}
+
+// SweepSetting is one combination of settings in a parameter sweep, with its top candidates
+type SweepSetting struct {
+	OptimalDistance float64 `json:"optimal_distance"`
+	MaxDistance     float64 `json:"max_distance"`
+	RequireLights   bool    `json:"require_lights"`
+	// Top holds the ICAOs of the setting's top N candidates, best first
+	Top []string `json:"top"`
+}
+
+// Label describes the setting in a few characters, for table and CSV headings
+func (s SweepSetting) Label() string {
+	lights := "any"
+	if s.RequireLights {
+		lights = "lit"
+	}
+	return fmt.Sprintf("%.0f/%.0f/%s", s.OptimalDistance, s.MaxDistance, lights)
+}
+
+// SweepCandidate is how an airport ranked across a parameter sweep
+type SweepCandidate struct {
+	Airport models.Airport `json:"airport"`
+	// TopCount is how many settings had the airport in their top N, and Share that as a fraction of all settings
+	TopCount int     `json:"top_count"`
+	Share    float64 `json:"share"`
+	// BestRank and WorstRank are the airport's best and worst rank among the settings it was in the top N of
+	BestRank  int `json:"best_rank"`
+	WorstRank int `json:"worst_rank"`
+	// Ranks holds the airport's rank in each setting, in the order of the analysis' settings, or 0 outside the top N
+	Ranks  []int `json:"ranks"`
+	Robust bool  `json:"robust"`
+}
+
+// SweepAnalysis is the result of running the optimal location analysis across a range of settings
+type SweepAnalysis struct {
+	Ranges   SweepRanges    `json:"ranges"`
+	Settings []SweepSetting `json:"settings"`
+	// Candidates are the airports in the top N of any setting, most often first
+	Candidates []SweepCandidate `json:"candidates"`
+}
+
+// SweepOptimalFBOLocations runs the optimal location analysis across a range of settings and reports which
+// candidates stay near the top (robust) and which only make it under some settings (fragile)
+func SweepOptimalFBOLocations(db *sqlx.DB, ranges SweepRanges, preferredSize *int, filter CandidateFilter, scorer Scorer) (string, error) {
+	analysis, err := AnalyseOptimalLocationSweep(db, ranges, preferredSize, filter, scorer)
+	if err != nil {
+		return "", err
+	}
+
+	return FormatSweep(analysis, filter, scorer), nil
+}
+
+// FormatSweep formats a sweep's candidates, most consistent first, and the top candidates under each setting
+func FormatSweep(analysis SweepAnalysis, filter CandidateFilter, scorer Scorer) string {
+	// Define color functions
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	ranges := analysis.Ranges
+	var optimal, maxes, lights []string
+	for _, distance := range ranges.OptimalDistances {
+		optimal = append(optimal, fmt.Sprintf("%.0f", distance))
+	}
+	for _, distance := range ranges.MaxDistances {
+		maxes = append(maxes, fmt.Sprintf("%.0f", distance))
+	}
+	for _, requireLights := range ranges.RequireLights {
+		if requireLights {
+			lights = append(lights, "required")
+		} else {
+			lights = append(lights, "not required")
+		}
+	}
+	result := fmt.Sprintf("%s %s %s nm, %s %s nm, %s %s\n",
+		bold("Sweeping:"),
+		bold("optimal distance:"), strings.Join(optimal, ", "),
+		bold("maximum distance:"), strings.Join(maxes, ", "),
+		bold("lights:"), strings.Join(lights, ", "))
+	if !filter.IsEmpty() {
+		result += fmt.Sprintf("%s %s\n", bold("Candidate filters:"), filter)
+	}
+	if scorer != nil && scorer.Name() != DefaultScorerName {
+		result += fmt.Sprintf("%s %s\n", bold("Scoring:"), scorer.Name())
+	}
+	result += fmt.Sprintf("%s %d settings, keeping the top %d of each. Candidates in the top %d of at least %.0f%% of settings are robust.\n",
+		bold("Ran:"), len(analysis.Settings), ranges.TopN, ranges.TopN, robustSweepShare*100)
+
+	if len(analysis.Candidates) == 0 {
+		result += fmt.Sprintf("\n%s\n", yellow("No candidates scored above zero under any setting."))
+		return result
+	}
+
+	result += fmt.Sprintf("\n%s\n", bold(cyan("Candidates, most consistent first:")))
+	result += bold(fmt.Sprintf("%-3s %-40s  %-7s  %9s  %6s  %10s", "#", "Airport", "Verdict", "In top N", "Share", "Rank range")) + "\n"
+	for i, candidate := range analysis.Candidates {
+		verdict := yellow(fmt.Sprintf("%-7s", "Fragile"))
+		if candidate.Robust {
+			verdict = green(fmt.Sprintf("%-7s", "Robust"))
+		}
+
+		result += fmt.Sprintf("%-3d %-40s  %s  %4d/%-4d  %5.0f%%  %10s\n",
+			i+1,
+			bold(candidate.Airport.Name)+" "+cyan("("+candidate.Airport.ICAO+")"),
+			verdict,
+			candidate.TopCount, len(analysis.Settings),
+			candidate.Share*100,
+			fmt.Sprintf("%d-%d", candidate.BestRank, candidate.WorstRank))
+	}
+
+	result += fmt.Sprintf("\n%s\n", bold(cyan("Top candidates by setting (optimal/max/lights):")))
+	for _, setting := range analysis.Settings {
+		top := strings.Join(setting.Top, ", ")
+		if top == "" {
+			top = "none"
+		}
+		result += fmt.Sprintf("  %-18s %s\n", setting.Label(), top)
+	}
+
+	return result
+}
+
+// AnalyseOptimalLocationSweep scores the candidates under every combination of the ranges. The max distance doesn't
+// change the scores, so under each max distance, candidates farther than it from every FBO are left out, since they
+// couldn't join the network.
+func AnalyseOptimalLocationSweep(db *sqlx.DB, ranges SweepRanges, preferredSize *int, filter CandidateFilter, scorer Scorer) (SweepAnalysis, error) {
+	if err := ranges.Validate(); err != nil {
+		return SweepAnalysis{}, err
+	}
+
+	var fbos []models.Airport
+	err := db.Select(&fbos, "SELECT * FROM airports WHERE has_fbo = TRUE AND latitude IS NOT NULL AND longitude IS NOT NULL")
+	if err != nil {
+		return SweepAnalysis{}, fmt.Errorf("error fetching FBOs: %w", err)
+	}
+	fboIndex := newAirportIndex(fbos)
+
+	analysis := SweepAnalysis{
+		Ranges:     ranges,
+		Settings:   []SweepSetting{},
+		Candidates: []SweepCandidate{},
+	}
+	candidates := make(map[string]*SweepCandidate)
+	nearestFBO := make(map[string]float64)
+
+	for _, optimalDistance := range ranges.OptimalDistances {
+		for _, requireLights := range ranges.RequireLights {
+			scored, err := ScoreOptimalFBOLocations(db, optimalDistance, requireLights, preferredSize, filter, scorer)
+			if err != nil {
+				return SweepAnalysis{}, err
+			}
+
+			for _, maxDistance := range ranges.MaxDistances {
+				setting := SweepSetting{
+					OptimalDistance: optimalDistance,
+					MaxDistance:     maxDistance,
+					RequireLights:   requireLights,
+					Top:             []string{},
+				}
+				settingIndex := len(analysis.Settings)
+
+				for _, score := range scored.Candidates {
+					if len(setting.Top) == ranges.TopN {
+						break
+					}
+
+					airport := score.Airport
+					nearest, ok := nearestFBO[airport.ICAO]
+					if !ok {
+						nearest = fboIndex.nearestDistance(*airport.Latitude, *airport.Longitude)
+						nearestFBO[airport.ICAO] = nearest
+					}
+					if nearest > maxDistance {
+						continue
+					}
+
+					setting.Top = append(setting.Top, airport.ICAO)
+					rank := len(setting.Top)
+
+					candidate, ok := candidates[airport.ICAO]
+					if !ok {
+						candidate = &SweepCandidate{Airport: airport, BestRank: math.MaxInt}
+						candidates[airport.ICAO] = candidate
+					}
+					for len(candidate.Ranks) < settingIndex {
+						candidate.Ranks = append(candidate.Ranks, 0)
+					}
+					candidate.Ranks = append(candidate.Ranks, rank)
+					candidate.TopCount++
+					candidate.BestRank = min(candidate.BestRank, rank)
+					candidate.WorstRank = max(candidate.WorstRank, rank)
+				}
+
+				analysis.Settings = append(analysis.Settings, setting)
+			}
+		}
+	}
+
+	for _, candidate := range candidates {
+		for len(candidate.Ranks) < len(analysis.Settings) {
+			candidate.Ranks = append(candidate.Ranks, 0)
+		}
+		candidate.Share = float64(candidate.TopCount) / float64(len(analysis.Settings))
+		candidate.Robust = candidate.Share >= robustSweepShare
+		analysis.Candidates = append(analysis.Candidates, *candidate)
+	}
+
+	sort.Slice(analysis.Candidates, func(i, j int) bool {
+		a, b := analysis.Candidates[i], analysis.Candidates[j]
+		if a.TopCount != b.TopCount {
+			return a.TopCount > b.TopCount
+		}
+		if a.BestRank != b.BestRank {
+			return a.BestRank < b.BestRank
+		}
+		return a.Airport.ICAO < b.Airport.ICAO
+	})
+
+	return analysis, nil
+}
@@ -0,0 +1,280 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	database "github.com/julietrb1/offair-cli/db"
+	"github.com/julietrb1/offair-cli/export"
+	"github.com/julietrb1/offair-cli/fbo"
+	"github.com/julietrb1/offair-cli/models"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// FieldConflict is a field that differs between the local airport and the imported one
+type FieldConflict struct {
+	ICAO   string
+	Field  string
+	Mine   string
+	Theirs string
+}
+
+// ConflictResolver decides a field conflict, returning true to take their value
+type ConflictResolver func(conflict FieldConflict) bool
+
+// KeepMine resolves every conflict in favour of the local value
+func KeepMine(FieldConflict) bool { return false }
+
+// TakeTheirs resolves every conflict in favour of the imported value
+func TakeTheirs(FieldConflict) bool { return true }
+
+// DatabaseMergePlan describes how an imported database export differs from the local database
+type DatabaseMergePlan struct {
+	// NewAirports are airports not in the local database
+	NewAirports []models.Airport
+	// Filled are fields blank locally that the import fills in, by ICAO
+	Filled map[string][]string
+	// Conflicts are fields set in both with different values
+	Conflicts []FieldConflict
+	// NewFBOs are imported FBOs at airports without a local FBO
+	NewFBOs []models.FBO
+	// Unchanged is the number of imported airports identical to the local ones
+	Unchanged int
+
+	theirs map[string]models.Airport
+	mine   map[string]models.Airport
+}
+
+// DatabaseMergeResult summarises an applied merge
+type DatabaseMergeResult struct {
+	Added        int
+	Updated      int
+	TookTheirs   int
+	KeptMine     int
+	FBOsAdded    []string
+	FBOsNotAdded map[string]error
+}
+
+// airportField describes a curated airport field that can be compared and merged
+type airportField struct {
+	name string
+	get  func(a models.Airport) string
+	set  func(dst *models.Airport, src models.Airport)
+}
+
+// mergeableAirportFields are the airport fields compared when merging. has_fbo is deliberately
+// excluded, as which airports have FBOs is specific to each person's company.
+var mergeableAirportFields = []airportField{
+	{"name", func(a models.Airport) string { return a.Name }, func(d *models.Airport, s models.Airport) { d.Name = s.Name }},
+	{"country_code", func(a models.Airport) string { return a.CountryCode }, func(d *models.Airport, s models.Airport) { d.CountryCode = s.CountryCode }},
+	{"iata", func(a models.Airport) string { return formatString(a.IATA) }, func(d *models.Airport, s models.Airport) { d.IATA = s.IATA }},
+	{"state", func(a models.Airport) string { return formatString(a.State) }, func(d *models.Airport, s models.Airport) { d.State = s.State }},
+	{"country_name", func(a models.Airport) string { return formatString(a.CountryName) }, func(d *models.Airport, s models.Airport) { d.CountryName = s.CountryName }},
+	{"city", func(a models.Airport) string { return formatString(a.City) }, func(d *models.Airport, s models.Airport) { d.City = s.City }},
+	{"airport_type", func(a models.Airport) string { return formatString(a.AirportType) }, func(d *models.Airport, s models.Airport) { d.AirportType = s.AirportType }},
+	{"latitude", func(a models.Airport) string { return formatFloat(a.Latitude) }, func(d *models.Airport, s models.Airport) { d.Latitude = s.Latitude }},
+	{"longitude", func(a models.Airport) string { return formatFloat(a.Longitude) }, func(d *models.Airport, s models.Airport) { d.Longitude = s.Longitude }},
+	{"elevation", func(a models.Airport) string { return formatFloat(a.Elevation) }, func(d *models.Airport, s models.Airport) { d.Elevation = s.Elevation }},
+	{"size", func(a models.Airport) string { return formatInt(a.Size) }, func(d *models.Airport, s models.Airport) { d.Size = s.Size }},
+	{"map_surface_type", func(a models.Airport) string { return formatInt(a.MapSurfaceType) }, func(d *models.Airport, s models.Airport) { d.MapSurfaceType = s.MapSurfaceType }},
+	{"display_name", func(a models.Airport) string { return formatString(a.DisplayName) }, func(d *models.Airport, s models.Airport) { d.DisplayName = s.DisplayName }},
+	{"is_military", func(a models.Airport) string { return strconv.FormatBool(a.IsMilitary) }, func(d *models.Airport, s models.Airport) { d.IsMilitary = s.IsMilitary }},
+	{"has_lights", func(a models.Airport) string { return strconv.FormatBool(a.HasLights) }, func(d *models.Airport, s models.Airport) { d.HasLights = s.HasLights }},
+	{"is_basecamp", func(a models.Airport) string { return strconv.FormatBool(a.IsBasecamp) }, func(d *models.Airport, s models.Airport) { d.IsBasecamp = s.IsBasecamp }},
+	{"is_in_simbrief", func(a models.Airport) string { return strconv.FormatBool(a.IsInSimbrief) }, func(d *models.Airport, s models.Airport) { d.IsInSimbrief = s.IsInSimbrief }},
+}
+
+// ReadDatabaseExport reads a database export, rejecting versions newer than this build understands
+func ReadDatabaseExport(r io.Reader) (export.DatabaseExport, error) {
+	var data export.DatabaseExport
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return export.DatabaseExport{}, fmt.Errorf("error reading database export: %w", err)
+	}
+
+	if data.Version < 1 || data.Version > export.DatabaseExportVersion {
+		return export.DatabaseExport{}, fmt.Errorf("unsupported database export version %d (this build supports up to %d)",
+			data.Version, export.DatabaseExportVersion)
+	}
+
+	return data, nil
+}
+
+// PlanDatabaseMerge compares an imported database export against the local database, without changing anything
+func PlanDatabaseMerge(db *sqlx.DB, theirs export.DatabaseExport) (DatabaseMergePlan, error) {
+	plan := DatabaseMergePlan{
+		Filled: make(map[string][]string),
+		theirs: make(map[string]models.Airport),
+		mine:   make(map[string]models.Airport),
+	}
+
+	var localAirports []models.Airport
+	if err := db.Select(&localAirports, "SELECT * FROM airports"); err != nil {
+		return DatabaseMergePlan{}, fmt.Errorf("error fetching airports: %w", err)
+	}
+	for _, airport := range localAirports {
+		plan.mine[airport.ICAO] = airport
+	}
+
+	for _, airport := range theirs.Airports {
+		plan.theirs[airport.ICAO] = airport
+
+		mine, exists := plan.mine[airport.ICAO]
+		if !exists {
+			plan.NewAirports = append(plan.NewAirports, airport)
+			continue
+		}
+
+		changed := false
+		for _, field := range mergeableAirportFields {
+			mineValue := field.get(mine)
+			theirValue := field.get(airport)
+			if mineValue == theirValue || theirValue == "" {
+				continue
+			}
+
+			changed = true
+			if mineValue == "" {
+				plan.Filled[airport.ICAO] = append(plan.Filled[airport.ICAO], field.name)
+				continue
+			}
+
+			plan.Conflicts = append(plan.Conflicts, FieldConflict{
+				ICAO:   airport.ICAO,
+				Field:  field.name,
+				Mine:   mineValue,
+				Theirs: theirValue,
+			})
+		}
+
+		if !changed {
+			plan.Unchanged++
+		}
+	}
+
+	for _, theirFBO := range theirs.FBOs {
+		if mine, exists := plan.mine[theirFBO.ICAO]; exists && mine.HasFBO {
+			continue
+		}
+		plan.NewFBOs = append(plan.NewFBOs, theirFBO)
+	}
+
+	sort.SliceStable(plan.Conflicts, func(i, j int) bool {
+		return plan.Conflicts[i].ICAO < plan.Conflicts[j].ICAO
+	})
+
+	return plan, nil
+}
+
+// ApplyDatabaseMerge applies a merge plan to the local database. Blank local fields are always filled in,
+// conflicts are decided by resolve, and their FBOs are only added if includeFBOs is set.
+func ApplyDatabaseMerge(db *sqlx.DB, plan DatabaseMergePlan, resolve ConflictResolver, includeFBOs bool) (DatabaseMergeResult, error) {
+	result := DatabaseMergeResult{FBOsNotAdded: make(map[string]error)}
+
+	// Decide which fields to take from their airports
+	takeFields := make(map[string]map[string]bool)
+	for icao, fields := range plan.Filled {
+		takeFields[icao] = make(map[string]bool)
+		for _, field := range fields {
+			takeFields[icao][field] = true
+		}
+	}
+	for _, conflict := range plan.Conflicts {
+		if !resolve(conflict) {
+			result.KeptMine++
+			continue
+		}
+
+		result.TookTheirs++
+		if takeFields[conflict.ICAO] == nil {
+			takeFields[conflict.ICAO] = make(map[string]bool)
+		}
+		takeFields[conflict.ICAO][conflict.Field] = true
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return result, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, airport := range plan.NewAirports {
+		airport.HasFBO = false
+		err = database.InsertAirport(tx, airport)
+		if err != nil {
+			return result, fmt.Errorf("error inserting airport %s: %w", airport.ICAO, err)
+		}
+		result.Added++
+	}
+
+	icaos := make([]string, 0, len(takeFields))
+	for icao := range takeFields {
+		icaos = append(icaos, icao)
+	}
+	sort.Strings(icaos)
+
+	for _, icao := range icaos {
+		merged := plan.mine[icao]
+		for _, field := range mergeableAirportFields {
+			if takeFields[icao][field.name] {
+				field.set(&merged, plan.theirs[icao])
+			}
+		}
+
+		_, err = tx.NamedExec(`
+			UPDATE airports SET
+				name = :name, country_code = :country_code, iata = :iata, state = :state,
+				country_name = :country_name, city = :city, latitude = :latitude,
+				longitude = :longitude, elevation = :elevation, size = :size,
+				is_military = :is_military, has_lights = :has_lights, is_basecamp = :is_basecamp,
+				map_surface_type = :map_surface_type, is_in_simbrief = :is_in_simbrief,
+				display_name = :display_name, airport_type = :airport_type
+			WHERE id = :id
+		`, merged)
+		if err != nil {
+			return result, fmt.Errorf("error updating airport %s: %w", icao, err)
+		}
+		result.Updated++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("error committing merge: %w", err)
+	}
+
+	if includeFBOs {
+		for _, theirFBO := range plan.NewFBOs {
+			if err := fbo.AddFBO(db, theirFBO.ICAO); err != nil {
+				result.FBOsNotAdded[theirFBO.ICAO] = err
+				continue
+			}
+			result.FBOsAdded = append(result.FBOsAdded, theirFBO.ICAO)
+		}
+	}
+
+	return result, nil
+}
+
+// formatString formats an optional string for comparison, with blank meaning not set
+func formatString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// formatFloat formats an optional float for comparison, ignoring noise beyond 6 decimal places
+func formatFloat(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', 6, 64)
+}
+
+// formatInt formats an optional int for comparison
+func formatInt(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.Itoa(*value)
+}
@@ -0,0 +1,81 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// FormatFromPath infers the import format from a file's extension, defaulting to CSV
+func FormatFromPath(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return FormatJSON
+	}
+	return FormatCSV
+}
+
+// ParseICAOList reads a list of ICAOs in the given format.
+// CSV files use the first column of each row, with an optional "icao" header row.
+// JSON files are either an array of ICAO strings or an array of objects with an "icao" field.
+// ICAOs are upper-cased and blank entries are skipped.
+func ParseICAOList(r io.Reader, format string) ([]string, error) {
+	var raw []string
+
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.Comment = '#'
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("error reading CSV: %w", err)
+		}
+
+		for i, record := range records {
+			if len(record) == 0 {
+				continue
+			}
+			if i == 0 && strings.EqualFold(strings.TrimSpace(record[0]), "icao") {
+				continue
+			}
+			raw = append(raw, record[0])
+		}
+	case FormatJSON:
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("error reading JSON: %w", err)
+		}
+
+		if err := json.Unmarshal(data, &raw); err != nil {
+			var objects []struct {
+				ICAO string `json:"icao"`
+			}
+			if objErr := json.Unmarshal(data, &objects); objErr != nil {
+				return nil, fmt.Errorf("JSON must be an array of ICAOs or of objects with an \"icao\" field: %w", err)
+			}
+			for _, object := range objects {
+				raw = append(raw, object.ICAO)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported import format %q", format)
+	}
+
+	var icaos []string
+	for _, icao := range raw {
+		icao = strings.ToUpper(strings.TrimSpace(icao))
+		if icao != "" {
+			icaos = append(icaos, icao)
+		}
+	}
+
+	return icaos, nil
+}
@@ -0,0 +1,126 @@
+package importer
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	database "github.com/julietrb1/offair-cli/db"
+	"github.com/julietrb1/offair-cli/fbo"
+	"github.com/julietrb1/offair-cli/models"
+	"github.com/julietrb1/offair-cli/models/onair"
+	"github.com/julietrb1/onair-api-go-client/api"
+)
+
+// PlannedFBOPreview describes what importing a list of ICAOs would change
+type PlannedFBOPreview struct {
+	// Known are airports in the local database that don't have an FBO yet
+	Known []models.Airport
+	// ToFetch are ICAOs not in the local database, which will be fetched from OnAir
+	ToFetch []string
+	// AlreadyFBOs are ICAOs that already have an FBO
+	AlreadyFBOs []string
+	// Duplicates are ICAOs listed more than once
+	Duplicates []string
+}
+
+// PreviewPlannedFBOs works out what importing a list of ICAOs would change, without changing anything
+func PreviewPlannedFBOs(db *sqlx.DB, icaos []string) (PlannedFBOPreview, error) {
+	var preview PlannedFBOPreview
+	seen := make(map[string]bool)
+
+	for _, icao := range icaos {
+		if seen[icao] {
+			preview.Duplicates = append(preview.Duplicates, icao)
+			continue
+		}
+		seen[icao] = true
+
+		var airport models.Airport
+		err := db.Get(&airport, "SELECT * FROM airports WHERE icao = ?", icao)
+		if errors.Is(err, sql.ErrNoRows) {
+			preview.ToFetch = append(preview.ToFetch, icao)
+			continue
+		} else if err != nil {
+			return PlannedFBOPreview{}, fmt.Errorf("error fetching airport %s: %w", icao, err)
+		}
+
+		if airport.HasFBO {
+			preview.AlreadyFBOs = append(preview.AlreadyFBOs, icao)
+			continue
+		}
+
+		preview.Known = append(preview.Known, airport)
+	}
+
+	return preview, nil
+}
+
+// ResolvePlannedFBOs fetches the preview's unknown airports from OnAir and stores them in the local database.
+// Returns every airport that can be planned, plus the ICAOs that couldn't be resolved and why.
+func ResolvePlannedFBOs(db *sqlx.DB, preview PlannedFBOPreview) ([]models.Airport, map[string]error) {
+	resolved := make([]models.Airport, len(preview.Known))
+	copy(resolved, preview.Known)
+	unresolved := make(map[string]error)
+
+	if len(preview.ToFetch) == 0 {
+		return resolved, unresolved
+	}
+
+	onairAPI, err := api.NewOnAirAPI()
+	if err != nil {
+		for _, icao := range preview.ToFetch {
+			unresolved[icao] = fmt.Errorf("error initializing API client: %w", err)
+		}
+		return resolved, unresolved
+	}
+
+	for _, icao := range preview.ToFetch {
+		airport, err := fetchAirport(db, onairAPI, icao)
+		if err != nil {
+			unresolved[icao] = err
+			continue
+		}
+		resolved = append(resolved, airport)
+	}
+
+	return resolved, unresolved
+}
+
+// AddPlannedFBOs adds an FBO at each airport, returning the ICAOs added and any that failed and why
+func AddPlannedFBOs(db *sqlx.DB, airports []models.Airport) ([]string, map[string]error) {
+	var added []string
+	failed := make(map[string]error)
+
+	for _, airport := range airports {
+		if err := fbo.AddFBO(db, airport.ICAO); err != nil {
+			failed[airport.ICAO] = err
+			continue
+		}
+		added = append(added, airport.ICAO)
+	}
+
+	return added, failed
+}
+
+// fetchAirport fetches an airport from OnAir and stores it in the local database
+func fetchAirport(db *sqlx.DB, onairAPI *api.OnAirAPI, icao string) (models.Airport, error) {
+	apiAirport, err := onairAPI.GetAirport(icao)
+	if err != nil {
+		return models.Airport{}, fmt.Errorf("error fetching airport from API: %w", err)
+	}
+
+	dbAirport := onair.AdaptAirportToDBModel(*apiAirport)
+
+	// Infer "AU" for Australian ICAOs, as the airport lookup does
+	if dbAirport.CountryCode == "" && icao[0] == 'Y' {
+		dbAirport.CountryCode = "AU"
+	}
+
+	err = database.InsertAirport(db, dbAirport)
+	if err != nil {
+		return models.Airport{}, fmt.Errorf("error inserting airport into database: %w", err)
+	}
+
+	return dbAirport, nil
+}
@@ -1,19 +1,31 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
 	"github.com/joho/godotenv"
+	"github.com/julietrb1/offair-cli/config"
 	"github.com/julietrb1/offair-cli/db"
+	"github.com/julietrb1/offair-cli/fbo"
 	"github.com/julietrb1/offair-cli/menu"
+	"github.com/julietrb1/offair-cli/server"
 )
 
 func main() {
 	// Load environment variables from .env file, ignoring any errors
 	_ = godotenv.Load()
 
+	// Load the config file, whose settings apply unless already set by the environment or .env file
+	if err := config.LoadFile(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
 	// Initialize database
 	database, err := db.InitDB()
 	if err != nil {
@@ -21,6 +33,14 @@ func main() {
 	}
 	defer database.Close()
 
+	// Run a subcommand if one was given, otherwise show the interactive menus
+	if len(os.Args) > 1 {
+		if err := runCommand(database, os.Args[1], os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// Create a bold cyan color function
 	boldCyan := color.New(color.FgCyan, color.Bold).SprintFunc()
 
@@ -28,3 +48,44 @@ func main() {
 	fmt.Println(boldCyan("Welcome to OffAir, the OnAir companion CLI!"))
 	menu.MainMenu(database)
 }
+
+// runCommand runs a non-interactive subcommand
+func runCommand(database *sqlx.DB, command string, args []string) error {
+	switch command {
+	case "serve":
+		flags := flag.NewFlagSet("serve", flag.ExitOnError)
+		addr := flags.String("addr", server.DefaultAddr, "address to listen on")
+		flags.Parse(args)
+
+		log.Printf("Serving the OffAir API on http://%s", *addr)
+		return server.ListenAndServe(database, *addr)
+	case "pins":
+		result, err := fbo.ListPinnedAirports(database)
+		if err != nil {
+			return err
+		}
+		fmt.Print(result)
+		return nil
+	case "pin", "unpin", "exclude", "include":
+		if len(args) == 0 {
+			return fmt.Errorf("usage: %s ICAO [ICAO...]", command)
+		}
+		done := map[string]string{"pin": "pinned", "unpin": "unpinned", "exclude": "excluded", "include": "included"}
+		for _, icao := range args {
+			var err error
+			switch command {
+			case "pin", "unpin":
+				err = fbo.SetAirportPinned(database, icao, command == "pin")
+			default:
+				err = fbo.SetAirportExcluded(database, icao, command == "exclude")
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s %s\n", strings.ToUpper(icao), done[command])
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q (available commands: serve, pins, pin, unpin, exclude, include)", command)
+	}
+}
@@ -129,9 +129,10 @@ func AddFBO(db *sqlx.DB) {
 		if err != nil {
 			fmt.Printf("%s %v\n", color.RedString("Error:"), err)
 		} else {
-			fmt.Printf("%s %s %s\n",
+			fmt.Printf("%s %s\n",
 				color.GreenString("FBO added at"),
 				bold(icao))
+			recordNetworkSnapshot(db, "added FBO at "+icao)
 		}
 		fmt.Println()
 	}
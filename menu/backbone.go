@@ -0,0 +1,22 @@
+package menu
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/config"
+	"github.com/julietrb1/offair-cli/fbo"
+)
+
+// FindNetworkBackbone shows the shortest set of legs connecting every FBO and where it exceeds the max distance
+func FindNetworkBackbone(db *sqlx.DB) {
+	cfg := config.Load()
+
+	result, err := fbo.FindNetworkBackbone(db, cfg.MaxDistance)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	fmt.Println(result)
+}
@@ -0,0 +1,120 @@
+package menu
+
+import (
+	"fmt"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/julietrb1/offair-cli/config"
+	"github.com/julietrb1/offair-cli/fbo"
+	"strconv"
+	"strings"
+)
+
+// promptForCandidateFilter shows the configured candidate filters and lets the user change them for this run
+// Returns false if the user entered a filter that couldn't be parsed
+func promptForCandidateFilter(filter fbo.CandidateFilter) (fbo.CandidateFilter, bool) {
+	change := false
+	changePrompt := &survey.Confirm{
+		Message: fmt.Sprintf("Candidate filters: %s. Change them for this run?", filter),
+		Default: false,
+	}
+	survey.AskOne(changePrompt, &change)
+
+	if !change {
+		return filter, true
+	}
+
+	values := candidateFilterValues(filter)
+	for _, field := range []struct {
+		name    string
+		message string
+	}{
+		{"types", "Airport types (AD, ALA, comma-separated, blank for any):"},
+		{"countries", "Country codes (comma-separated, blank for any):"},
+		{"states", "States (comma-separated, blank for any):"},
+		{"min_size", "Minimum size (0-5, blank for any):"},
+		{"max_size", "Maximum size (0-5, blank for any):"},
+		{"surfaces", "Map surface types (comma-separated numbers, blank for any):"},
+		{"max_elevation", "Maximum elevation in ft (blank for any):"},
+		{"exclude", "ICAOs to exclude (comma-separated):"},
+	} {
+		prompt := &survey.Input{
+			Message: field.message,
+			Default: values[field.name],
+		}
+		var value string
+		survey.AskOne(prompt, &value)
+		values[field.name] = value
+	}
+
+	var military string
+	militaryPrompt := &survey.Select{
+		Message: "Military airports:",
+		Options: []string{
+			AnyMilitaryMenuLabel,
+			NoMilitaryMenuLabel,
+			MilitaryOnlyMenuLabel,
+		},
+		Default: militaryMenuLabel(filter.Military),
+	}
+	survey.AskOne(militaryPrompt, &military)
+
+	switch military {
+	case NoMilitaryMenuLabel:
+		values["military"] = "false"
+	case MilitaryOnlyMenuLabel:
+		values["military"] = "true"
+	default:
+		values["military"] = ""
+	}
+
+	newFilter, err := config.ParseCandidateFilter(func(name string) string {
+		return values[name]
+	})
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return filter, false
+	}
+
+	return newFilter, true
+}
+
+// candidateFilterValues formats a candidate filter as the text settings ParseCandidateFilter reads
+func candidateFilterValues(filter fbo.CandidateFilter) map[string]string {
+	values := map[string]string{
+		"types":     strings.Join(filter.AirportTypes, ", "),
+		"countries": strings.Join(filter.CountryCodes, ", "),
+		"states":    strings.Join(filter.States, ", "),
+		"exclude":   strings.Join(filter.ExcludeICAOs, ", "),
+	}
+
+	if filter.MinSize != nil {
+		values["min_size"] = strconv.Itoa(*filter.MinSize)
+	}
+	if filter.MaxSize != nil {
+		values["max_size"] = strconv.Itoa(*filter.MaxSize)
+	}
+
+	var surfaceTypes []string
+	for _, surfaceType := range filter.SurfaceTypes {
+		surfaceTypes = append(surfaceTypes, strconv.Itoa(surfaceType))
+	}
+	values["surfaces"] = strings.Join(surfaceTypes, ", ")
+
+	if filter.MaxElevation != nil {
+		values["max_elevation"] = strconv.FormatFloat(*filter.MaxElevation, 'f', -1, 64)
+	}
+
+	return values
+}
+
+// militaryMenuLabel returns the menu label for a military filter setting
+func militaryMenuLabel(military *bool) string {
+	if military == nil {
+		return AnyMilitaryMenuLabel
+	}
+	if *military {
+		return MilitaryOnlyMenuLabel
+	}
+	return NoMilitaryMenuLabel
+}
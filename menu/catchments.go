@@ -0,0 +1,22 @@
+package menu
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/config"
+	"github.com/julietrb1/offair-cli/fbo"
+)
+
+// FindFBOCatchments shows how many airports are nearest to each FBO, and which airports no FBO reaches
+func FindFBOCatchments(db *sqlx.DB) {
+	cfg := config.Load()
+
+	result, err := fbo.FindFBOCatchments(db, cfg.MaxDistance)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	fmt.Println(result)
+}
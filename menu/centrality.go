@@ -0,0 +1,22 @@
+package menu
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/config"
+	"github.com/julietrb1/offair-cli/fbo"
+)
+
+// RankFBOCentrality shows which FBOs are hubs in the network of legs within the max distance
+func RankFBOCentrality(db *sqlx.DB) {
+	cfg := config.Load()
+
+	result, err := fbo.RankFBOCentrality(db, cfg.MaxDistance)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	fmt.Println(result)
+}
@@ -0,0 +1,52 @@
+package menu
+
+import (
+	"fmt"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/config"
+	"github.com/julietrb1/offair-cli/fbo"
+	"strconv"
+	"strings"
+)
+
+// SearchRouteCorridor lists the airports and FBOs along the route between two airports
+func SearchRouteCorridor(db *sqlx.DB) {
+	cfg := config.Load()
+
+	var fromICAO string
+	survey.AskOne(&survey.Input{
+		Message: "Enter origin ICAO (blank to go back):",
+	}, &fromICAO)
+	if fromICAO == "" {
+		return
+	}
+
+	var toICAO string
+	survey.AskOne(&survey.Input{
+		Message: "Enter destination ICAO (blank to go back):",
+	}, &toICAO)
+	if toICAO == "" {
+		return
+	}
+
+	var widthStr string
+	survey.AskOne(&survey.Input{
+		Message: "Corridor width either side of the route (nm):",
+		Default: strconv.FormatFloat(cfg.CorridorWidth, 'f', -1, 64),
+	}, &widthStr)
+	width, err := strconv.ParseFloat(strings.TrimSpace(widthStr), 64)
+	if err != nil || width <= 0 {
+		fmt.Printf("%s %s\n", color.RedString("Error:"), "enter a width greater than zero")
+		return
+	}
+
+	result, err := fbo.FindCorridor(db, fromICAO, toICAO, width, cfg.MaxDistance)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	fmt.Println(result)
+}
@@ -0,0 +1,33 @@
+package menu
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/config"
+	"github.com/julietrb1/offair-cli/fbo"
+)
+
+// FindCoverageGaps shows the largest regions inside the network without an FBO in reach, with an airport to fill each
+func FindCoverageGaps(db *sqlx.DB) {
+	cfg := config.Load()
+
+	scorer, err := cfg.NewScorer()
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	filter, ok := promptForCandidateFilter(cfg.CandidateFilter)
+	if !ok {
+		return
+	}
+
+	result, err := fbo.FindCoverageGaps(db, cfg.CoverageRadius, 10, cfg.OptimalDistance, cfg.RequireLights, cfg.PreferredSize, filter, scorer)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	fmt.Println(result)
+}
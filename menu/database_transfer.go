@@ -0,0 +1,188 @@
+package menu
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/export"
+	"github.com/julietrb1/offair-cli/importer"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ExportDatabase writes all airports and FBOs to a versioned JSON file for sharing
+func ExportDatabase(db *sqlx.DB) {
+	bold := color.New(color.Bold).SprintFunc()
+
+	data, err := export.BuildDatabaseExport(db)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	path := promptForExportPath("offair_export.json")
+	if path == "" {
+		return
+	}
+
+	// Build the export before writing the file, so an error doesn't leave a truncated file behind
+	var contents bytes.Buffer
+	if err := export.WriteDatabaseExport(&contents, data); err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	if err := os.WriteFile(path, contents.Bytes(), 0644); err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error creating file:"), err)
+		return
+	}
+
+	fmt.Printf("%s %d %s %d %s %s\n\n",
+		color.GreenString("Exported"),
+		len(data.Airports),
+		color.GreenString("airports and"),
+		len(data.FBOs),
+		color.GreenString("FBOs to"),
+		bold(path))
+}
+
+// ImportDatabase merges another OffAir user's database export into the local database
+func ImportDatabase(db *sqlx.DB) {
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	var path string
+	survey.AskOne(&survey.Input{
+		Message: "Enter OffAir database export to import (blank to go back):",
+	}, &path)
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error opening file:"), err)
+		return
+	}
+	data, err := importer.ReadDatabaseExport(file)
+	file.Close()
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	plan, err := importer.PlanDatabaseMerge(db, data)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	// Summarise the differences
+	fmt.Printf("\n%s %s (exported %s)\n",
+		bold("Import from"),
+		bold(path),
+		data.ExportedAt.Local().Format("2006-01-02 15:04"))
+	fmt.Printf("  • %s: %d\n", bold("New airports"), len(plan.NewAirports))
+	fmt.Printf("  • %s: %d\n", bold("Airports with blank fields filled in"), len(plan.Filled))
+	fmt.Printf("  • %s: %d\n", bold("Conflicting fields"), len(plan.Conflicts))
+	fmt.Printf("  • %s: %d\n", bold("Unchanged airports"), plan.Unchanged)
+	fmt.Printf("  • %s: %d\n\n", bold("FBOs at airports where you have none"), len(plan.NewFBOs))
+
+	if len(plan.Conflicts) > 0 {
+		fmt.Printf("%s\n", bold(yellow("Conflicts:")))
+		for _, conflict := range plan.Conflicts {
+			fmt.Printf("  %s %-16s mine: %s  theirs: %s\n",
+				bold(conflict.ICAO),
+				conflict.Field,
+				cyan(conflict.Mine),
+				yellow(conflict.Theirs))
+		}
+		fmt.Println()
+	}
+
+	if len(plan.NewAirports) == 0 && len(plan.Filled) == 0 && len(plan.Conflicts) == 0 && len(plan.NewFBOs) == 0 {
+		fmt.Println(color.GreenString("Nothing to import, your database already matches."))
+		return
+	}
+
+	resolve := importer.KeepMine
+	if len(plan.Conflicts) > 0 {
+		var strategy string
+		survey.AskOne(&survey.Select{
+			Message: "Resolve conflicts by:",
+			Options: []string{
+				KeepMineMenuLabel,
+				TakeTheirsMenuLabel,
+				PromptForEachMenuLabel,
+				CancelMenuLabel,
+			},
+		}, &strategy)
+
+		switch strategy {
+		case KeepMineMenuLabel:
+			resolve = importer.KeepMine
+		case TakeTheirsMenuLabel:
+			resolve = importer.TakeTheirs
+		case PromptForEachMenuLabel:
+			resolve = promptForConflict
+		default:
+			return
+		}
+	} else {
+		proceed := false
+		survey.AskOne(&survey.Confirm{
+			Message: "Import these changes?",
+			Default: true,
+		}, &proceed)
+		if !proceed {
+			return
+		}
+	}
+
+	includeFBOs := false
+	if len(plan.NewFBOs) > 0 {
+		survey.AskOne(&survey.Confirm{
+			Message: fmt.Sprintf("Also add their %d FBOs to your network?", len(plan.NewFBOs)),
+		}, &includeFBOs)
+	}
+
+	result, err := importer.ApplyDatabaseMerge(db, plan, resolve, includeFBOs)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	fmt.Printf("%s Database merged.\n", color.GreenString("Success:"))
+	fmt.Printf("  Airports added: %d\n", result.Added)
+	fmt.Printf("  Airports updated: %d\n", result.Updated)
+	fmt.Printf("  Conflicts taken from theirs: %d\n", result.TookTheirs)
+	fmt.Printf("  Conflicts kept as mine: %d\n", result.KeptMine)
+	if includeFBOs {
+		sort.Strings(result.FBOsAdded)
+		fmt.Printf("  FBOs added: %d\n", len(result.FBOsAdded))
+		printUnresolvedICAOs(result.FBOsNotAdded)
+		if len(result.FBOsAdded) > 0 {
+			recordNetworkSnapshot(db, "merged FBOs from an imported database")
+		}
+	}
+	fmt.Println()
+}
+
+// promptForConflict asks the user whether to keep their value or take the imported one
+func promptForConflict(conflict importer.FieldConflict) bool {
+	mineOption := fmt.Sprintf("Keep mine: %s", conflict.Mine)
+	theirsOption := fmt.Sprintf("Take theirs: %s", conflict.Theirs)
+
+	var choice string
+	survey.AskOne(&survey.Select{
+		Message: fmt.Sprintf("%s %s:", conflict.ICAO, conflict.Field),
+		Options: []string{mineOption, theirsOption},
+	}, &choice)
+
+	return choice == theirsOption
+}
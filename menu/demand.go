@@ -0,0 +1,70 @@
+package menu
+
+import (
+	"fmt"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/config"
+	"github.com/julietrb1/offair-cli/fbo"
+	"github.com/julietrb1/offair-cli/models"
+	"strconv"
+	"strings"
+)
+
+// ListAirportDemand shows the airports with the most demand and how much of it the FBO network serves
+func ListAirportDemand(db *sqlx.DB) {
+	cfg := config.Load()
+
+	result, err := fbo.ListAirportDemand(db, cfg.DemandWeights(), cfg.OptimalDistance, 15)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	fmt.Println(result)
+}
+
+// ModifyDemandWeight allows the user to assign an airport's demand weight, or clear it to use the derived weight
+func ModifyDemandWeight(db *sqlx.DB, airport *models.Airport) {
+	bold := color.New(color.Bold).SprintFunc()
+
+	derived := config.Load().DemandWeights().Derive(*airport)
+	defaultWeight := ""
+	if airport.DemandWeight != nil {
+		defaultWeight = strconv.FormatFloat(*airport.DemandWeight, 'f', -1, 64)
+	}
+
+	var weightStr string
+	prompt := &survey.Input{
+		Message: fmt.Sprintf("Enter demand weight (blank to use the derived weight of %.1f):", derived),
+		Default: defaultWeight,
+	}
+	survey.AskOne(prompt, &weightStr)
+
+	var weight *float64
+	if weightStr = strings.TrimSpace(weightStr); weightStr != "" {
+		parsed, err := strconv.ParseFloat(weightStr, 64)
+		if err != nil || parsed < 0 {
+			fmt.Printf("%s %s\n", color.RedString("Error:"), "demand weight must be a number of 0 or more")
+			return
+		}
+		weight = &parsed
+	}
+
+	if err := fbo.SetDemandWeight(db, airport.ICAO, weight); err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error updating airport:"), err)
+		return
+	}
+	airport.DemandWeight = weight
+
+	if weight == nil {
+		fmt.Printf("%s %s\n",
+			color.GreenString("Demand weight cleared. Using the derived weight of"),
+			bold(fmt.Sprintf("%.1f", derived)))
+	} else {
+		fmt.Printf("%s %s\n",
+			color.GreenString("Demand weight updated to"),
+			bold(weightStr))
+	}
+}
@@ -0,0 +1,260 @@
+package menu
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/config"
+	"github.com/julietrb1/offair-cli/export"
+	"github.com/julietrb1/offair-cli/fbo"
+	"github.com/julietrb1/offair-cli/models"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ImportExportMenu displays the import and export menu and handles user selection
+func ImportExportMenu(db *sqlx.DB) {
+	for {
+		var option string
+		prompt := &survey.Select{
+			Message: "Import & Export:",
+			Options: []string{
+				ImportPlannedFBOsMenuLabel,
+				ImportDatabaseMenuLabel,
+				ExportDatabaseMenuLabel,
+				ExportLittleNavmapMenuLabel,
+				ExportMSFSDirectMenuLabel,
+				ExportMSFSViaFBOsMenuLabel,
+				ExportDOTMenuLabel,
+				ExportNetworkHistoryMenuLabel,
+				BackToMainMenuLabel,
+			},
+		}
+		survey.AskOne(prompt, &option)
+
+		switch option {
+		case ImportPlannedFBOsMenuLabel:
+			ImportPlannedFBOs(db)
+		case ImportDatabaseMenuLabel:
+			ImportDatabase(db)
+		case ExportDatabaseMenuLabel:
+			ExportDatabase(db)
+		case ExportLittleNavmapMenuLabel:
+			ExportLittleNavmapUserpoints(db)
+		case ExportMSFSDirectMenuLabel:
+			ExportMSFSFlightPlan(db, false)
+		case ExportMSFSViaFBOsMenuLabel:
+			ExportMSFSFlightPlan(db, true)
+		case ExportDOTMenuLabel:
+			ExportFBONetworkDOT(db)
+		case ExportNetworkHistoryMenuLabel:
+			ExportNetworkHistory(db)
+		case BackToMainMenuLabel:
+			return
+		}
+	}
+}
+
+// ExportLittleNavmapUserpoints writes all FBO airports to a Little Navmap userpoints CSV
+func ExportLittleNavmapUserpoints(db *sqlx.DB) {
+	bold := color.New(color.Bold).SprintFunc()
+
+	airports, err := fbo.ListAirportsWithFBOs(db)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	if len(airports) == 0 {
+		fmt.Println(color.YellowString("There are no FBOs to export."))
+		return
+	}
+
+	path := promptForExportPath("offair_fbos_userpoints.csv")
+	if path == "" {
+		return
+	}
+
+	// Build the userpoints before writing the file, so an error doesn't leave a truncated file behind
+	var userpoints bytes.Buffer
+	written, err := export.WriteLittleNavmapUserpoints(&userpoints, airports)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	if err := os.WriteFile(path, userpoints.Bytes(), 0644); err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error creating file:"), err)
+		return
+	}
+
+	fmt.Printf("%s %d %s %s\n",
+		color.GreenString("Exported"),
+		written,
+		color.GreenString("FBO userpoints to"),
+		bold(path))
+	if skipped := len(airports) - written; skipped > 0 {
+		fmt.Printf("%s\n", color.YellowString(fmt.Sprintf("Skipped %d FBOs without latitude or longitude information.", skipped)))
+	}
+	fmt.Println()
+}
+
+// ExportMSFSFlightPlan writes an MSFS flight plan between two airports, either direct or via FBOs
+func ExportMSFSFlightPlan(db *sqlx.DB, viaFBOs bool) {
+	bold := color.New(color.Bold).SprintFunc()
+
+	var fromICAO string
+	survey.AskOne(&survey.Input{
+		Message: "Enter departure ICAO (blank to go back):",
+	}, &fromICAO)
+	if fromICAO == "" {
+		return
+	}
+	fromICAO = strings.ToUpper(fromICAO)
+
+	var toICAO string
+	survey.AskOne(&survey.Input{
+		Message: "Enter destination ICAO (blank to go back):",
+	}, &toICAO)
+	if toICAO == "" {
+		return
+	}
+	toICAO = strings.ToUpper(toICAO)
+
+	if fromICAO == toICAO {
+		fmt.Printf("%s %s\n", color.RedString("Error:"), "Both ICAOs are the same. Please enter different ICAOs.")
+		return
+	}
+
+	var route []models.Airport
+	if viaFBOs {
+		var err error
+		route, err = fbo.FindFBORoute(db, fromICAO, toICAO, config.Load().MaxDistance)
+		if err != nil {
+			fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+			return
+		}
+	} else {
+		for _, icao := range []string{fromICAO, toICAO} {
+			var airport models.Airport
+			err := db.Get(&airport, "SELECT * FROM airports WHERE icao = ?", icao)
+			if err != nil {
+				fmt.Printf("%s %s %s\n", color.RedString("Error:"), bold(icao), "not found in the database.")
+				return
+			}
+			route = append(route, airport)
+		}
+	}
+
+	var cruisingAltitudeStr string
+	survey.AskOne(&survey.Input{
+		Message: "Enter cruising altitude in feet:",
+		Default: "8500",
+	}, &cruisingAltitudeStr)
+	cruisingAltitude, err := strconv.ParseFloat(cruisingAltitudeStr, 64)
+	if err != nil {
+		fmt.Printf("%s %s\n", color.RedString("Error:"), "Cruising altitude must be a number.")
+		return
+	}
+
+	path := promptForExportPath(fmt.Sprintf("%s%s.pln", fromICAO, toICAO))
+	if path == "" {
+		return
+	}
+
+	// Build the flight plan before writing the file, so an error doesn't leave a truncated file behind
+	var plan bytes.Buffer
+	if err := export.WriteMSFSFlightPlan(&plan, route, cruisingAltitude); err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	if err := os.WriteFile(path, plan.Bytes(), 0644); err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error creating file:"), err)
+		return
+	}
+
+	var idents []string
+	totalDistance := 0.0
+	for i, airport := range route {
+		idents = append(idents, bold(airport.ICAO))
+		if i > 0 {
+			totalDistance += fbo.CalculateDistance(
+				*route[i-1].Latitude, *route[i-1].Longitude,
+				*airport.Latitude, *airport.Longitude)
+		}
+	}
+
+	fmt.Printf("%s %s\n", bold("Route:"), strings.Join(idents, color.BlueString(" → ")))
+	fmt.Printf("%s %.2f %s (%d legs)\n", bold("Distance:"), totalDistance, color.GreenString("nm"), len(route)-1)
+	fmt.Printf("%s %s\n\n", color.GreenString("Flight plan written to"), bold(path))
+}
+
+// ExportFBONetworkDOT writes the FBO leg network as a Graphviz DOT graph
+func ExportFBONetworkDOT(db *sqlx.DB) {
+	bold := color.New(color.Bold).SprintFunc()
+
+	airports, err := fbo.ListAirportsWithFBOs(db)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	if len(airports) == 0 {
+		fmt.Println(color.YellowString("There are no FBOs to export."))
+		return
+	}
+
+	maxDistance := config.Load().MaxDistance
+	options := export.DOTOptions{MaxDistance: maxDistance}
+
+	var edges string
+	survey.AskOne(&survey.Select{
+		Message: "Edges to include:",
+		Options: []string{
+			fmt.Sprintf("All legs up to %.0f nm", maxDistance),
+			"Minimum spanning tree only",
+		},
+	}, &edges)
+	options.MinimumSpanningTree = edges == "Minimum spanning tree only"
+
+	var componentICAO string
+	survey.AskOne(&survey.Input{
+		Message: "Restrict to the connected component containing ICAO (blank for whole network):",
+	}, &componentICAO)
+	options.ComponentICAO = strings.ToUpper(strings.TrimSpace(componentICAO))
+
+	path := promptForExportPath("offair_fbo_network.dot")
+	if path == "" {
+		return
+	}
+
+	// Build the graph before creating the file, so an unknown component ICAO doesn't leave an empty file behind
+	var graph bytes.Buffer
+	if err := export.WriteFBONetworkDOT(&graph, airports, options); err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	if err := os.WriteFile(path, graph.Bytes(), 0644); err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error creating file:"), err)
+		return
+	}
+
+	fmt.Printf("%s %s\n", color.GreenString("FBO network graph written to"), bold(path))
+	fmt.Printf("%s\n\n", color.CyanString("Render it with e.g. neato -Tsvg "+path+" -o network.svg"))
+}
+
+// promptForExportPath prompts the user for a file to export to, returning blank if they go back
+func promptForExportPath(defaultPath string) string {
+	var path string
+	survey.AskOne(&survey.Input{
+		Message: "Enter file to export to (blank to go back):",
+		Default: defaultPath,
+	}, &path)
+
+	return strings.TrimSpace(path)
+}
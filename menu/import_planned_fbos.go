@@ -0,0 +1,169 @@
+package menu
+
+import (
+	"fmt"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/fbo"
+	"github.com/julietrb1/offair-cli/importer"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ImportPlannedFBOs imports a list of ICAOs from CSV or JSON, either adding them as FBOs or saving them as a planned set
+func ImportPlannedFBOs(db *sqlx.DB) {
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+
+	var path string
+	survey.AskOne(&survey.Input{
+		Message: "Enter CSV or JSON file of ICAOs to import (blank to go back):",
+	}, &path)
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error opening file:"), err)
+		return
+	}
+	icaos, err := importer.ParseICAOList(file, importer.FormatFromPath(path))
+	file.Close()
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	if len(icaos) == 0 {
+		fmt.Println(color.YellowString("No ICAOs found in the file."))
+		return
+	}
+
+	preview, err := importer.PreviewPlannedFBOs(db, icaos)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	// Show a preview of what will change
+	fmt.Printf("\n%s %d ICAOs in %s\n", bold("Read"), len(icaos), bold(path))
+	if len(preview.Known) > 0 {
+		var known []string
+		for _, airport := range preview.Known {
+			known = append(known, airport.ICAO)
+		}
+		fmt.Printf("  • %s (%d): %s\n", bold("New FBOs at known airports"), len(known), cyan(strings.Join(known, ", ")))
+	}
+	if len(preview.ToFetch) > 0 {
+		fmt.Printf("  • %s (%d): %s\n", bold("New FBOs at airports to fetch from OnAir"), len(preview.ToFetch), cyan(strings.Join(preview.ToFetch, ", ")))
+	}
+	if len(preview.AlreadyFBOs) > 0 {
+		fmt.Printf("  • %s (%d): %s\n", bold("Already FBOs, skipped"), len(preview.AlreadyFBOs), yellow(strings.Join(preview.AlreadyFBOs, ", ")))
+	}
+	if len(preview.Duplicates) > 0 {
+		fmt.Printf("  • %s (%d): %s\n", bold("Duplicates, skipped"), len(preview.Duplicates), yellow(strings.Join(preview.Duplicates, ", ")))
+	}
+	fmt.Println()
+
+	if len(preview.Known) == 0 && len(preview.ToFetch) == 0 {
+		fmt.Println(color.YellowString("Nothing to import."))
+		return
+	}
+
+	var action string
+	survey.AskOne(&survey.Select{
+		Message: "Import as:",
+		Options: []string{
+			AddAsFBOsMenuLabel,
+			SaveAsPlannedSetMenuLabel,
+			CancelMenuLabel,
+		},
+	}, &action)
+
+	if action == CancelMenuLabel || action == "" {
+		return
+	}
+
+	var planName string
+	if action == SaveAsPlannedSetMenuLabel {
+		survey.AskOne(&survey.Input{
+			Message: "Enter a name for the planned set (blank to cancel):",
+		}, &planName)
+		planName = strings.TrimSpace(planName)
+		if planName == "" {
+			return
+		}
+
+		existing, err := fbo.ListPlannedFBOSets(db)
+		if err != nil {
+			fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+			return
+		}
+		for _, name := range existing {
+			if name == planName {
+				replace := false
+				survey.AskOne(&survey.Confirm{
+					Message: fmt.Sprintf("Planned set %s already exists. Replace it?", planName),
+				}, &replace)
+				if !replace {
+					return
+				}
+			}
+		}
+	}
+
+	if len(preview.ToFetch) > 0 {
+		fmt.Printf("Fetching %d airports from OnAir...\n", len(preview.ToFetch))
+	}
+	airports, unresolved := importer.ResolvePlannedFBOs(db, preview)
+
+	if action == AddAsFBOsMenuLabel {
+		added, failed := importer.AddPlannedFBOs(db, airports)
+		for icao, err := range unresolved {
+			failed[icao] = err
+		}
+
+		fmt.Printf("%s %d %s\n", color.GreenString("Added"), len(added), color.GreenString("FBOs."))
+		if len(added) > 0 {
+			recordNetworkSnapshot(db, "imported planned FBOs")
+		}
+		printUnresolvedICAOs(failed)
+	} else {
+		if err := fbo.SavePlannedFBOSet(db, planName, airports); err != nil {
+			fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+			return
+		}
+
+		fmt.Printf("%s %s %s %d %s\n",
+			color.GreenString("Saved planned set"),
+			bold(planName),
+			color.GreenString("with"),
+			len(airports),
+			color.GreenString("airports."))
+		printUnresolvedICAOs(unresolved)
+	}
+	fmt.Println()
+}
+
+// printUnresolvedICAOs prints ICAOs that couldn't be imported, along with the reason
+func printUnresolvedICAOs(unresolved map[string]error) {
+	if len(unresolved) == 0 {
+		return
+	}
+
+	icaos := make([]string, 0, len(unresolved))
+	for icao := range unresolved {
+		icaos = append(icaos, icao)
+	}
+	sort.Strings(icaos)
+
+	fmt.Printf("%s\n", color.YellowString(fmt.Sprintf("%d ICAOs couldn't be imported:", len(icaos))))
+	for _, icao := range icaos {
+		fmt.Printf("  • %s: %v\n", color.New(color.Bold).Sprint(icao), unresolved[icao])
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/fatih/color"
 	"github.com/jmoiron/sqlx"
-
+	"github.com/julietrb1/offair-cli/config"
 	"github.com/julietrb1/offair-cli/fbo"
 )
 
@@ -18,6 +18,7 @@ func MainMenu(db *sqlx.DB) {
 			Options: []string{
 				"Airports",
 				"FBOs",
+				ImportExportMenuLabel,
 				"Exit",
 			},
 		}
@@ -28,6 +29,8 @@ func MainMenu(db *sqlx.DB) {
 			AirportsMenu(db)
 		case "FBOs":
 			FBOOptimiserMenu(db)
+		case ImportExportMenuLabel:
+			ImportExportMenu(db)
 		case "Exit":
 			fmt.Println(ExitMessage)
 			return
@@ -44,6 +47,8 @@ func AirportsMenu(db *sqlx.DB) {
 			Options: []string{
 				"Airport Lookup",
 				"Modify Airport",
+				AirportDemandMenuLabel,
+				PinnedAirportsMenuLabel,
 				BackToMainMenuLabel,
 			},
 		}
@@ -54,6 +59,10 @@ func AirportsMenu(db *sqlx.DB) {
 			SearchAirportByICAO(db)
 		case "Modify Airport":
 			ModifyAirport(db)
+		case AirportDemandMenuLabel:
+			ListAirportDemand(db)
+		case PinnedAirportsMenuLabel:
+			PinnedAirportsMenu(db)
 		case BackToMainMenuLabel:
 			return
 		}
@@ -69,9 +78,23 @@ func FBOOptimiserMenu(db *sqlx.DB) {
 			Options: []string{
 				ListAirportsWithFBOsMenuLabel,
 				ListDistancesBetweenFBOsMenuLabel,
+				NetworkHealthMenuLabel,
+				NetworkBackboneMenuLabel,
+				FBOCentralityMenuLabel,
+				FBOCatchmentsMenuLabel,
+				CoverageGapsMenuLabel,
+				SimulateJobsMenuLabel,
 				"Find Distance Between Airports",
+				RouteCorridorMenuLabel,
 				"Find Optimal FBO Locations",
-				"[PRESENTLY BROKEN] Find Redundant FBOs",
+				ExplainCandidateScoreMenuLabel,
+				SweepMenuLabel,
+				PlanNewFBOsMenuLabel,
+				RelocationsMenuLabel,
+				RebalanceMenuLabel,
+				WhatIfScenariosMenuLabel,
+				NetworkVariantsMenuLabel,
+				RedundantFBOsMenuLabel,
 				SyncFBOsMenuLabel,
 				BackToMainMenuLabel,
 			},
@@ -83,11 +106,39 @@ func FBOOptimiserMenu(db *sqlx.DB) {
 			ListAirportsWithFBOs(db)
 		case "List Distances Between FBOs":
 			ListDistancesBetweenFBOs(db)
+		case NetworkHealthMenuLabel:
+			NetworkHealth(db)
+		case NetworkBackboneMenuLabel:
+			FindNetworkBackbone(db)
+		case FBOCentralityMenuLabel:
+			RankFBOCentrality(db)
+		case FBOCatchmentsMenuLabel:
+			FindFBOCatchments(db)
+		case CoverageGapsMenuLabel:
+			FindCoverageGaps(db)
+		case SimulateJobsMenuLabel:
+			SimulateJobs(db)
 		case "Find Distance Between Airports":
 			FindDistanceBetweenAirports(db)
+		case RouteCorridorMenuLabel:
+			SearchRouteCorridor(db)
 		case "Find Optimal FBO Locations":
 			FindOptimalFBOLocations(db)
-		case "Find Redundant FBOs":
+		case ExplainCandidateScoreMenuLabel:
+			ExplainCandidateScore(db)
+		case SweepMenuLabel:
+			SweepOptimalFBOLocations(db)
+		case PlanNewFBOsMenuLabel:
+			PlanNewFBOs(db)
+		case RelocationsMenuLabel:
+			SuggestFBORelocations(db)
+		case RebalanceMenuLabel:
+			RebalanceFBONetwork(db)
+		case WhatIfScenariosMenuLabel:
+			WhatIfScenarios(db)
+		case NetworkVariantsMenuLabel:
+			NetworkVariantsMenu(db)
+		case RedundantFBOsMenuLabel:
 			FindRedundantFBOs(db)
 		case SyncFBOsMenuLabel:
 			SyncFBOs(db)
@@ -115,13 +166,16 @@ func FBOOptions(db *sqlx.DB, icao string) {
 			fmt.Printf("%s %v\n", color.RedString("Error:"), err)
 		} else {
 			fmt.Printf("FBO at %s removed.\n", icao)
+			recordNetworkSnapshot(db, "removed FBO at "+icao)
 		}
 	}
 }
 
 // ListDistancesBetweenFBOs lists the distances between all FBOs
 func ListDistancesBetweenFBOs(db *sqlx.DB) {
-	result, err := fbo.ListDistancesBetweenFBOs(db)
+	cfg := config.Load()
+
+	result, err := fbo.ListDistancesBetweenFBOs(db, cfg.Clustering)
 	if err != nil {
 		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
 		return
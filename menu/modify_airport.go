@@ -8,6 +8,7 @@ import (
 	"github.com/julietrb1/offair-cli/models"
 	"github.com/julietrb1/offair-cli/models/onair"
 	"github.com/julietrb1/onair-api-go-client/api"
+	"strconv"
 	"strings"
 )
 
@@ -182,6 +183,16 @@ func ModifyAirport(db *sqlx.DB) {
 					color.YellowString("Not set"))
 			}
 
+			if airport.DemandWeight != nil {
+				fmt.Printf("%s %s\n",
+					bold("Demand Weight:"),
+					strconv.FormatFloat(*airport.DemandWeight, 'f', -1, 64))
+			} else {
+				fmt.Printf("%s %s\n",
+					bold("Demand Weight:"),
+					color.YellowString("Derived"))
+			}
+
 			fmt.Println()
 
 			var option string
@@ -193,6 +204,7 @@ func ModifyAirport(db *sqlx.DB) {
 					"Modify Country Name",
 					"Modify City",
 					"Modify Airport Type",
+					"Modify Demand Weight",
 					BackMenuLabel,
 				},
 			}
@@ -209,6 +221,8 @@ func ModifyAirport(db *sqlx.DB) {
 				ModifyCity(db, &airport)
 			case "Modify Airport Type":
 				ModifyAirportType(db, &airport)
+			case "Modify Demand Weight":
+				ModifyDemandWeight(db, &airport)
 			case BackMenuLabel:
 				// Break out of the inner loop and return to the ICAO input prompt
 				break
@@ -248,7 +262,7 @@ func ModifyCountryCode(db *sqlx.DB, airport *models.Airport) {
 		return
 	}
 
-	fmt.Printf("%s %s %s\n",
+	fmt.Printf("%s %s\n",
 		color.GreenString("Country code updated to"),
 		bold(countryCode))
 }
@@ -288,7 +302,7 @@ func ModifyState(db *sqlx.DB, airport *models.Airport) {
 	if state == "" {
 		fmt.Printf("%s\n", color.GreenString("State cleared."))
 	} else {
-		fmt.Printf("%s %s %s\n",
+		fmt.Printf("%s %s\n",
 			color.GreenString("State updated to"),
 			bold(state))
 	}
@@ -329,7 +343,7 @@ func ModifyCountryName(db *sqlx.DB, airport *models.Airport) {
 	if countryName == "" {
 		fmt.Printf("%s\n", color.GreenString("Country name cleared."))
 	} else {
-		fmt.Printf("%s %s %s\n",
+		fmt.Printf("%s %s\n",
 			color.GreenString("Country name updated to"),
 			bold(countryName))
 	}
@@ -372,7 +386,7 @@ func ModifyCity(db *sqlx.DB, airport *models.Airport) {
 	if city == "" {
 		fmt.Printf("%s\n", color.GreenString("City cleared."))
 	} else {
-		fmt.Printf("%s %s %s\n",
+		fmt.Printf("%s %s\n",
 			color.GreenString("City updated to"),
 			bold(city))
 	}
@@ -426,7 +440,7 @@ func ModifyAirportType(db *sqlx.DB, airport *models.Airport) {
 	if airportTypeOption == ClearMenuLabel {
 		fmt.Printf("%s\n", color.GreenString("Airport type cleared."))
 	} else if airportTypeOption != CancelMenuLabel {
-		fmt.Printf("%s %s %s\n",
+		fmt.Printf("%s %s\n",
 			color.GreenString("Airport type updated to"),
 			bold(airportTypeOption))
 	}
@@ -0,0 +1,79 @@
+package menu
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/config"
+	"github.com/julietrb1/offair-cli/export"
+	"github.com/julietrb1/offair-cli/fbo"
+	"os"
+)
+
+// NetworkHealth shows the FBO network's metrics, connectivity and coverage, and how they've changed
+func NetworkHealth(db *sqlx.DB) {
+	cfg := config.Load()
+
+	result, err := fbo.NetworkHealth(db, cfg.OptimalDistance, cfg.MaxDistance, cfg.CoverageRadius)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	fmt.Println(result)
+}
+
+// ExportNetworkHistory writes every network health snapshot to a CSV file
+func ExportNetworkHistory(db *sqlx.DB) {
+	bold := color.New(color.Bold).SprintFunc()
+
+	snapshots, err := fbo.ListNetworkSnapshots(db, 0)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	if len(snapshots) == 0 {
+		fmt.Println(color.YellowString("There are no network snapshots to export."))
+		return
+	}
+
+	path := promptForExportPath("offair_network_history.csv")
+	if path == "" {
+		return
+	}
+
+	// Oldest first, which suits charting
+	for i, j := 0, len(snapshots)-1; i < j; i, j = i+1, j-1 {
+		snapshots[i], snapshots[j] = snapshots[j], snapshots[i]
+	}
+
+	// Build the CSV before writing the file, so an error doesn't leave a truncated file behind
+	var history bytes.Buffer
+	if err := export.WriteNetworkSnapshotsCSV(&history, snapshots); err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	if err := os.WriteFile(path, history.Bytes(), 0644); err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error creating file:"), err)
+		return
+	}
+
+	fmt.Printf("%s %d %s %s\n\n",
+		color.GreenString("Exported"),
+		len(snapshots),
+		color.GreenString("network snapshots to"),
+		bold(path))
+}
+
+// recordNetworkSnapshot records the network's health after a change, warning rather than failing if it can't
+func recordNetworkSnapshot(db *sqlx.DB, reason string) {
+	cfg := config.Load()
+
+	_, err := fbo.RecordNetworkSnapshot(db, reason, cfg.OptimalDistance, cfg.MaxDistance, cfg.CoverageRadius)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.YellowString("Warning: couldn't record a network snapshot:"), err)
+	}
+}
@@ -0,0 +1,187 @@
+package menu
+
+import (
+	"fmt"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/config"
+	"github.com/julietrb1/offair-cli/fbo"
+	"strings"
+)
+
+// NetworkVariantsMenu displays the network variants menu and handles user selection
+func NetworkVariantsMenu(db *sqlx.DB) {
+	for {
+		var option string
+		prompt := &survey.Select{
+			Message: "Network variants:",
+			Options: []string{
+				CompareNetworkVariantsMenuLabel,
+				NewNetworkVariantMenuLabel,
+				DeleteNetworkVariantMenuLabel,
+				BackMenuLabel,
+			},
+		}
+		survey.AskOne(prompt, &option)
+
+		switch option {
+		case CompareNetworkVariantsMenuLabel:
+			compareNetworkVariants(db)
+		case NewNetworkVariantMenuLabel:
+			newNetworkVariant(db)
+		case DeleteNetworkVariantMenuLabel:
+			deleteNetworkVariant(db)
+		default:
+			return
+		}
+	}
+}
+
+// compareNetworkVariants lets the user pick two network variants and compares them side by side
+func compareNetworkVariants(db *sqlx.DB) {
+	names, err := fbo.ListNetworkVariants(db)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+	if len(names) == 0 {
+		fmt.Println(color.YellowString("No saved network variants. Create one to compare it with the current network."))
+		return
+	}
+
+	a, ok := selectNetworkVariant(db, "First network:", names)
+	if !ok {
+		return
+	}
+	b, ok := selectNetworkVariant(db, "Second network:", names)
+	if !ok {
+		return
+	}
+
+	cfg := config.Load()
+	result, err := fbo.CompareNetworkVariants(db, a, b, cfg.OptimalDistance, cfg.MaxDistance, cfg.CoverageRadius)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	fmt.Println(result)
+}
+
+// selectNetworkVariant prompts for a saved network variant or the current network and loads it
+// Returns false if the user went back or it couldn't be loaded
+func selectNetworkVariant(db *sqlx.DB, message string, names []string) (fbo.NetworkVariant, bool) {
+	var name string
+	survey.AskOne(&survey.Select{
+		Message: message,
+		Options: append(append([]string{fbo.CurrentNetworkName}, names...), BackMenuLabel),
+	}, &name)
+	if name == BackMenuLabel || name == "" {
+		return fbo.NetworkVariant{}, false
+	}
+
+	var variant fbo.NetworkVariant
+	var err error
+	if name == fbo.CurrentNetworkName {
+		variant, err = fbo.CurrentNetwork(db)
+	} else {
+		variant, err = fbo.GetNetworkVariant(db, name)
+	}
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return fbo.NetworkVariant{}, false
+	}
+	return variant, true
+}
+
+// newNetworkVariant saves the current network, optionally with FBOs added and removed, as a named variant
+func newNetworkVariant(db *sqlx.DB) {
+	bold := color.New(color.Bold).SprintFunc()
+
+	var name string
+	survey.AskOne(&survey.Input{
+		Message: "Enter a name for the network variant (blank to cancel):",
+	}, &name)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return
+	}
+	if name == fbo.CurrentNetworkName {
+		fmt.Printf("%s %s\n", color.RedString("Error:"), "that name is reserved for the current network")
+		return
+	}
+
+	existing, err := fbo.ListNetworkVariants(db)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+	for _, existingName := range existing {
+		if existingName == name {
+			replace := false
+			survey.AskOne(&survey.Confirm{
+				Message: fmt.Sprintf("Network variant %s already exists. Replace it?", name),
+			}, &replace)
+			if !replace {
+				return
+			}
+		}
+	}
+
+	var add, remove string
+	survey.AskOne(&survey.Input{
+		Message: "Starting from the current network, ICAOs of FBOs to add (comma-separated, blank for none):",
+	}, &add)
+	survey.AskOne(&survey.Input{
+		Message: "ICAOs of FBOs to remove (comma-separated, blank for none):",
+	}, &remove)
+
+	variant, err := fbo.NetworkVariantFromScenario(db, fbo.Scenario{Name: name, Add: parseICAOs(add), Remove: parseICAOs(remove)})
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	if err := fbo.SaveNetworkVariant(db, variant); err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	fmt.Printf("%s %s %s %d %s\n",
+		color.GreenString("Saved network variant"),
+		bold(name),
+		color.GreenString("with"),
+		len(variant.FBOs),
+		color.GreenString("FBOs."))
+}
+
+// deleteNetworkVariant lets the user pick a saved network variant and deletes it
+func deleteNetworkVariant(db *sqlx.DB) {
+	bold := color.New(color.Bold).SprintFunc()
+
+	names, err := fbo.ListNetworkVariants(db)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+	if len(names) == 0 {
+		fmt.Println(color.YellowString("No saved network variants."))
+		return
+	}
+
+	var name string
+	survey.AskOne(&survey.Select{
+		Message: "Network variant to delete:",
+		Options: append(names, BackMenuLabel),
+	}, &name)
+	if name == BackMenuLabel || name == "" {
+		return
+	}
+
+	if err := fbo.DeleteNetworkVariant(db, name); err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+	fmt.Printf("%s %s\n", color.GreenString("Deleted network variant"), bold(name))
+}
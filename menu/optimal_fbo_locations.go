@@ -2,11 +2,12 @@ package menu
 
 import (
 	"fmt"
+	"github.com/AlecAivazis/survey/v2"
 	"github.com/fatih/color"
 	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/config"
 	"github.com/julietrb1/offair-cli/fbo"
-	"os"
-	"strconv"
+	"strings"
 )
 
 // FindOptimalFBOLocations finds optimal locations for FBOs
@@ -15,42 +16,54 @@ func FindOptimalFBOLocations(db *sqlx.DB) {
 	bold := color.New(color.Bold).SprintFunc()
 	cyan := color.New(color.FgCyan).SprintFunc()
 
-	// Get values from environment variables or use hardcoded defaults
-	optimalDistanceStr := os.Getenv("FBO_NM_OPTIMAL")
-	if optimalDistanceStr == "" {
-		optimalDistanceStr = "800" // Default value when environment variable is not set
+	cfg := config.Load()
+
+	scorer, err := cfg.NewScorer()
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
 	}
-	optimalDistance, _ := strconv.ParseFloat(optimalDistanceStr, 64)
 
-	maxDistanceStr := os.Getenv("FBO_NM_MAX")
-	if maxDistanceStr == "" {
-		maxDistanceStr = "1200" // Default value when environment variable is not set
+	filter, ok := promptForCandidateFilter(cfg.CandidateFilter)
+	if !ok {
+		return
 	}
-	maxDistance, _ := strconv.ParseFloat(maxDistanceStr, 64)
 
-	// Get FBO_REQ_LIGHTS environment variable (default to "true")
-	requireLightsStr := os.Getenv("FBO_REQ_LIGHTS")
-	if requireLightsStr == "" {
-		requireLightsStr = "true" // Default value when environment variable is not set
+	result, err := fbo.FindOptimalFBOLocations(db, cfg.OptimalDistance, cfg.MaxDistance, cfg.RequireLights, cfg.PreferredSize, filter, scorer)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
 	}
-	requireLights := requireLightsStr == "true"
-
-	// Get FBO_PREFERRED_SIZE environment variable (no default)
-	preferredSizeStr := os.Getenv("FBO_PREFERRED_SIZE")
-	var preferredSize *int
-	if preferredSizeStr != "" {
-		size, err := strconv.Atoi(preferredSizeStr)
-		if err == nil && size >= 0 && size <= 5 {
-			preferredSize = &size
-		}
+
+	fmt.Println(bold(cyan("Calculating optimal FBO locations...")))
+	fmt.Println(result)
+}
+
+// ExplainCandidateScore shows how the optimiser scores an airport as a location for a new FBO
+func ExplainCandidateScore(db *sqlx.DB) {
+	var icao string
+	prompt := &survey.Input{
+		Message: "Enter ICAO of the airport (blank to go back):",
+	}
+	survey.AskOne(prompt, &icao)
+
+	if icao == "" {
+		return
 	}
 
-	result, err := fbo.FindOptimalFBOLocations(db, optimalDistance, maxDistance, requireLights, preferredSize)
+	cfg := config.Load()
+
+	scorer, err := cfg.NewScorer()
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	result, err := fbo.ExplainOptimalFBOLocation(db, strings.ToUpper(icao), cfg.OptimalDistance, cfg.MaxDistance, cfg.RequireLights, cfg.PreferredSize, cfg.CandidateFilter, scorer)
 	if err != nil {
 		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
 		return
 	}
 
-	fmt.Println(bold(cyan("Calculating optimal FBO locations...")))
 	fmt.Println(result)
 }
@@ -0,0 +1,72 @@
+package menu
+
+import (
+	"fmt"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/fbo"
+	"strings"
+)
+
+// PinnedAirportsMenu lets the user pin FBOs so analyses never suggest removing or moving them, and exclude airports
+// so analyses never recommend them
+func PinnedAirportsMenu(db *sqlx.DB) {
+	for {
+		var option string
+		prompt := &survey.Select{
+			Message: "Pinned & Excluded Airports:",
+			Options: []string{
+				ListPinsMenuLabel,
+				PinFBOMenuLabel,
+				UnpinFBOMenuLabel,
+				ExcludeAirportMenuLabel,
+				IncludeAirportMenuLabel,
+				BackMenuLabel,
+			},
+		}
+		survey.AskOne(prompt, &option)
+
+		switch option {
+		case ListPinsMenuLabel:
+			result, err := fbo.ListPinnedAirports(db)
+			if err != nil {
+				fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+				continue
+			}
+			fmt.Println(result)
+		case PinFBOMenuLabel:
+			setAirportPin(db, "pin", "pinned", func(icao string) error { return fbo.SetAirportPinned(db, icao, true) })
+		case UnpinFBOMenuLabel:
+			setAirportPin(db, "unpin", "unpinned", func(icao string) error { return fbo.SetAirportPinned(db, icao, false) })
+		case ExcludeAirportMenuLabel:
+			setAirportPin(db, "exclude", "excluded", func(icao string) error { return fbo.SetAirportExcluded(db, icao, true) })
+		case IncludeAirportMenuLabel:
+			setAirportPin(db, "include", "included", func(icao string) error { return fbo.SetAirportExcluded(db, icao, false) })
+		case BackMenuLabel:
+			return
+		}
+	}
+}
+
+// setAirportPin prompts for ICAOs and applies set to each
+func setAirportPin(db *sqlx.DB, verb, done string, set func(icao string) error) {
+	bold := color.New(color.Bold).SprintFunc()
+
+	var input string
+	survey.AskOne(&survey.Input{
+		Message: fmt.Sprintf("Enter ICAOs to %s, separated by commas (blank to go back):", verb),
+	}, &input)
+
+	for _, icao := range strings.Split(input, ",") {
+		icao = strings.ToUpper(strings.TrimSpace(icao))
+		if icao == "" {
+			continue
+		}
+		if err := set(icao); err != nil {
+			fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+			continue
+		}
+		fmt.Printf("%s %s\n", bold(icao), color.GreenString(done+"."))
+	}
+}
@@ -0,0 +1,58 @@
+package menu
+
+import (
+	"fmt"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/config"
+	"github.com/julietrb1/offair-cli/fbo"
+	"strconv"
+	"strings"
+)
+
+// PlanNewFBOs plans a set of new FBOs that together add the most coverage to the network
+func PlanNewFBOs(db *sqlx.DB) {
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+
+	var countStr string
+	countPrompt := &survey.Input{
+		Message: "How many new FBOs?",
+		Default: "5",
+	}
+	survey.AskOne(countPrompt, &countStr)
+
+	count, err := strconv.Atoi(strings.TrimSpace(countStr))
+	if err != nil || count < 1 {
+		fmt.Printf("%s %s\n", color.RedString("Error:"), "enter a whole number of 1 or more")
+		return
+	}
+
+	var method string
+	methodPrompt := &survey.Select{
+		Message: "Planning method:",
+		Options: []string{
+			GreedyWithLocalSearchMenuLabel,
+			GreedyMenuLabel,
+		},
+	}
+	survey.AskOne(methodPrompt, &method)
+
+	cfg := config.Load()
+
+	filter, ok := promptForCandidateFilter(cfg.CandidateFilter)
+	if !ok {
+		return
+	}
+
+	fmt.Println(bold(cyan("Planning new FBO locations...")))
+	result, err := fbo.FindFBOPlacements(db, count, cfg.OptimalDistance, cfg.MaxDistance, cfg.CoverageRadius,
+		cfg.RequireLights, method == GreedyWithLocalSearchMenuLabel, filter)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	fmt.Println(result)
+}
@@ -0,0 +1,107 @@
+package menu
+
+import (
+	"fmt"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/config"
+	"github.com/julietrb1/offair-cli/fbo"
+	"strconv"
+	"strings"
+)
+
+// RebalanceFBONetwork plans FBOs to open and close within a maximum count or budget, and offers to save the plan
+// as a what-if scenario
+func RebalanceFBONetwork(db *sqlx.DB) {
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+
+	cfg := config.Load()
+	budget := cfg.Rebalance
+
+	var maxFBOsStr string
+	maxDefault := ""
+	if budget.MaxFBOs > 0 {
+		maxDefault = strconv.Itoa(budget.MaxFBOs)
+	}
+	survey.AskOne(&survey.Input{
+		Message: "Maximum number of FBOs (blank for no limit):",
+		Default: maxDefault,
+	}, &maxFBOsStr)
+	budget.MaxFBOs = 0
+	if maxFBOsStr = strings.TrimSpace(maxFBOsStr); maxFBOsStr != "" {
+		maxFBOs, err := strconv.Atoi(maxFBOsStr)
+		if err != nil || maxFBOs < 1 {
+			fmt.Printf("%s %s\n", color.RedString("Error:"), "enter a whole number of 1 or more")
+			return
+		}
+		budget.MaxFBOs = maxFBOs
+	}
+
+	budget.Budget = 0
+	if len(budget.SizeCosts) > 0 {
+		var budgetStr string
+		budgetDefault := ""
+		if cfg.Rebalance.Budget > 0 {
+			budgetDefault = strconv.FormatFloat(cfg.Rebalance.Budget, 'f', -1, 64)
+		}
+		survey.AskOne(&survey.Input{
+			Message: "Budget, using the FBO_SIZE_COSTS table (blank for no limit):",
+			Default: budgetDefault,
+		}, &budgetStr)
+		if budgetStr = strings.TrimSpace(budgetStr); budgetStr != "" {
+			value, err := strconv.ParseFloat(budgetStr, 64)
+			if err != nil || value <= 0 {
+				fmt.Printf("%s %s\n", color.RedString("Error:"), "enter a budget greater than zero")
+				return
+			}
+			budget.Budget = value
+		}
+	}
+
+	if err := budget.Validate(); err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	scorer, err := cfg.NewScorer()
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	filter, ok := promptForCandidateFilter(cfg.CandidateFilter)
+	if !ok {
+		return
+	}
+
+	fmt.Println(bold(cyan("Planning rebalance...")))
+	plan, err := fbo.PlanFBORebalance(db, budget, cfg.OptimalDistance, cfg.MaxDistance, cfg.CoverageRadius, cfg.RequireLights, cfg.PreferredSize, filter, scorer)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	fmt.Println(fbo.FormatRebalancePlan(plan, cfg.OptimalDistance, cfg.MaxDistance, cfg.CoverageRadius, filter, scorer))
+	if len(plan.Steps) == 0 {
+		return
+	}
+
+	var name string
+	survey.AskOne(&survey.Input{
+		Message: "Save the plan as a what-if scenario to review or commit later? Enter a name (blank to skip):",
+	}, &name)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return
+	}
+
+	scenario := plan.Scenario()
+	scenario.Name = name
+	if err := fbo.SaveScenario(db, scenario); err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+	fmt.Printf("%s %s\n", color.GreenString("Saved scenario"), bold(name))
+}
@@ -0,0 +1,37 @@
+package menu
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/config"
+	"github.com/julietrb1/offair-cli/fbo"
+)
+
+// SuggestFBORelocations shows moves of existing FBOs to nearby airports that improve the network
+func SuggestFBORelocations(db *sqlx.DB) {
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+
+	cfg := config.Load()
+
+	scorer, err := cfg.NewScorer()
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	filter, ok := promptForCandidateFilter(cfg.CandidateFilter)
+	if !ok {
+		return
+	}
+
+	fmt.Println(bold(cyan("Evaluating FBO relocations...")))
+	result, err := fbo.SuggestFBORelocations(db, cfg.RelocationRadius, cfg.OptimalDistance, cfg.MaxDistance, cfg.CoverageRadius, cfg.RequireLights, cfg.PreferredSize, filter, scorer)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	fmt.Println(result)
+}
@@ -22,6 +22,56 @@ const (
 	CancelMenuLabel                   = "Cancel"
 	ClearMenuLabel                    = "Clear"
 	NotSetMenuLabel                   = "Not Set"
+	ImportExportMenuLabel             = "Import & Export"
+	ImportPlannedFBOsMenuLabel        = "Import Planned FBOs (CSV/JSON)"
+	AddAsFBOsMenuLabel                = "Add as FBOs"
+	SaveAsPlannedSetMenuLabel         = "Save as planned set"
+	ImportDatabaseMenuLabel           = "Import Database (Merge)"
+	ExportDatabaseMenuLabel           = "Export Database"
+	KeepMineMenuLabel                 = "Keep mine"
+	TakeTheirsMenuLabel               = "Take theirs"
+	PromptForEachMenuLabel            = "Prompt for each conflict"
+	ExportLittleNavmapMenuLabel       = "Little Navmap Userpoints (FBOs)"
+	ExportMSFSDirectMenuLabel         = "MSFS Flight Plan (Direct)"
+	ExportMSFSViaFBOsMenuLabel        = "MSFS Flight Plan (via FBOs)"
+	ExportDOTMenuLabel                = "Graphviz DOT (FBO Network)"
+	PlanNewFBOsMenuLabel              = "Plan New FBOs"
+	ExplainCandidateScoreMenuLabel    = "Explain Candidate Score"
+	WhatIfScenariosMenuLabel          = "What-If Scenarios"
+	NewScenarioMenuLabel              = "New Scenario"
+	OpenScenarioMenuLabel             = "Open Saved Scenario"
+	OpenPlannedSetMenuLabel           = "Open Planned FBO Set"
+	SaveScenarioMenuLabel             = "Save Scenario"
+	CommitScenarioMenuLabel           = "Commit to Database"
+	DeleteScenarioMenuLabel           = "Delete Scenario"
+	NetworkVariantsMenuLabel          = "Network Variants"
+	CompareNetworkVariantsMenuLabel   = "Compare Network Variants"
+	NewNetworkVariantMenuLabel        = "New Network Variant"
+	DeleteNetworkVariantMenuLabel     = "Delete Network Variant"
+	NetworkHealthMenuLabel            = "Network Health"
+	FBOCatchmentsMenuLabel            = "FBO Catchments"
+	CoverageGapsMenuLabel             = "Find Coverage Gaps"
+	NetworkBackboneMenuLabel          = "Network Backbone"
+	FBOCentralityMenuLabel            = "FBO Hubs (Centrality)"
+	RelocationsMenuLabel              = "Suggest FBO Relocations"
+	RebalanceMenuLabel                = "Plan Rebalance"
+	RedundantFBOsMenuLabel            = "Find Redundant FBOs"
+	SweepMenuLabel                    = "Sweep Optimiser Settings"
+	SimulateJobsMenuLabel             = "Simulate Jobs"
+	RouteCorridorMenuLabel            = "Search Route Corridor"
+	AirportDemandMenuLabel            = "Airport Demand"
+	PinnedAirportsMenuLabel           = "Pinned & Excluded Airports"
+	ListPinsMenuLabel                 = "List Pinned & Excluded"
+	PinFBOMenuLabel                   = "Pin FBO"
+	UnpinFBOMenuLabel                 = "Unpin FBO"
+	ExcludeAirportMenuLabel           = "Exclude Airport"
+	IncludeAirportMenuLabel           = "Include Excluded Airport"
+	ExportNetworkHistoryMenuLabel     = "Network Health History (CSV)"
+	GreedyMenuLabel                   = "Greedy"
+	GreedyWithLocalSearchMenuLabel    = "Greedy with local search"
+	AnyMilitaryMenuLabel              = "Allow military and civilian"
+	NoMilitaryMenuLabel               = "Exclude military"
+	MilitaryOnlyMenuLabel             = "Military only"
 )
 
 // promptForAirportType prompts the user to select an airport type and updates the airport object
@@ -0,0 +1,66 @@
+package menu
+
+import (
+	"fmt"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/config"
+	"github.com/julietrb1/offair-cli/fbo"
+	"strconv"
+	"strings"
+)
+
+// SimulateJobs simulates random jobs against the FBO network and shows how many it can serve
+func SimulateJobs(db *sqlx.DB) {
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+
+	cfg := config.Load()
+	settings := cfg.SimulationSettings()
+
+	var jobsStr string
+	survey.AskOne(&survey.Input{
+		Message: "How many jobs should be simulated?",
+		Default: strconv.Itoa(settings.Jobs),
+	}, &jobsStr)
+	jobs, err := strconv.Atoi(strings.TrimSpace(jobsStr))
+	if err != nil || jobs < 1 || jobs > fbo.MaxSimulationJobs {
+		fmt.Printf("%s %s\n", color.RedString("Error:"), fmt.Sprintf("enter a whole number from 1 to %d", fbo.MaxSimulationJobs))
+		return
+	}
+	settings.Jobs = jobs
+
+	var rangeStr string
+	survey.AskOne(&survey.Input{
+		Message: "Aircraft range (nm):",
+		Default: strconv.FormatFloat(settings.Range, 'f', -1, 64),
+	}, &rangeStr)
+	aircraftRange, err := strconv.ParseFloat(strings.TrimSpace(rangeStr), 64)
+	if err != nil || aircraftRange <= 0 {
+		fmt.Printf("%s %s\n", color.RedString("Error:"), "enter a range greater than zero")
+		return
+	}
+	settings.Range = aircraftRange
+
+	var seedStr string
+	survey.AskOne(&survey.Input{
+		Message: "Random seed (the same seed repeats the same jobs):",
+		Default: strconv.FormatInt(settings.Seed, 10),
+	}, &seedStr)
+	seed, err := strconv.ParseInt(strings.TrimSpace(seedStr), 10, 64)
+	if err != nil {
+		fmt.Printf("%s %s\n", color.RedString("Error:"), "enter a whole number for the seed")
+		return
+	}
+	settings.Seed = seed
+
+	fmt.Println(bold(cyan("Simulating jobs...")))
+	result, err := fbo.SimulateJobs(db, settings)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	fmt.Println(result)
+}
@@ -0,0 +1,140 @@
+package menu
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/config"
+	"github.com/julietrb1/offair-cli/export"
+	"github.com/julietrb1/offair-cli/fbo"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Lights options for the parameter sweep
+const (
+	sweepLightsBothLabel        = "With and without lights required"
+	sweepLightsRequiredLabel    = "Lights required"
+	sweepLightsNotRequiredLabel = "Lights not required"
+)
+
+// SweepOptimalFBOLocations runs the optimal location analysis across a range of settings, shows which candidates
+// are robust, and offers to export the results as CSV
+func SweepOptimalFBOLocations(db *sqlx.DB) {
+	bold := color.New(color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+
+	cfg := config.Load()
+	ranges := cfg.SweepRanges()
+
+	var err error
+	ranges.OptimalDistances, err = promptForDistances("Optimal distances to try (nm, comma-separated):", ranges.OptimalDistances)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+	ranges.MaxDistances, err = promptForDistances("Max distances to try (nm, comma-separated):", ranges.MaxDistances)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	var lights string
+	survey.AskOne(&survey.Select{
+		Message: "Lights:",
+		Options: []string{sweepLightsBothLabel, sweepLightsRequiredLabel, sweepLightsNotRequiredLabel},
+	}, &lights)
+	switch lights {
+	case sweepLightsRequiredLabel:
+		ranges.RequireLights = []bool{true}
+	case sweepLightsNotRequiredLabel:
+		ranges.RequireLights = []bool{false}
+	}
+
+	var topNStr string
+	survey.AskOne(&survey.Input{
+		Message: "How many top candidates count as recommended under each setting?",
+		Default: strconv.Itoa(ranges.TopN),
+	}, &topNStr)
+	ranges.TopN, err = strconv.Atoi(strings.TrimSpace(topNStr))
+	if err != nil || ranges.TopN < 1 {
+		fmt.Printf("%s %s\n", color.RedString("Error:"), "enter a whole number of 1 or more")
+		return
+	}
+
+	scorer, err := cfg.NewScorer()
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	filter, ok := promptForCandidateFilter(cfg.CandidateFilter)
+	if !ok {
+		return
+	}
+
+	fmt.Println(bold(cyan("Running the sweep...")))
+	analysis, err := fbo.AnalyseOptimalLocationSweep(db, ranges, cfg.PreferredSize, filter, scorer)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+	fmt.Println(fbo.FormatSweep(analysis, filter, scorer))
+
+	exportCSV := false
+	survey.AskOne(&survey.Confirm{
+		Message: "Export the sweep as CSV?",
+	}, &exportCSV)
+	if !exportCSV {
+		return
+	}
+
+	path := promptForExportPath("offair_sweep.csv")
+	if path == "" {
+		return
+	}
+
+	// Build the CSV before writing the file, so an error doesn't leave a truncated file behind
+	var sweep bytes.Buffer
+	if err := export.WriteSweepCSV(&sweep, analysis); err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	if err := os.WriteFile(path, sweep.Bytes(), 0644); err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error creating file:"), err)
+		return
+	}
+
+	fmt.Printf("%s %d %s %s\n\n",
+		color.GreenString("Exported"),
+		len(analysis.Candidates),
+		color.GreenString("candidates to"),
+		bold(path))
+}
+
+// promptForDistances prompts for a comma-separated list of distances, defaulting to defaults
+func promptForDistances(message string, defaults []float64) ([]float64, error) {
+	var values []string
+	for _, distance := range defaults {
+		values = append(values, strconv.FormatFloat(distance, 'f', -1, 64))
+	}
+
+	var answer string
+	survey.AskOne(&survey.Input{
+		Message: message,
+		Default: strings.Join(values, ","),
+	}, &answer)
+
+	distances, err := config.ParseDistances(answer)
+	if err != nil {
+		return nil, err
+	}
+	if len(distances) == 0 {
+		return nil, fmt.Errorf("enter at least one distance")
+	}
+	return distances, nil
+}
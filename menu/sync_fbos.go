@@ -158,6 +158,7 @@ func SyncFBOs(db *sqlx.DB) {
 	}
 
 	fmt.Printf("%s FBOs synchronized successfully.\n", color.GreenString("Success:"))
+	recordNetworkSnapshot(db, "synced FBOs from OnAir")
 	fmt.Printf("  Added: %d\n", added)
 	fmt.Printf("  Updated: %d\n", updated)
 	fmt.Printf("  Unchanged: %d\n", unchanged)
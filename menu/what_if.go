@@ -0,0 +1,207 @@
+package menu
+
+import (
+	"fmt"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/jmoiron/sqlx"
+	"github.com/julietrb1/offair-cli/config"
+	"github.com/julietrb1/offair-cli/fbo"
+	"strings"
+)
+
+// WhatIfScenarios displays the what-if scenario menu and handles user selection
+func WhatIfScenarios(db *sqlx.DB) {
+	for {
+		var option string
+		prompt := &survey.Select{
+			Message: "What-if scenarios:",
+			Options: []string{
+				NewScenarioMenuLabel,
+				OpenScenarioMenuLabel,
+				OpenPlannedSetMenuLabel,
+				BackMenuLabel,
+			},
+		}
+		survey.AskOne(prompt, &option)
+
+		switch option {
+		case NewScenarioMenuLabel:
+			newScenario(db)
+		case OpenScenarioMenuLabel:
+			openScenario(db)
+		case OpenPlannedSetMenuLabel:
+			openPlannedSet(db)
+		default:
+			return
+		}
+	}
+}
+
+// newScenario prompts for hypothetical FBO changes and compares the network with them applied
+func newScenario(db *sqlx.DB) {
+	var add, remove string
+	survey.AskOne(&survey.Input{
+		Message: "ICAOs of FBOs to add (comma-separated, blank for none):",
+	}, &add)
+	survey.AskOne(&survey.Input{
+		Message: "ICAOs of FBOs to remove (comma-separated, blank for none):",
+	}, &remove)
+
+	scenario := fbo.Scenario{Add: parseICAOs(add), Remove: parseICAOs(remove)}
+	if len(scenario.Add) == 0 && len(scenario.Remove) == 0 {
+		return
+	}
+
+	scenarioActions(db, scenario)
+}
+
+// openScenario lets the user pick a saved scenario and compares the network with it applied
+func openScenario(db *sqlx.DB) {
+	names, err := fbo.ListScenarios(db)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+	if len(names) == 0 {
+		fmt.Println(color.YellowString("No saved scenarios."))
+		return
+	}
+
+	var name string
+	survey.AskOne(&survey.Select{
+		Message: "Scenario:",
+		Options: append(names, BackMenuLabel),
+	}, &name)
+	if name == BackMenuLabel || name == "" {
+		return
+	}
+
+	scenario, err := fbo.GetScenario(db, name)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+
+	scenarioActions(db, scenario)
+}
+
+// openPlannedSet lets the user pick a saved planned FBO set and compares the network with its FBOs added
+func openPlannedSet(db *sqlx.DB) {
+	bold := color.New(color.Bold).SprintFunc()
+
+	names, err := fbo.ListPlannedFBOSets(db)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+	if len(names) == 0 {
+		fmt.Println(color.YellowString("No saved planned FBO sets. Import one from the Import & Export menu."))
+		return
+	}
+
+	var name string
+	survey.AskOne(&survey.Select{
+		Message: "Planned FBO set:",
+		Options: append(names, BackMenuLabel),
+	}, &name)
+	if name == BackMenuLabel || name == "" {
+		return
+	}
+
+	scenario, existing, err := fbo.ScenarioFromPlannedFBOSet(db, name)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+	for _, airport := range existing {
+		fmt.Printf("%s %s\n", bold(airport.ICAO), color.YellowString("already has an FBO."))
+	}
+	if len(scenario.Add) == 0 {
+		fmt.Println(color.YellowString("Every airport in the planned set already has an FBO."))
+		return
+	}
+
+	scenarioActions(db, scenario)
+}
+
+// scenarioActions shows a scenario's before/after comparison, then lets the user save, commit or delete it
+func scenarioActions(db *sqlx.DB, scenario fbo.Scenario) {
+	bold := color.New(color.Bold).SprintFunc()
+
+	cfg := config.Load()
+	result, err := fbo.WhatIfScenario(db, scenario, cfg.OptimalDistance, cfg.MaxDistance, cfg.CoverageRadius)
+	if err != nil {
+		fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+		return
+	}
+	fmt.Println(result)
+
+	for {
+		options := []string{SaveScenarioMenuLabel, CommitScenarioMenuLabel}
+		if scenario.Name != "" {
+			options = append(options, DeleteScenarioMenuLabel)
+		}
+		options = append(options, BackMenuLabel)
+
+		var option string
+		survey.AskOne(&survey.Select{
+			Message: "Scenario:",
+			Options: options,
+		}, &option)
+
+		switch option {
+		case SaveScenarioMenuLabel:
+			var name string
+			survey.AskOne(&survey.Input{
+				Message: "Enter a name for the scenario (blank to cancel):",
+				Default: scenario.Name,
+			}, &name)
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+
+			scenario.Name = name
+			if err := fbo.SaveScenario(db, scenario); err != nil {
+				fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+				continue
+			}
+			fmt.Printf("%s %s\n", color.GreenString("Saved scenario"), bold(name))
+		case CommitScenarioMenuLabel:
+			proceed := false
+			survey.AskOne(&survey.Confirm{
+				Message: fmt.Sprintf("Add %d and remove %d FBOs in your database?", len(scenario.Add), len(scenario.Remove)),
+			}, &proceed)
+			if !proceed {
+				continue
+			}
+
+			if err := fbo.CommitScenario(db, scenario); err != nil {
+				fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+				continue
+			}
+			fmt.Println(color.GreenString("Scenario committed to the database."))
+			recordNetworkSnapshot(db, "committed what-if scenario")
+			return
+		case DeleteScenarioMenuLabel:
+			if err := fbo.DeleteScenario(db, scenario.Name); err != nil {
+				fmt.Printf("%s %v\n", color.RedString("Error:"), err)
+				continue
+			}
+			fmt.Printf("%s %s\n", color.GreenString("Deleted scenario"), bold(scenario.Name))
+			return
+		default:
+			return
+		}
+	}
+}
+
+// parseICAOs splits a comma- or space-separated list of ICAOs, upper-casing each
+func parseICAOs(list string) []string {
+	var icaos []string
+	for _, icao := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == ' ' }) {
+		icaos = append(icaos, strings.ToUpper(icao))
+	}
+	return icaos
+}
@@ -33,6 +33,12 @@ type Airport struct {
 	DisplayName    *string  `json:"display_name" db:"display_name"`
 	HasFBO         bool     `json:"has_fbo" db:"has_fbo"`
 	AirportType    *string  `json:"airport_type" db:"airport_type"`
+	// DemandWeight is a user-assigned demand weight, overriding the one derived from the airport's details
+	DemandWeight *float64 `json:"demand_weight" db:"demand_weight"`
+	// IsPinned keeps the airport's FBO out of removal and relocation suggestions
+	IsPinned bool `json:"is_pinned" db:"is_pinned"`
+	// IsExcluded keeps the airport out of new FBO recommendations
+	IsExcluded bool `json:"is_excluded" db:"is_excluded"`
 }
 
 // FBO represents a Fixed Base of Operations
@@ -0,0 +1,295 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	database "github.com/julietrb1/offair-cli/db"
+	"github.com/julietrb1/offair-cli/fbo"
+	"github.com/julietrb1/offair-cli/models"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// nearbyAirport is an airport and its distance from the search point
+type nearbyAirport struct {
+	models.Airport
+	Distance float64 `json:"distance"`
+}
+
+// airportUpdate holds the curated airport fields that can be changed, nil meaning unchanged
+type airportUpdate struct {
+	Name        *string `json:"name"`
+	CountryCode *string `json:"country_code"`
+	IATA        *string `json:"iata"`
+	State       *string `json:"state"`
+	CountryName *string `json:"country_name"`
+	City        *string `json:"city"`
+	AirportType *string `json:"airport_type"`
+	// DemandWeight is a number to assign the demand weight, or null to go back to the derived weight
+	DemandWeight json.RawMessage `json:"demand_weight"`
+	IsPinned     *bool           `json:"is_pinned"`
+	IsExcluded   *bool           `json:"is_excluded"`
+}
+
+// listAirports lists all airports in the local database
+func (s *server) listAirports(w http.ResponseWriter, r *http.Request) {
+	airports := []models.Airport{}
+	if err := s.db.Select(&airports, "SELECT * FROM airports ORDER BY icao"); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("error fetching airports: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, airports)
+}
+
+// pinnedAirports lists the pinned FBOs and excluded airports
+func (s *server) pinnedAirports(w http.ResponseWriter, r *http.Request) {
+	pins, err := fbo.LoadPinnedAirports(s.db)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, pins)
+}
+
+// getAirport returns a single airport by ICAO
+func (s *server) getAirport(w http.ResponseWriter, r *http.Request) {
+	airport, err := s.airportByICAO(r.PathValue("icao"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, airport)
+}
+
+// createAirport adds an airport to the local database
+func (s *server) createAirport(w http.ResponseWriter, r *http.Request) {
+	var airport models.Airport
+	if err := json.NewDecoder(r.Body).Decode(&airport); err != nil {
+		badRequest(w, "invalid airport: %v", err)
+		return
+	}
+
+	airport.ICAO = strings.ToUpper(strings.TrimSpace(airport.ICAO))
+	if airport.ICAO == "" || airport.Name == "" || airport.CountryCode == "" {
+		badRequest(w, "icao, name and country_code are required")
+		return
+	}
+	if airport.ID == "" {
+		airport.ID = airport.ICAO
+	}
+	// FBOs are added through the FBO endpoints so the fbos table stays in step
+	airport.HasFBO = false
+
+	err := database.InsertAirport(s.db, airport)
+	if err != nil {
+		writeError(w, http.StatusConflict, fmt.Errorf("error inserting airport %s: %w", airport.ICAO, err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, airport)
+}
+
+// updateAirport changes the curated fields of an airport
+func (s *server) updateAirport(w http.ResponseWriter, r *http.Request) {
+	airport, err := s.airportByICAO(r.PathValue("icao"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var update airportUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		badRequest(w, "invalid airport update: %v", err)
+		return
+	}
+
+	if update.Name != nil {
+		airport.Name = *update.Name
+	}
+	if update.CountryCode != nil {
+		airport.CountryCode = strings.ToUpper(*update.CountryCode)
+	}
+	if update.AirportType != nil && *update.AirportType != "" && *update.AirportType != "AD" && *update.AirportType != "ALA" {
+		badRequest(w, "airport_type must be AD, ALA or blank")
+		return
+	}
+
+	// Blank optional fields clear them, as in the airport menu
+	for _, field := range []struct {
+		value  *string
+		target **string
+	}{
+		{update.IATA, &airport.IATA},
+		{update.State, &airport.State},
+		{update.CountryName, &airport.CountryName},
+		{update.City, &airport.City},
+		{update.AirportType, &airport.AirportType},
+	} {
+		if field.value == nil {
+			continue
+		}
+		if *field.value == "" {
+			*field.target = nil
+		} else {
+			value := *field.value
+			*field.target = &value
+		}
+	}
+
+	if update.DemandWeight != nil {
+		airport.DemandWeight = nil
+		if string(update.DemandWeight) != "null" {
+			var weight float64
+			if err := json.Unmarshal(update.DemandWeight, &weight); err != nil || weight < 0 {
+				badRequest(w, "demand_weight must be a number of 0 or more, or null")
+				return
+			}
+			airport.DemandWeight = &weight
+		}
+	}
+
+	if update.IsPinned != nil {
+		airport.IsPinned = *update.IsPinned
+	}
+	if update.IsExcluded != nil {
+		airport.IsExcluded = *update.IsExcluded
+	}
+
+	_, err = s.db.NamedExec(`
+		UPDATE airports SET
+			name = :name, country_code = :country_code, iata = :iata, state = :state,
+			country_name = :country_name, city = :city, airport_type = :airport_type,
+			demand_weight = :demand_weight, is_pinned = :is_pinned, is_excluded = :is_excluded
+		WHERE id = :id
+	`, airport)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("error updating airport: %w", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, airport)
+}
+
+// deleteAirport removes an airport from the local database, as long as it has no FBO
+func (s *server) deleteAirport(w http.ResponseWriter, r *http.Request) {
+	airport, err := s.airportByICAO(r.PathValue("icao"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if airport.HasFBO {
+		writeError(w, http.StatusConflict, fmt.Errorf("airport %s has an FBO, remove it first", airport.ICAO))
+		return
+	}
+
+	if _, err := s.db.Exec("DELETE FROM airports WHERE id = ?", airport.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("error deleting airport: %w", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// nearbyAirports lists airports within a radius of an ICAO or a latitude/longitude, nearest first
+func (s *server) nearbyAirports(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	radius := 100.0
+	if radiusStr := query.Get("radius"); radiusStr != "" {
+		var err error
+		radius, err = strconv.ParseFloat(radiusStr, 64)
+		if err != nil || radius <= 0 {
+			badRequest(w, "radius must be a positive number of nm")
+			return
+		}
+	}
+
+	var lat, lon float64
+	if icao := query.Get("icao"); icao != "" {
+		origin, err := s.airportByICAO(icao)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if origin.Latitude == nil || origin.Longitude == nil {
+			badRequest(w, "airport %s does not have latitude or longitude information", origin.ICAO)
+			return
+		}
+		lat, lon = *origin.Latitude, *origin.Longitude
+	} else {
+		var latErr, lonErr error
+		lat, latErr = strconv.ParseFloat(query.Get("lat"), 64)
+		lon, lonErr = strconv.ParseFloat(query.Get("lon"), 64)
+		if latErr != nil || lonErr != nil {
+			badRequest(w, "either icao or lat and lon are required")
+			return
+		}
+	}
+
+	var airports []models.Airport
+	if err := s.db.Select(&airports, "SELECT * FROM airports WHERE latitude IS NOT NULL AND longitude IS NOT NULL"); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("error fetching airports: %w", err))
+		return
+	}
+
+	nearby := []nearbyAirport{}
+	for _, airport := range airports {
+		distance := fbo.CalculateDistance(lat, lon, *airport.Latitude, *airport.Longitude)
+		if distance <= radius {
+			nearby = append(nearby, nearbyAirport{Airport: airport, Distance: distance})
+		}
+	}
+
+	sort.Slice(nearby, func(i, j int) bool {
+		return nearby[i].Distance < nearby[j].Distance
+	})
+
+	writeJSON(w, http.StatusOK, nearby)
+}
+
+// distance returns the distance between two airports
+func (s *server) distance(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if strings.TrimSpace(query.Get("from")) == "" || strings.TrimSpace(query.Get("to")) == "" {
+		badRequest(w, "from and to are required")
+		return
+	}
+	from, err := s.airportByICAO(query.Get("from"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	to, err := s.airportByICAO(query.Get("to"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	for _, airport := range []models.Airport{from, to} {
+		if airport.Latitude == nil || airport.Longitude == nil {
+			badRequest(w, "airport %s does not have latitude or longitude information", airport.ICAO)
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"from":     from.ICAO,
+		"to":       to.ICAO,
+		"distance": fbo.CalculateDistance(*from.Latitude, *from.Longitude, *to.Latitude, *to.Longitude),
+	})
+}
+
+// airportByICAO fetches an airport from the local database by ICAO
+func (s *server) airportByICAO(icao string) (models.Airport, error) {
+	icao = strings.ToUpper(strings.TrimSpace(icao))
+
+	var airport models.Airport
+	err := s.db.Get(&airport, "SELECT * FROM airports WHERE icao = ?", icao)
+	if err != nil {
+		return models.Airport{}, fmt.Errorf("airport with ICAO %s not found: %w", icao, err)
+	}
+	return airport, nil
+}
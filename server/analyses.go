@@ -0,0 +1,627 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/julietrb1/offair-cli/config"
+	"github.com/julietrb1/offair-cli/fbo"
+	"github.com/julietrb1/offair-cli/models"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// analysisDefaults returns the configured analysis settings, which the query overrides start from
+func (s *server) analysisDefaults(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, config.Load())
+}
+
+// optimalLocations runs the optimal FBO location analysis
+func (s *server) optimalLocations(w http.ResponseWriter, r *http.Request) {
+	cfg, err := analysisConfig(r.URL.Query(), candidateParams, []string{"optimal", "lights"})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	scorer, err := cfg.NewScorer()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	limit := 10
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			badRequest(w, "limit must be a positive whole number")
+			return
+		}
+	}
+
+	analysis, err := fbo.ScoreOptimalFBOLocations(s.db, cfg.OptimalDistance, cfg.RequireLights, cfg.PreferredSize, cfg.CandidateFilter, scorer)
+	if err != nil {
+		writeAnalysisError(w, err)
+		return
+	}
+
+	if len(analysis.Candidates) > limit {
+		analysis.Candidates = analysis.Candidates[:limit]
+	}
+	if analysis.Candidates == nil {
+		analysis.Candidates = []fbo.CandidateScore{}
+	}
+
+	// Only note the excluded airports that would have made the list
+	pinOverrides := []fbo.PinOverride{}
+	for _, override := range analysis.PinOverrides {
+		if override.Rank <= limit {
+			pinOverrides = append(pinOverrides, override)
+		}
+	}
+	analysis.PinOverrides = pinOverrides
+
+	writeJSON(w, http.StatusOK, analysis)
+}
+
+// explainOptimalLocation breaks down how one airport scores as a location for a new FBO
+func (s *server) explainOptimalLocation(w http.ResponseWriter, r *http.Request) {
+	cfg, err := analysisConfig(r.URL.Query(), candidateParams, []string{"optimal", "lights"})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	scorer, err := cfg.NewScorer()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	airport, err := s.airportByICAO(r.PathValue("icao"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	explanation, err := fbo.ExplainCandidateScore(s.db, airport.ICAO, cfg.OptimalDistance, cfg.RequireLights, cfg.PreferredSize, cfg.CandidateFilter, scorer)
+	if err != nil {
+		writeAnalysisError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, explanation)
+}
+
+// whatIf compares the FBO network before and after the hypothetical changes in the body, without changing it
+func (s *server) whatIf(w http.ResponseWriter, r *http.Request) {
+	cfg, err := analysisConfig(r.URL.Query(), networkParams)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var scenario fbo.Scenario
+	if err := json.NewDecoder(r.Body).Decode(&scenario); err != nil {
+		badRequest(w, "a JSON body with add and/or remove ICAO lists is required")
+		return
+	}
+	for i := range scenario.Add {
+		scenario.Add[i] = strings.ToUpper(strings.TrimSpace(scenario.Add[i]))
+	}
+	for i := range scenario.Remove {
+		scenario.Remove[i] = strings.ToUpper(strings.TrimSpace(scenario.Remove[i]))
+	}
+
+	comparison, err := fbo.CompareScenario(s.db, scenario, cfg.OptimalDistance, cfg.MaxDistance, cfg.CoverageRadius)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if comparison.Added == nil {
+		comparison.Added = []models.Airport{}
+	}
+	if comparison.Removed == nil {
+		comparison.Removed = []models.Airport{}
+	}
+
+	writeJSON(w, http.StatusOK, comparison)
+}
+
+// catchments assigns every airport to its nearest FBO
+func (s *server) catchments(w http.ResponseWriter, r *http.Request) {
+	cfg, err := analysisConfig(r.URL.Query(), []string{"max"})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	analysis, err := fbo.AnalyseFBOCatchments(s.db, cfg.MaxDistance)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, analysis)
+}
+
+// coverageGaps finds regions inside the network without an FBO within the coverage radius
+func (s *server) coverageGaps(w http.ResponseWriter, r *http.Request) {
+	cfg, err := analysisConfig(r.URL.Query(), candidateParams, []string{"optimal", "lights"})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	scorer, err := cfg.NewScorer()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	limit := 10
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			badRequest(w, "limit must be a positive whole number")
+			return
+		}
+	}
+
+	analysis, err := fbo.AnalyseCoverageGaps(s.db, cfg.CoverageRadius, limit, cfg.OptimalDistance, cfg.RequireLights, cfg.PreferredSize, cfg.CandidateFilter, scorer)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, analysis)
+}
+
+// clusters groups the FBOs into clusters of nearby FBOs
+func (s *server) clusters(w http.ResponseWriter, r *http.Request) {
+	cfg, err := analysisConfig(r.URL.Query(), clusterParams)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := cfg.Clustering.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	analysis, err := fbo.AnalyseFBOClusters(s.db, cfg.Clustering)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, analysis)
+}
+
+// backbone builds the minimum spanning tree over the FBO network
+func (s *server) backbone(w http.ResponseWriter, r *http.Request) {
+	cfg, err := analysisConfig(r.URL.Query(), []string{"max"})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	backbone, err := fbo.AnalyseNetworkBackbone(s.db, cfg.MaxDistance)
+	if err != nil {
+		writeAnalysisError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, backbone)
+}
+
+// centrality ranks the FBOs by betweenness, closeness and degree
+func (s *server) centrality(w http.ResponseWriter, r *http.Request) {
+	cfg, err := analysisConfig(r.URL.Query(), []string{"max"})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	analysis, err := fbo.AnalyseFBOCentrality(s.db, cfg.MaxDistance)
+	if err != nil {
+		writeAnalysisError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, analysis)
+}
+
+// relocations suggests moves of existing FBOs to nearby airports that improve the network
+func (s *server) relocations(w http.ResponseWriter, r *http.Request) {
+	cfg, err := analysisConfig(r.URL.Query(), candidateParams, networkParams, []string{"lights", "radius"})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	scorer, err := cfg.NewScorer()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	analysis, err := fbo.AnalyseFBORelocations(s.db, cfg.RelocationRadius, cfg.OptimalDistance, cfg.MaxDistance, cfg.CoverageRadius, cfg.RequireLights, cfg.PreferredSize, cfg.CandidateFilter, scorer)
+	if err != nil {
+		writeAnalysisError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, analysis)
+}
+
+// rebalance plans FBOs to open and close within a maximum count or budget
+func (s *server) rebalance(w http.ResponseWriter, r *http.Request) {
+	cfg, err := analysisConfig(r.URL.Query(), candidateParams, networkParams, []string{"lights"})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	scorer, err := cfg.NewScorer()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	budget := cfg.Rebalance
+	if maxFBOs := r.URL.Query().Get("max_fbos"); maxFBOs != "" {
+		budget.MaxFBOs, err = strconv.Atoi(maxFBOs)
+		if err != nil || budget.MaxFBOs < 0 {
+			badRequest(w, "max_fbos must be a whole number")
+			return
+		}
+	}
+	if budgetStr := r.URL.Query().Get("budget"); budgetStr != "" {
+		budget.Budget, err = strconv.ParseFloat(budgetStr, 64)
+		if err != nil || budget.Budget < 0 {
+			badRequest(w, "budget must be a number")
+			return
+		}
+	}
+	if costs := r.URL.Query().Get("size_costs"); costs != "" {
+		budget.SizeCosts, err = config.ParseSizeCosts(costs)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if err := budget.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	plan, err := fbo.PlanFBORebalance(s.db, budget, cfg.OptimalDistance, cfg.MaxDistance, cfg.CoverageRadius, cfg.RequireLights, cfg.PreferredSize, cfg.CandidateFilter, scorer)
+	if err != nil {
+		writeAnalysisError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, plan)
+}
+
+// demand reports airport demand weights and how much demand is within the optimal distance of an FBO
+func (s *server) demand(w http.ResponseWriter, r *http.Request) {
+	cfg, err := analysisConfig(r.URL.Query(), []string{"optimal"})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, err := cfg.NewScorer(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			badRequest(w, "limit must be a positive whole number")
+			return
+		}
+	}
+
+	analysis, err := fbo.AnalyseAirportDemand(s.db, cfg.DemandWeights(), cfg.OptimalDistance)
+	if err != nil {
+		writeAnalysisError(w, err)
+		return
+	}
+
+	if limit > 0 && len(analysis.Airports) > limit {
+		analysis.Airports = analysis.Airports[:limit]
+	}
+
+	writeJSON(w, http.StatusOK, analysis)
+}
+
+// simulation simulates random jobs against the FBO network, reporting how many can be served
+func (s *server) simulation(w http.ResponseWriter, r *http.Request) {
+	cfg, err := analysisConfig(r.URL.Query(), []string{"max"})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// The range follows max unless range is given
+	settings := cfg.SimulationSettings()
+	if jobsStr := r.URL.Query().Get("jobs"); jobsStr != "" {
+		settings.Jobs, err = strconv.Atoi(jobsStr)
+		if err != nil || settings.Jobs < 1 || settings.Jobs > fbo.MaxSimulationJobs {
+			badRequest(w, "jobs must be a whole number from 1 to %d", fbo.MaxSimulationJobs)
+			return
+		}
+	}
+	if rangeStr := r.URL.Query().Get("range"); rangeStr != "" {
+		settings.Range, err = strconv.ParseFloat(rangeStr, 64)
+		if err != nil || settings.Range <= 0 {
+			badRequest(w, "range must be a number greater than zero")
+			return
+		}
+	}
+	if seedStr := r.URL.Query().Get("seed"); seedStr != "" {
+		settings.Seed, err = strconv.ParseInt(seedStr, 10, 64)
+		if err != nil {
+			badRequest(w, "seed must be a whole number")
+			return
+		}
+	}
+
+	simulation, err := fbo.AnalyseJobSimulation(s.db, settings)
+	if err != nil {
+		writeAnalysisError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, simulation)
+}
+
+// corridor lists the airports and FBOs along the great-circle route between two airports
+func (s *server) corridor(w http.ResponseWriter, r *http.Request) {
+	cfg, err := analysisConfig(r.URL.Query(), []string{"max"})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	query := r.URL.Query()
+	if query.Get("from") == "" || query.Get("to") == "" {
+		badRequest(w, "from and to are required")
+		return
+	}
+	from, err := s.airportByICAO(query.Get("from"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	to, err := s.airportByICAO(query.Get("to"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	width := cfg.CorridorWidth
+	if widthStr := query.Get("width"); widthStr != "" {
+		width, err = strconv.ParseFloat(widthStr, 64)
+		if err != nil || width <= 0 {
+			badRequest(w, "width must be a positive number of nm")
+			return
+		}
+	}
+
+	corridor, err := fbo.AnalyseCorridor(s.db, from.ICAO, to.ICAO, width, cfg.MaxDistance)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, corridor)
+}
+
+// sweep runs the optimal location analysis across ranges of settings, reporting robust and fragile candidates
+func (s *server) sweep(w http.ResponseWriter, r *http.Request) {
+	cfg, err := analysisConfig(r.URL.Query(), candidateParams, []string{"optimal", "max"})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	scorer, err := cfg.NewScorer()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ranges := cfg.SweepRanges()
+	for name, target := range map[string]*[]float64{
+		"optimal_range": &ranges.OptimalDistances,
+		"max_range":     &ranges.MaxDistances,
+	} {
+		if value := r.URL.Query().Get(name); value != "" {
+			*target, err = config.ParseDistances(value)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("%s: %w", name, err))
+				return
+			}
+		}
+	}
+	if lightsRange := r.URL.Query().Get("lights_range"); lightsRange != "" {
+		ranges.RequireLights = nil
+		for _, value := range strings.Split(lightsRange, ",") {
+			requireLights, err := strconv.ParseBool(strings.TrimSpace(value))
+			if err != nil {
+				badRequest(w, "lights_range must be a comma-separated list of true and false")
+				return
+			}
+			ranges.RequireLights = append(ranges.RequireLights, requireLights)
+		}
+	}
+	if top := r.URL.Query().Get("top"); top != "" {
+		ranges.TopN, err = strconv.Atoi(top)
+		if err != nil || ranges.TopN < 1 {
+			badRequest(w, "top must be a positive whole number")
+			return
+		}
+	}
+	if err := ranges.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	analysis, err := fbo.AnalyseOptimalLocationSweep(s.db, ranges, cfg.PreferredSize, cfg.CandidateFilter, scorer)
+	if err != nil {
+		writeAnalysisError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, analysis)
+}
+
+// redundantFBOs runs the redundant FBO analysis
+func (s *server) redundantFBOs(w http.ResponseWriter, r *http.Request) {
+	cfg, err := analysisConfig(r.URL.Query(), candidateParams, []string{"optimal", "lights", "threshold"})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	scorer, err := cfg.NewScorer()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	analysis, err := fbo.AnalyseRedundantFBOs(s.db, cfg.OptimalDistance, cfg.RequireLights, cfg.PreferredSize, cfg.RedundancyThreshold, cfg.CandidateFilter, scorer)
+	if err != nil {
+		writeAnalysisError(w, err)
+		return
+	}
+
+	if analysis.Redundant == nil {
+		analysis.Redundant = []fbo.RedundantFBO{}
+	}
+
+	writeJSON(w, http.StatusOK, analysis)
+}
+
+// Analysis settings the query can override, grouped by what uses them. Each endpoint passes the groups it uses to
+// analysisConfig, which rejects the others.
+var (
+	// networkParams are used to summarise a network's legs and coverage
+	networkParams = []string{"optimal", "max", "coverage"}
+	// candidateParams are used to score candidate airports, with the candidate filter settings; the coverage radius
+	// is one of the scorer's settings
+	candidateParams = append([]string{"size", "scorer", "coverage"}, config.CandidateFilterFields...)
+	// clusterParams are used to cluster the FBOs
+	clusterParams = []string{"cluster_algorithm", "cluster_radius", "cluster_min_points"}
+)
+
+// analysisConfig starts from the configured analysis settings and applies any overrides in the query:
+// optimal, max, lights, size, threshold, coverage, radius, scorer and the cluster settings, plus the candidate
+// filter settings. Overrides outside params, the groups of settings the endpoint uses, are rejected.
+func analysisConfig(query url.Values, params ...[]string) (config.Config, error) {
+	cfg := config.Load()
+
+	used := make(map[string]bool)
+	for _, group := range params {
+		for _, name := range group {
+			used[name] = true
+		}
+	}
+	overrides := append([]string{"optimal", "max", "lights", "size", "threshold", "coverage", "radius", "scorer"}, clusterParams...)
+	overrides = append(overrides, config.CandidateFilterFields...)
+	for _, name := range overrides {
+		if _, ok := query[name]; ok && !used[name] {
+			return config.Config{}, fmt.Errorf("%s isn't used by this endpoint", name)
+		}
+	}
+
+	for name, target := range map[string]*float64{
+		"optimal":   &cfg.OptimalDistance,
+		"max":       &cfg.MaxDistance,
+		"threshold": &cfg.RedundancyThreshold,
+		"coverage":  &cfg.CoverageRadius,
+		"radius":    &cfg.RelocationRadius,
+	} {
+		if value := query.Get(name); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil || parsed <= 0 {
+				return config.Config{}, fmt.Errorf("%s must be a number greater than zero", name)
+			}
+			*target = parsed
+		}
+	}
+
+	if cfg.OptimalDistance <= 0 {
+		return config.Config{}, fmt.Errorf("optimal must be greater than zero")
+	}
+
+	if lights := query.Get("lights"); lights != "" {
+		requireLights, err := strconv.ParseBool(lights)
+		if err != nil {
+			return config.Config{}, fmt.Errorf("lights must be true or false")
+		}
+		cfg.RequireLights = requireLights
+	}
+
+	if scorer := query.Get("scorer"); scorer != "" {
+		cfg.ScorerName = scorer
+	}
+
+	if algorithm := query.Get("cluster_algorithm"); algorithm != "" {
+		cfg.Clustering.Algorithm = strings.ToLower(algorithm)
+	}
+	if radius := query.Get("cluster_radius"); radius != "" {
+		parsed, err := strconv.ParseFloat(radius, 64)
+		if err != nil {
+			return config.Config{}, fmt.Errorf("cluster_radius must be a number")
+		}
+		cfg.Clustering.Radius = parsed
+	}
+	if minPoints := query.Get("cluster_min_points"); minPoints != "" {
+		parsed, err := strconv.Atoi(minPoints)
+		if err != nil {
+			return config.Config{}, fmt.Errorf("cluster_min_points must be a whole number")
+		}
+		cfg.Clustering.MinPoints = parsed
+	}
+
+	if size := query.Get("size"); size != "" {
+		cfg.PreferredSize = config.ParsePreferredSize(size)
+		if cfg.PreferredSize == nil {
+			return config.Config{}, fmt.Errorf("size must be a whole number from 0 to 5")
+		}
+	}
+
+	// Any filter setting in the query replaces the configured filter
+	for _, name := range config.CandidateFilterFields {
+		if _, ok := query[name]; ok {
+			filter, err := config.ParseCandidateFilter(query.Get)
+			if err != nil {
+				return config.Config{}, err
+			}
+			cfg.CandidateFilter = filter
+			break
+		}
+	}
+
+	return cfg, nil
+}
+
+// writeAnalysisError writes an analysis error, treating too few FBOs as a client-side problem
+func writeAnalysisError(w http.ResponseWriter, err error) {
+	var insufficient *fbo.InsufficientFBOsError
+	if errors.As(err, &insufficient) {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err)
+}
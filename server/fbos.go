@@ -0,0 +1,95 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/julietrb1/offair-cli/fbo"
+	"github.com/julietrb1/offair-cli/models"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// listFBOs lists all airports with FBOs
+func (s *server) listFBOs(w http.ResponseWriter, r *http.Request) {
+	airports, err := fbo.ListAirportsWithFBOs(s.db)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if airports == nil {
+		airports = []models.Airport{}
+	}
+	writeJSON(w, http.StatusOK, airports)
+}
+
+// addFBO adds an FBO at an airport already in the local database
+func (s *server) addFBO(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		ICAO string `json:"icao"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ICAO == "" {
+		badRequest(w, "a JSON body with an icao is required")
+		return
+	}
+	icao := strings.ToUpper(strings.TrimSpace(body.ICAO))
+
+	if err := fbo.AddFBO(s.db, icao); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	s.recordNetworkSnapshot("added FBO at " + icao)
+
+	airport, err := s.airportByICAO(icao)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, airport)
+}
+
+// removeFBO removes the FBO at an airport
+func (s *server) removeFBO(w http.ResponseWriter, r *http.Request) {
+	icao := strings.ToUpper(strings.TrimSpace(r.PathValue("icao")))
+
+	if err := fbo.RemoveFBO(s.db, icao); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	s.recordNetworkSnapshot("removed FBO at " + icao)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// nearestFBOs lists the FBOs closest to an airport, nearest first
+func (s *server) nearestFBOs(w http.ResponseWriter, r *http.Request) {
+	airport, err := s.airportByICAO(r.PathValue("icao"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if airport.Latitude == nil || airport.Longitude == nil {
+		badRequest(w, "airport %s does not have latitude or longitude information", airport.ICAO)
+		return
+	}
+
+	limit := 5
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			badRequest(w, "limit must be a positive whole number")
+			return
+		}
+	}
+
+	fbos, err := fbo.ListAirportsWithFBOs(s.db)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	nearest := fbo.NearestFBOs(airport, fbos, limit)
+	if nearest == nil {
+		nearest = []fbo.NearbyFBO{}
+	}
+	writeJSON(w, http.StatusOK, nearest)
+}
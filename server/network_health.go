@@ -0,0 +1,60 @@
+package server
+
+import (
+	"github.com/julietrb1/offair-cli/config"
+	"github.com/julietrb1/offair-cli/fbo"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// networkHealth summarises the FBO network's metrics, connectivity and coverage
+func (s *server) networkHealth(w http.ResponseWriter, r *http.Request) {
+	cfg, err := analysisConfig(r.URL.Query(), networkParams)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	summary, err := fbo.AnalyseNetworkHealth(s.db, cfg.OptimalDistance, cfg.MaxDistance, cfg.CoverageRadius)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// networkHealthHistory lists the network health snapshots, newest first
+func (s *server) networkHealthHistory(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			badRequest(w, "limit must be a positive whole number")
+			return
+		}
+	}
+
+	snapshots, err := fbo.ListNetworkSnapshots(s.db, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if snapshots == nil {
+		snapshots = []fbo.NetworkSnapshot{}
+	}
+
+	writeJSON(w, http.StatusOK, snapshots)
+}
+
+// recordNetworkSnapshot records the network's health after a change, logging rather than failing if it can't
+func (s *server) recordNetworkSnapshot(reason string) {
+	cfg := config.Load()
+
+	_, err := fbo.RecordNetworkSnapshot(s.db, reason, cfg.OptimalDistance, cfg.MaxDistance, cfg.CoverageRadius)
+	if err != nil {
+		log.Printf("Error recording network snapshot: %v", err)
+	}
+}
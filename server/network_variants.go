@@ -0,0 +1,51 @@
+package server
+
+import (
+	"github.com/julietrb1/offair-cli/fbo"
+	"net/http"
+)
+
+// listNetworkVariants lists the names of the saved network variants
+func (s *server) listNetworkVariants(w http.ResponseWriter, r *http.Request) {
+	names, err := fbo.ListNetworkVariants(s.db)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if names == nil {
+		names = []string{}
+	}
+	writeJSON(w, http.StatusOK, names)
+}
+
+// compareNetworkVariants compares the network variants named by a and b, either of which is the current network if blank
+func (s *server) compareNetworkVariants(w http.ResponseWriter, r *http.Request) {
+	cfg, err := analysisConfig(r.URL.Query(), networkParams)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var variants []fbo.NetworkVariant
+	for _, name := range []string{r.URL.Query().Get("a"), r.URL.Query().Get("b")} {
+		var variant fbo.NetworkVariant
+		if name == "" {
+			variant, err = fbo.CurrentNetwork(s.db)
+		} else {
+			variant, err = fbo.GetNetworkVariant(s.db, name)
+		}
+		if err != nil {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		variants = append(variants, variant)
+	}
+
+	comparison, err := fbo.AnalyseNetworkVariants(s.db, variants[0], variants[1], cfg.OptimalDistance, cfg.MaxDistance, cfg.CoverageRadius)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, comparison)
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultAddr is the address the server listens on by default, which only accepts local connections
+const DefaultAddr = "127.0.0.1:8080"
+
+// server serves the local database and analyses over HTTP
+type server struct {
+	db *sqlx.DB
+}
+
+// NewHandler creates the HTTP handler for the REST API and web UI, served on addr
+func NewHandler(db *sqlx.DB, addr string) http.Handler {
+	s := &server{db: db}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/airports", s.listAirports)
+	mux.HandleFunc("POST /api/airports", s.createAirport)
+	mux.HandleFunc("GET /api/airports/nearby", s.nearbyAirports)
+	mux.HandleFunc("GET /api/airports/pinned", s.pinnedAirports)
+	mux.HandleFunc("GET /api/airports/{icao}", s.getAirport)
+	mux.HandleFunc("GET /api/airports/{icao}/nearest-fbos", s.nearestFBOs)
+	mux.HandleFunc("PATCH /api/airports/{icao}", s.updateAirport)
+	mux.HandleFunc("DELETE /api/airports/{icao}", s.deleteAirport)
+
+	mux.HandleFunc("GET /api/fbos", s.listFBOs)
+	mux.HandleFunc("POST /api/fbos", s.addFBO)
+	mux.HandleFunc("DELETE /api/fbos/{icao}", s.removeFBO)
+
+	mux.HandleFunc("GET /api/distance", s.distance)
+	mux.HandleFunc("GET /api/analyses/defaults", s.analysisDefaults)
+	mux.HandleFunc("GET /api/analyses/optimal-locations", s.optimalLocations)
+	mux.HandleFunc("GET /api/analyses/optimal-locations/{icao}", s.explainOptimalLocation)
+	mux.HandleFunc("GET /api/analyses/redundant-fbos", s.redundantFBOs)
+	mux.HandleFunc("POST /api/analyses/what-if", s.whatIf)
+	mux.HandleFunc("GET /api/analyses/catchments", s.catchments)
+	mux.HandleFunc("GET /api/analyses/coverage-gaps", s.coverageGaps)
+	mux.HandleFunc("GET /api/analyses/clusters", s.clusters)
+	mux.HandleFunc("GET /api/analyses/backbone", s.backbone)
+	mux.HandleFunc("GET /api/analyses/centrality", s.centrality)
+	mux.HandleFunc("GET /api/analyses/relocations", s.relocations)
+	mux.HandleFunc("GET /api/analyses/rebalance", s.rebalance)
+	mux.HandleFunc("GET /api/analyses/demand", s.demand)
+	mux.HandleFunc("GET /api/analyses/sweep", s.sweep)
+	mux.HandleFunc("GET /api/analyses/simulation", s.simulation)
+	mux.HandleFunc("GET /api/analyses/corridor", s.corridor)
+
+	mux.HandleFunc("GET /api/network-health", s.networkHealth)
+	mux.HandleFunc("GET /api/network-health/history", s.networkHealthHistory)
+
+	mux.HandleFunc("GET /api/network-variants", s.listNetworkVariants)
+	mux.HandleFunc("GET /api/network-variants/compare", s.compareNetworkVariants)
+
+	mux.Handle("GET /", webHandler())
+
+	return logRequests(checkRequestSource(mux, allowedHosts(addr)))
+}
+
+// ListenAndServe serves the REST API on addr until the server fails
+func ListenAndServe(db *sqlx.DB, addr string) error {
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           NewHandler(db, addr),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return httpServer.ListenAndServe()
+}
+
+// logRequests logs each request and how long it took
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		log.Printf("%s %s (%s)", r.Method, r.URL.RequestURI(), time.Since(start).Round(time.Millisecond))
+	})
+}
+
+// allowedHosts returns the host names the server answers to: the loopback names, and addr's host if it has one
+func allowedHosts(addr string) map[string]bool {
+	hosts := map[string]bool{"localhost": true, "127.0.0.1": true, "::1": true}
+	if host, _, err := net.SplitHostPort(addr); err == nil && host != "" {
+		hosts[strings.ToLower(host)] = true
+	}
+	return hosts
+}
+
+// checkRequestSource rejects requests from other websites: any request for a host that isn't allowed, which stops DNS
+// rebinding, any request with an Origin header that isn't this server, and any request that changes data without a
+// JSON body, which a page could otherwise send without a CORS preflight
+func checkRequestSource(next http.Handler, hosts map[string]bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.ToLower(strings.Trim(host, "[]"))
+		if !hosts[host] {
+			writeError(w, http.StatusForbidden, fmt.Errorf("requests for host %s are not allowed", r.Host))
+			return
+		}
+
+		if origin := r.Header.Get("Origin"); origin != "" {
+			parsed, err := url.Parse(origin)
+			if err != nil || parsed.Host != r.Host {
+				writeError(w, http.StatusForbidden, fmt.Errorf("requests from %s are not allowed", origin))
+				return
+			}
+		}
+
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || mediaType != "application/json" {
+				writeError(w, http.StatusUnsupportedMediaType, fmt.Errorf("the Content-Type must be application/json"))
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeJSON writes a value as a JSON response
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
+
+// writeError writes an error as a JSON response, using 404 for anything not found
+func writeError(w http.ResponseWriter, status int, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		status = http.StatusNotFound
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// badRequest writes a 400 response with a formatted message
+func badRequest(w http.ResponseWriter, format string, args ...interface{}) {
+	writeError(w, http.StatusBadRequest, fmt.Errorf(format, args...))
+}
@@ -0,0 +1,468 @@
+'use strict';
+
+// OffAir map UI. Draws the bundled coastline, airports and FBOs on an equirectangular
+// projection, and runs the FBO analyses through the local REST API.
+
+const canvas = document.getElementById('map');
+const ctx = canvas.getContext('2d');
+const statusBox = document.getElementById('status');
+
+const state = {
+    coastline: [],
+    airports: [],
+    candidates: new Map(),
+    redundant: new Map(),
+    selected: null,
+    // View centre in degrees and scale in pixels per degree of latitude
+    centerLon: 134,
+    centerLat: -27,
+    scale: 12,
+};
+
+const sliders = {
+    optimal: document.getElementById('optimal'),
+    max: document.getElementById('max'),
+    lights: document.getElementById('lights'),
+    size: document.getElementById('size'),
+};
+
+// api fetches a JSON endpoint, throwing the API's error message on failure
+async function api(path) {
+    const response = await fetch(path);
+    const body = await response.json();
+    if (!response.ok) {
+        throw new Error(body.error || response.statusText);
+    }
+    return body;
+}
+
+function setStatus(message, isError) {
+    statusBox.textContent = message || '';
+    statusBox.className = isError ? 'error' : '';
+}
+
+// Projection
+
+function lonScale() {
+    return Math.cos(state.centerLat * Math.PI / 180);
+}
+
+function project(lon, lat) {
+    return [
+        canvas.width / 2 + (lon - state.centerLon) * state.scale * lonScale(),
+        canvas.height / 2 - (lat - state.centerLat) * state.scale,
+    ];
+}
+
+function unproject(x, y) {
+    return [
+        state.centerLon + (x - canvas.width / 2) / (state.scale * lonScale()),
+        state.centerLat - (y - canvas.height / 2) / state.scale,
+    ];
+}
+
+function hasPosition(airport) {
+    return airport.latitude !== null && airport.longitude !== null;
+}
+
+// distanceNM is the great-circle distance between two airports, matching the CLI's calculation
+function distanceNM(a, b) {
+    const toRad = Math.PI / 180;
+    const dLat = (b.latitude - a.latitude) * toRad;
+    const dLon = (b.longitude - a.longitude) * toRad;
+    const h = Math.sin(dLat / 2) ** 2 +
+        Math.cos(a.latitude * toRad) * Math.cos(b.latitude * toRad) * Math.sin(dLon / 2) ** 2;
+    return 2 * 3440.0 * Math.asin(Math.sqrt(h));
+}
+
+// fitToAirports centres the view on the airports in the database
+function fitToAirports() {
+    const positioned = state.airports.filter(hasPosition);
+    if (positioned.length === 0) {
+        return;
+    }
+
+    const lons = positioned.map(a => a.longitude);
+    const lats = positioned.map(a => a.latitude);
+    const minLon = Math.min(...lons), maxLon = Math.max(...lons);
+    const minLat = Math.min(...lats), maxLat = Math.max(...lats);
+
+    state.centerLon = (minLon + maxLon) / 2;
+    state.centerLat = (minLat + maxLat) / 2;
+    const spanX = Math.max(maxLon - minLon, 1) * lonScale();
+    const spanY = Math.max(maxLat - minLat, 1);
+    state.scale = 0.85 * Math.min(canvas.width / spanX, canvas.height / spanY);
+}
+
+// Drawing
+
+function resize() {
+    canvas.width = canvas.clientWidth;
+    canvas.height = canvas.clientHeight;
+    draw();
+}
+
+function draw() {
+    ctx.fillStyle = '#cfe3f0';
+    ctx.fillRect(0, 0, canvas.width, canvas.height);
+
+    drawCoastline();
+    drawLegs();
+
+    const fbos = state.airports.filter(a => a.has_fbo && hasPosition(a));
+    const others = state.airports.filter(a => !a.has_fbo && hasPosition(a));
+
+    for (const airport of others) {
+        if (state.candidates.has(airport.icao)) {
+            drawPoint(airport, 5, '#16a34a');
+        } else {
+            drawPoint(airport, 2, '#6b7280');
+        }
+    }
+    for (const airport of fbos) {
+        drawPoint(airport, 5, state.redundant.has(airport.icao) ? '#dc2626' : '#1d4ed8');
+    }
+
+    if (state.selected && hasPosition(state.selected)) {
+        const [x, y] = project(state.selected.longitude, state.selected.latitude);
+        ctx.strokeStyle = '#111827';
+        ctx.lineWidth = 2;
+        ctx.beginPath();
+        ctx.arc(x, y, 9, 0, 2 * Math.PI);
+        ctx.stroke();
+    }
+}
+
+function drawCoastline() {
+    ctx.fillStyle = '#f3efe0';
+    ctx.strokeStyle = '#a8a29e';
+    ctx.lineWidth = 1;
+
+    for (const feature of state.coastline) {
+        const polygons = feature.geometry.type === 'MultiPolygon'
+            ? feature.geometry.coordinates
+            : [feature.geometry.coordinates];
+
+        for (const polygon of polygons) {
+            ctx.beginPath();
+            for (const ring of polygon) {
+                ring.forEach(([lon, lat], i) => {
+                    const [x, y] = project(lon, lat);
+                    if (i === 0) {
+                        ctx.moveTo(x, y);
+                    } else {
+                        ctx.lineTo(x, y);
+                    }
+                });
+                ctx.closePath();
+            }
+            ctx.fill('evenodd');
+            ctx.stroke();
+        }
+    }
+}
+
+// drawLegs draws every FBO-to-FBO leg within the max distance slider
+function drawLegs() {
+    const maxDistance = Number(sliders.max.value);
+    const fbos = state.airports.filter(a => a.has_fbo && hasPosition(a));
+
+    ctx.strokeStyle = 'rgba(29, 78, 216, 0.35)';
+    ctx.lineWidth = 1;
+    ctx.beginPath();
+    for (let i = 0; i < fbos.length; i++) {
+        for (let j = i + 1; j < fbos.length; j++) {
+            if (distanceNM(fbos[i], fbos[j]) <= maxDistance) {
+                const [x1, y1] = project(fbos[i].longitude, fbos[i].latitude);
+                const [x2, y2] = project(fbos[j].longitude, fbos[j].latitude);
+                ctx.moveTo(x1, y1);
+                ctx.lineTo(x2, y2);
+            }
+        }
+    }
+    ctx.stroke();
+}
+
+function drawPoint(airport, radius, colour) {
+    const [x, y] = project(airport.longitude, airport.latitude);
+    if (x < -radius || y < -radius || x > canvas.width + radius || y > canvas.height + radius) {
+        return;
+    }
+    ctx.fillStyle = colour;
+    ctx.beginPath();
+    ctx.arc(x, y, radius, 0, 2 * Math.PI);
+    ctx.fill();
+}
+
+// Interaction
+
+function airportAt(x, y) {
+    let best = null;
+    let bestDistance = 8 * 8;
+    for (const airport of state.airports) {
+        if (!hasPosition(airport)) {
+            continue;
+        }
+        const [ax, ay] = project(airport.longitude, airport.latitude);
+        const d = (ax - x) ** 2 + (ay - y) ** 2;
+        // Prefer FBOs when points overlap
+        if (d < bestDistance || (d === bestDistance && airport.has_fbo)) {
+            best = airport;
+            bestDistance = d;
+        }
+    }
+    return best;
+}
+
+let drag = null;
+
+canvas.addEventListener('mousedown', event => {
+    drag = {x: event.offsetX, y: event.offsetY, moved: false};
+});
+
+canvas.addEventListener('mousemove', event => {
+    if (!drag) {
+        return;
+    }
+    const dx = event.offsetX - drag.x;
+    const dy = event.offsetY - drag.y;
+    if (Math.abs(dx) + Math.abs(dy) > 2) {
+        drag.moved = true;
+        canvas.classList.add('dragging');
+    }
+    if (drag.moved) {
+        state.centerLon -= dx / (state.scale * lonScale());
+        state.centerLat += dy / state.scale;
+        state.centerLat = Math.max(-85, Math.min(85, state.centerLat));
+        drag.x = event.offsetX;
+        drag.y = event.offsetY;
+        draw();
+    }
+});
+
+window.addEventListener('mouseup', event => {
+    if (drag && !drag.moved && event.target === canvas) {
+        const airport = airportAt(event.offsetX, event.offsetY);
+        if (airport) {
+            selectAirport(airport.icao);
+        }
+    }
+    drag = null;
+    canvas.classList.remove('dragging');
+});
+
+canvas.addEventListener('wheel', event => {
+    event.preventDefault();
+    const [lon, lat] = unproject(event.offsetX, event.offsetY);
+    const factor = event.deltaY < 0 ? 1.2 : 1 / 1.2;
+    state.scale = Math.max(1, Math.min(2000, state.scale * factor));
+    // Keep the point under the cursor fixed while zooming
+    const [x, y] = project(lon, lat);
+    state.centerLon += (x - event.offsetX) / (state.scale * lonScale());
+    state.centerLat -= (y - event.offsetY) / state.scale;
+    draw();
+}, {passive: false});
+
+// Panels
+
+function formatValue(value) {
+    if (value === null || value === undefined || value === '') {
+        return '<span class="muted">-</span>';
+    }
+    if (typeof value === 'boolean') {
+        return value ? 'Yes' : 'No';
+    }
+    if (typeof value === 'number' && !Number.isInteger(value)) {
+        return value.toFixed(4);
+    }
+    return escapeHTML(String(value));
+}
+
+function escapeHTML(text) {
+    return text.replace(/[&<>"']/g, c => ({
+        '&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', '\'': '&#39;',
+    })[c]);
+}
+
+function airportLink(icao) {
+    return `<a data-icao="${escapeHTML(icao)}">${escapeHTML(icao)}</a>`;
+}
+
+async function selectAirport(icao) {
+    const panel = document.getElementById('airport-panel');
+    try {
+        const [airport, nearest] = await Promise.all([
+            api(`/api/airports/${encodeURIComponent(icao)}`),
+            api(`/api/airports/${encodeURIComponent(icao)}/nearest-fbos?limit=5`).catch(() => []),
+        ]);
+        state.selected = airport;
+
+        document.getElementById('airport-title').textContent = `${airport.icao} - ${airport.name}`;
+        document.getElementById('airport-details').innerHTML = Object.entries(airport)
+            .map(([key, value]) => `<tr><td>${escapeHTML(key)}</td><td>${formatValue(value)}</td></tr>`)
+            .join('');
+        document.getElementById('nearest-fbos').innerHTML = nearest.length === 0
+            ? '<li class="muted">No other FBOs</li>'
+            : nearest.map(n => `<li>${airportLink(n.icao)} (${n.distance.toFixed(1)} nm)</li>`).join('');
+
+        panel.hidden = false;
+        setStatus('');
+        draw();
+    } catch (err) {
+        setStatus(err.message, true);
+    }
+}
+
+document.addEventListener('click', event => {
+    const icao = event.target.dataset && event.target.dataset.icao;
+    if (icao) {
+        selectAirport(icao);
+    }
+});
+
+// Analyses
+
+function updateSliderLabels() {
+    document.getElementById('optimal-value').textContent = sliders.optimal.value;
+    document.getElementById('max-value').textContent = sliders.max.value;
+    document.getElementById('lights-value').textContent = sliders.lights.value === '1' ? 'Yes' : 'No';
+    document.getElementById('size-value').textContent = sliders.size.value === '-1' ? 'Any' : sliders.size.value;
+}
+
+function analysisQuery() {
+    const params = new URLSearchParams({
+        optimal: sliders.optimal.value,
+        lights: sliders.lights.value === '1' ? 'true' : 'false',
+    });
+    if (sliders.size.value !== '-1') {
+        params.set('size', sliders.size.value);
+    }
+    return params;
+}
+
+function showResults(title, summary, items) {
+    document.getElementById('results-title').textContent = title;
+    document.getElementById('results-summary').textContent = summary;
+    document.getElementById('results').innerHTML = items.length === 0
+        ? '<li class="muted">None found</li>'
+        : items.join('');
+    document.getElementById('results-panel').hidden = false;
+}
+
+async function runOptimal() {
+    setStatus('Finding optimal FBO locations...');
+    try {
+        const params = analysisQuery();
+        params.set('limit', '10');
+        const analysis = await api(`/api/analyses/optimal-locations?${params}`);
+
+        state.redundant.clear();
+        state.candidates = new Map(analysis.candidates.map(c => [c.airport.icao, c]));
+
+        showResults(
+            'Optimal FBO locations',
+            `${analysis.candidate_count} candidates scored against ${analysis.existing_fbo_count} FBOs`,
+            analysis.candidates.map(c =>
+                `<li>${airportLink(c.airport.icao)} ${escapeHTML(c.airport.name)} ` +
+                `<span class="muted">score ${c.score.toFixed(1)}, ` +
+                `${c.eligible_connections}/${c.total_connections} connections</span></li>`),
+        );
+        setStatus('');
+        draw();
+    } catch (err) {
+        setStatus(err.message, true);
+    }
+}
+
+async function runRedundant() {
+    setStatus('Finding redundant FBOs...');
+    try {
+        const analysis = await api(`/api/analyses/redundant-fbos?${analysisQuery()}`);
+
+        state.candidates.clear();
+        state.redundant = new Map(analysis.redundant.map(r => [r.fbo.icao, r]));
+
+        const before = analysis.initial_metrics;
+        const after = analysis.optimized_metrics;
+        showResults(
+            'Redundant FBOs',
+            `${analysis.fbo_count} FBOs, ${analysis.optimized_fbo_count} after removals. ` +
+            `Efficiency ${before.efficiency_score.toFixed(1)}% to ${after.efficiency_score.toFixed(1)}%, ` +
+            `average leg ${before.average_distance.toFixed(0)} nm to ${after.average_distance.toFixed(0)} nm`,
+            analysis.redundant.map(r =>
+                `<li>${airportLink(r.fbo.icao)} ${escapeHTML(r.fbo.name)} ` +
+                `<span class="muted">score ${r.score.toFixed(1)}, nearest ` +
+                r.nearest_alternatives.map(n => `${escapeHTML(n.icao)} ${n.distance.toFixed(0)} nm`).join(', ') +
+                '</span></li>'),
+        );
+        setStatus('');
+        draw();
+    } catch (err) {
+        setStatus(err.message, true);
+    }
+}
+
+// runRelocations suggests FBO moves, marking each FBO that moves as redundant and its destination as a candidate
+async function runRelocations() {
+    setStatus('Suggesting FBO relocations...');
+    try {
+        const params = analysisQuery();
+        params.set('max', sliders.max.value);
+        const analysis = await api(`/api/analyses/relocations?${params}`);
+
+        state.redundant = new Map(analysis.relocations.map(r => [r.from.icao, r]));
+        state.candidates = new Map(analysis.relocations.map(r => [r.to.icao, r]));
+
+        showResults(
+            'FBO relocations',
+            `${analysis.considered} moves within ${analysis.radius.toFixed(0)} nm considered`,
+            analysis.relocations.map(r =>
+                `<li>${airportLink(r.from.icao)} to ${airportLink(r.to.icao)} ${escapeHTML(r.to.name)} ` +
+                `<span class="muted">${r.distance.toFixed(0)} nm, improvement ${r.improvement.toFixed(1)}</span></li>`),
+        );
+        setStatus('');
+        draw();
+    } catch (err) {
+        setStatus(err.message, true);
+    }
+}
+
+for (const slider of Object.values(sliders)) {
+    slider.addEventListener('input', () => {
+        updateSliderLabels();
+        draw();
+    });
+}
+document.getElementById('run-optimal').addEventListener('click', runOptimal);
+document.getElementById('run-redundant').addEventListener('click', runRedundant);
+document.getElementById('run-relocations').addEventListener('click', runRelocations);
+window.addEventListener('resize', resize);
+
+async function init() {
+    setStatus('Loading...');
+    try {
+        const [coastline, airports, defaults] = await Promise.all([
+            api('coastline.geojson'),
+            api('/api/airports'),
+            api('/api/analyses/defaults'),
+        ]);
+        state.coastline = coastline.features;
+        state.airports = airports;
+
+        sliders.optimal.value = defaults.optimal_distance;
+        sliders.max.value = defaults.max_distance;
+        sliders.lights.value = defaults.require_lights ? '1' : '0';
+        sliders.size.value = defaults.preferred_size === null ? '-1' : defaults.preferred_size;
+        updateSliderLabels();
+
+        canvas.width = canvas.clientWidth;
+        canvas.height = canvas.clientHeight;
+        fitToAirports();
+        setStatus('');
+        draw();
+    } catch (err) {
+        setStatus(err.message, true);
+    }
+}
+
+init();